@@ -0,0 +1,40 @@
+package ranobehub
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var BaseUrl = "https://ranobehub.org"
+
+func apiUrl() string {
+	return BaseUrl + "/api"
+}
+func GetUniqueName(urlStr string) (string, error) {
+	const nameIndex = 2
+
+	if url, err := url.Parse(urlStr); err != nil {
+		return "", err
+	} else {
+		parts := strings.Split(url.Path, "/")
+
+		if len(parts) <= nameIndex || parts[nameIndex] == "" {
+			return "", fmt.Errorf("Ranobe name not found in url: %s", urlStr)
+		}
+		return parts[nameIndex], nil
+	}
+}
+func GetRanobeId(uniqueName string) (string, error) {
+	id, _, _ := strings.Cut(uniqueName, "-")
+
+	for _, char := range id {
+		if char < '0' || char > '9' {
+			return "", fmt.Errorf("Invalid ranobe name: %s", uniqueName)
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("Invalid ranobe name: %s", uniqueName)
+	}
+	return id, nil
+}
@@ -0,0 +1,79 @@
+package ranobehub
+
+import (
+	"fmt"
+	"ranobedl/util"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type chapterContent struct {
+	RanobeId string
+	Volume   string
+	Number   string
+}
+
+func (self *chapterContent) constructUrl() string {
+	return fmt.Sprintf(
+		"%s/ranobe/%s/%s/%s",
+		BaseUrl,
+		self.RanobeId,
+		self.Volume,
+		self.Number,
+	)
+}
+func (self *chapterContent) isTextContainer(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.Data != "div" {
+		return false
+	}
+	for _, attr := range node.Attr {
+		if attr.Key == "class" && slices.Contains(strings.Fields(attr.Val), "text") {
+			return true
+		}
+	}
+	return false
+}
+func (self *chapterContent) findTextContainer(node *html.Node) *html.Node {
+	if self.isTextContainer(node) {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if container := self.findTextContainer(child); container != nil {
+			return container
+		}
+	}
+	return nil
+}
+func (self *chapterContent) Parse() (*html.Node, error) {
+	response, err := util.SendRequest(self.constructUrl())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	document, err := html.Parse(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if container := self.findTextContainer(document); container == nil {
+		return nil, fmt.Errorf("Chapter text not found: %s", self.constructUrl())
+	} else {
+		return container, nil
+	}
+}
+func GetChapterContent(uniqueName string, volume string, number string) (*html.Node, error) {
+	if ranobeId, err := GetRanobeId(uniqueName); err != nil {
+		return nil, err
+	} else {
+		return (&chapterContent{
+			RanobeId: ranobeId,
+			Volume:   volume,
+			Number:   number,
+		}).Parse()
+	}
+}
+func GetMediaUrl(mediaId string) string {
+	return fmt.Sprintf("%s/media/%s", apiUrl(), mediaId)
+}
@@ -0,0 +1,50 @@
+package ranobehub
+
+import (
+	"encoding/json"
+	"fmt"
+	"ranobedl/util"
+)
+
+type chapterData struct {
+	Id   int    `json:"id"`
+	Num  int    `json:"num"`
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+type volumeData struct {
+	Id       int           `json:"id"`
+	Num      int           `json:"num"`
+	Name     string        `json:"name"`
+	Chapters []chapterData `json:"chapters"`
+}
+type contents struct {
+	RanobeId string
+}
+
+func (self *contents) constructUrl() string {
+	return fmt.Sprintf("%s/ranobe/%s/contents", apiUrl(), self.RanobeId)
+}
+func (self *contents) Parse() ([]volumeData, error) {
+	output := struct {
+		Volumes []volumeData `json:"volumes"`
+	}{}
+	if response, err := util.SendRequest(self.constructUrl()); err != nil {
+		return output.Volumes, err
+	} else {
+		defer response.Body.Close()
+
+		if err := json.NewDecoder(response.Body).Decode(&output); err != nil {
+			return output.Volumes, err
+		} else {
+			return output.Volumes, nil
+		}
+	}
+}
+func GetContents(uniqueName string) ([]volumeData, error) {
+	if ranobeId, err := GetRanobeId(uniqueName); err != nil {
+		return nil, err
+	} else {
+		return (&contents{RanobeId: ranobeId}).Parse()
+	}
+}
@@ -0,0 +1,106 @@
+package ranobehub
+
+import (
+	"encoding/json"
+	"fmt"
+	"ranobedl/util"
+)
+
+type names struct {
+	Rus      string `json:"rus"`
+	Eng      string `json:"eng"`
+	Original string `json:"original"`
+}
+type author struct {
+	NameRus string `json:"name_rus"`
+	NameEng string `json:"name_eng"`
+}
+type titled struct {
+	Title string `json:"title"`
+}
+type tags struct {
+	Genres []titled `json:"genres"`
+	Events []titled `json:"events"`
+}
+type posters struct {
+	Big    string `json:"big"`
+	Medium string `json:"medium"`
+}
+type RanobeInfoData struct {
+	Id          int      `json:"id"`
+	Names       names    `json:"names"`
+	Synonyms    []string `json:"synonyms"`
+	Authors     []author `json:"authors"`
+	Description string   `json:"description"`
+	Tags        tags     `json:"tags"`
+	Status      titled   `json:"status"`
+	Year        int      `json:"year"`
+	Posters     posters  `json:"posters"`
+}
+
+func (self *RanobeInfoData) Name() string {
+	if self.Names.Rus != "" {
+		return self.Names.Rus
+	}
+	if self.Names.Eng != "" {
+		return self.Names.Eng
+	}
+	return self.Names.Original
+}
+func titles(items []titled) []string {
+	output := make([]string, 0, len(items))
+
+	for _, item := range items {
+		output = append(output, item.Title)
+	}
+	return output
+}
+func (self *RanobeInfoData) GenreNames() []string {
+	return titles(self.Tags.Genres)
+}
+func (self *RanobeInfoData) TagNames() []string {
+	return titles(self.Tags.Events)
+}
+func (self *RanobeInfoData) PosterUrl() string {
+	if self.Posters.Big != "" {
+		return self.Posters.Big
+	}
+	return self.Posters.Medium
+}
+func (self *author) Name() string {
+	if self.NameRus != "" {
+		return self.NameRus
+	}
+	return self.NameEng
+}
+
+type ranobeInfo struct {
+	RanobeId string
+}
+
+func (self *ranobeInfo) constructUrl() string {
+	return fmt.Sprintf("%s/ranobe/%s", apiUrl(), self.RanobeId)
+}
+func (self *ranobeInfo) Parse() (RanobeInfoData, error) {
+	output := struct {
+		Data RanobeInfoData `json:"data"`
+	}{}
+	if response, err := util.SendRequest(self.constructUrl()); err != nil {
+		return output.Data, err
+	} else {
+		defer response.Body.Close()
+
+		if err := json.NewDecoder(response.Body).Decode(&output); err != nil {
+			return output.Data, err
+		} else {
+			return output.Data, nil
+		}
+	}
+}
+func GetRanobeInfo(uniqueName string) (RanobeInfoData, error) {
+	if ranobeId, err := GetRanobeId(uniqueName); err != nil {
+		return RanobeInfoData{}, err
+	} else {
+		return (&ranobeInfo{RanobeId: ranobeId}).Parse()
+	}
+}
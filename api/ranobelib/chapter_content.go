@@ -3,6 +3,7 @@ package ranobelib
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"ranobedl/schema"
 	"ranobedl/util"
 )
@@ -33,15 +34,22 @@ type chapterContent struct {
 	UniqueName string
 	Number     string
 	Volume     string
+	BranchId   string
 }
 
 func (self *chapterContent) constructUrl() string {
+	query := url.Values{}
+	query.Set("number", self.Number)
+	query.Set("volume", self.Volume)
+
+	if self.BranchId != "" {
+		query.Set("branch_id", self.BranchId)
+	}
 	return fmt.Sprintf(
-		"%s/manga/%s/chapter?number=%s&volume=%s",
+		"%s/manga/%s/chapter?%s",
 		apiUrl,
 		self.UniqueName,
-		self.Number,
-		self.Volume,
+		query.Encode(),
 	)
 }
 func (self *chapterContent) Parse() (ChapterContentData, error) {
@@ -60,10 +68,11 @@ func (self *chapterContent) Parse() (ChapterContentData, error) {
 		}
 	}
 }
-func GetChapterContent(uniqueName string, number string, volume string) (ChapterContentData, error) {
+func GetChapterContent(uniqueName string, number string, volume string, branchId string) (ChapterContentData, error) {
 	return (&chapterContent{
 		UniqueName: uniqueName,
 		Number:     number,
 		Volume:     volume,
+		BranchId:   branchId,
 	}).Parse()
 }
@@ -3,14 +3,14 @@ package ranobelib
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"ranobedl/util"
 )
 
 type user struct {
 	Username string `json:"username"`
 	Id       int    `json:"id"`
 }
-type branch struct {
+type Branch struct {
 	Id          int    `json:"id"`
 	BranchId    int    `json:"branch_id"`
 	CreatedAt   string `json:"created_at"`
@@ -18,6 +18,18 @@ type branch struct {
 	ExpiredType int    `json:"expired_type"`
 	User        user   `json:"user"`
 }
+func (self *Branch) Revision() string {
+	return fmt.Sprintf("%d@%s", self.Id, self.CreatedAt)
+}
+func (self *Branch) TeamNames() []string {
+	output := make([]string, 0, len(self.Teams))
+
+	for _, team := range self.Teams {
+		output = append(output, team.Name)
+	}
+	return output
+}
+
 type chapterInfoData struct {
 	Id              int      `json:"id"`
 	Index           int      `json:"index"`
@@ -27,8 +39,16 @@ type chapterInfoData struct {
 	NumberSecondary string   `json:"number_secondary"`
 	Name            string   `json:"name"`
 	BranchesCount   int      `json:"branches_count"`
-	Branches        []branch `json:"branches"`
+	Branches        []Branch `json:"branches"`
 }
+
+func (self *chapterInfoData) Revision() string {
+	if len(self.Branches) == 0 {
+		return ""
+	}
+	return self.Branches[0].Revision()
+}
+
 type chapterInfo struct {
 	uniqueName string
 }
@@ -40,7 +60,7 @@ func (self *chapterInfo) Parse() ([]chapterInfoData, error) {
 	output := struct {
 		Data []chapterInfoData `json:"data"`
 	}{}
-	if response, err := http.Get(self.constructUrl()); err != nil {
+	if response, err := util.SendRequest(self.constructUrl()); err != nil {
 		return output.Data, err
 	} else {
 		defer response.Body.Close()
@@ -3,28 +3,83 @@ package ranobelib
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"net/url"
+	"ranobedl/util"
 )
 
 type author struct {
 	Name string `json:"name"`
 }
-type ranobeInfoData struct {
-	Name    string   `json:"name"`
-	Authors []author `json:"authors"`
+type label struct {
+	Id    int    `json:"id"`
+	Label string `json:"label"`
 }
+type namedItem struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+type RanobeInfoData struct {
+	Name           string      `json:"name"`
+	RusName        string      `json:"rus_name"`
+	EngName        string      `json:"eng_name"`
+	OtherNames     []string    `json:"otherNames"`
+	Summary        string      `json:"summary"`
+	Authors        []author    `json:"authors"`
+	Publisher      []namedItem `json:"publisher"`
+	Genres         []namedItem `json:"genres"`
+	Tags           []namedItem `json:"tags"`
+	Status         label       `json:"status"`
+	AgeRestriction label       `json:"ageRestriction"`
+	Type           label       `json:"type"`
+	ReleaseDate    string      `json:"releaseDate"`
+	Cover          cover       `json:"cover"`
+}
+
+func names(items []namedItem) []string {
+	output := make([]string, 0, len(items))
+
+	for _, item := range items {
+		output = append(output, item.Name)
+	}
+	return output
+}
+func (self *RanobeInfoData) GenreNames() []string {
+	return names(self.Genres)
+}
+func (self *RanobeInfoData) TagNames() []string {
+	return names(self.Tags)
+}
+func (self *RanobeInfoData) Year() string {
+	if len(self.ReleaseDate) >= 4 {
+		return self.ReleaseDate[:4]
+	}
+	return self.ReleaseDate
+}
+
+var ranobeInfoFields = []string{
+	"authors",
+	"publisher",
+	"otherNames",
+	"summary",
+	"genres",
+	"tags",
+	"status_id",
+	"releaseDate",
+}
+
 type ranobeInfo struct {
 	UniqueName string
 }
 
 func (self *ranobeInfo) constructUrl() string {
-	return fmt.Sprintf("%s/manga/%s?fields[]=authors", apiUrl, self.UniqueName)
+	query := url.Values{"fields[]": ranobeInfoFields}
+	return fmt.Sprintf("%s/manga/%s?%s", apiUrl, self.UniqueName, query.Encode())
 }
-func (self *ranobeInfo) Parse() (ranobeInfoData, error) {
+func (self *ranobeInfo) Parse() (RanobeInfoData, error) {
 	output := struct {
-		Data ranobeInfoData `json:"data"`
+		Data RanobeInfoData `json:"data"`
 	}{}
-	if response, err := http.Get(self.constructUrl()); err != nil {
+	if response, err := util.SendRequest(self.constructUrl()); err != nil {
 		return output.Data, err
 	} else {
 		defer response.Body.Close()
@@ -36,7 +91,7 @@ func (self *ranobeInfo) Parse() (ranobeInfoData, error) {
 		}
 	}
 }
-func GetRanobeInfo(uniqueName string) (ranobeInfoData, error) {
+func GetRanobeInfo(uniqueName string) (RanobeInfoData, error) {
 	ranobeInfo := ranobeInfo{UniqueName: uniqueName}
 	return ranobeInfo.Parse()
 }
@@ -0,0 +1,26 @@
+package cachemgr
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const volumesDirname = "volumes"
+const emptyVolumeDirname = "_"
+
+func pathSegment(str string) string {
+	if str == "" {
+		return emptyVolumeDirname
+	} else if str == "." || str == ".." {
+		return strings.ReplaceAll(str, ".", "%2E")
+	}
+	return url.PathEscape(str)
+}
+func ChapterPath(ranobeProvider RanobeProvider, uniqueName string, volume string, number string) (string, error) {
+	if ranobeDir, err := ConstructPath(ranobeProvider, uniqueName); err != nil {
+		return "", err
+	} else {
+		return filepath.Join(ranobeDir, volumesDirname, pathSegment(volume), pathSegment(number)+".json"), nil
+	}
+}
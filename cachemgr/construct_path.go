@@ -5,22 +5,15 @@ import (
 	"path/filepath"
 )
 
-type RanobeProvider int
+type RanobeProvider string
 
 const (
-	RanobeLib RanobeProvider = iota
-	RanobeHub
+	RanobeLib RanobeProvider = "ranobelib"
+	RanobeHub RanobeProvider = "ranobehub"
 )
 
 func (self *RanobeProvider) String() string {
-	switch *self {
-	case RanobeLib:
-		return "ranobelib"
-	case RanobeHub:
-		return "ranobehub"
-	default:
-		return ""
-	}
+	return string(*self)
 }
 func ConstructPath(ranobeProvider RanobeProvider, uniqueName string) (string, error) {
 	if cacheDir, err := os.UserCacheDir(); err != nil {
@@ -0,0 +1,26 @@
+package cachemgr
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+const CoverName = "cover"
+const CustomCoverName = "custom-cover"
+
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".avif": true,
+}
+
+func imageExtension(urlStr string) string {
+	if url, err := url.Parse(urlStr); err == nil {
+		if extension := strings.ToLower(path.Ext(url.Path)); imageExtensions[extension] {
+			return extension
+		}
+	}
+	return ".jpg"
+}
+func DownloadCover(ranobeProvider RanobeProvider, uniqueName string, url string, name string) (string, error) {
+	return DownloadImage(ranobeProvider, uniqueName, url, name+imageExtension(url))
+}
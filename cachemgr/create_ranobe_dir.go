@@ -9,6 +9,6 @@ func CreateRanobeDir(ranobeProvider RanobeProvider, uniqueName string) error {
 		return err
 	} else {
 		os.MkdirAll(ranobeDir, 0777)
-		return nil
+		return Migrate(ranobeProvider, uniqueName)
 	}
 }
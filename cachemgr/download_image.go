@@ -1,12 +1,9 @@
 package cachemgr
 
 import (
-	"fmt"
 	"io"
-	"net/http"
-	"os"
 	"path/filepath"
-	"time"
+	"ranobedl/util"
 )
 
 type imageDownloader struct {
@@ -25,49 +22,23 @@ func cImageDownloader(ranobeProvider RanobeProvider, uniqueName string, url stri
 		Filename:       filename,
 	}
 }
-func (self *imageDownloader) httpGet() (*http.Response, error) {
-	if response, err := http.Get(self.Url); err != nil {
-		return nil, err
-	} else {
-		if response.StatusCode == http.StatusTooManyRequests {
-			defer response.Body.Close()
-
-			time.Sleep(time.Second)
-			return self.httpGet()
-		}
-		if response.StatusCode != http.StatusOK {
-			defer response.Body.Close()
-
-			return nil, fmt.Errorf("Status code not 200, %s", response.Status)
-		}
-		return response, nil
-	}
-}
-func (self *imageDownloader) openFile() (*os.File, string, error) {
-	if ranobeDir, err := ConstructPath(self.RanobeProvider, self.UniqueName); err != nil {
-		return nil, "", err
-	} else {
-
-		path := filepath.Join(ranobeDir, self.Filename)
-		file, err := os.Create(path)
-
-		return file, path, err
-	}
-}
 func (self *imageDownloader) saveImage(stream io.Reader) (string, error) {
-	if file, path, err := self.openFile(); err != nil {
+	if ranobeDir, err := ConstructPath(self.RanobeProvider, self.UniqueName); err != nil {
 		return "", err
 	} else {
+		path := filepath.Join(ranobeDir, self.Filename)
 
-		if _, err := io.Copy(file, stream); err != nil {
+		if err := util.WriteFileAtomic(path, func(writer io.Writer) error {
+			_, err := io.Copy(writer, stream)
+			return err
+		}); err != nil {
 			return "", err
-		} else {
-			return path, nil
 		}
+		return path, nil
 	}
 }
 func (self *imageDownloader) Download() (string, error) {
-	if response, err := self.httpGet(); err != nil {
+	if response, err := util.SendRequest(self.Url); err != nil {
 		return "", err
 	} else {
 		defer response.Body.Close()
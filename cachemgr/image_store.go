@@ -0,0 +1,143 @@
+package cachemgr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"ranobedl/util"
+	"sync"
+)
+
+const imagesDirname = "images"
+const imageIndexFilename = "ImageIndex.json"
+const incompleteImagePrefix = "download-"
+
+type imageIndex struct {
+	mutex  sync.Mutex
+	loaded bool
+	urls   map[string]string
+}
+
+var imageIndexes sync.Map
+
+func ImagesDir(ranobeProvider RanobeProvider, uniqueName string) (string, error) {
+	if ranobeDir, err := ConstructPath(ranobeProvider, uniqueName); err != nil {
+		return "", err
+	} else {
+		return filepath.Join(ranobeDir, imagesDirname), nil
+	}
+}
+func ImagePath(ranobeProvider RanobeProvider, uniqueName string, src string) (string, error) {
+	if filepath.IsAbs(src) {
+		return src, nil
+	}
+	if imagesDir, err := ImagesDir(ranobeProvider, uniqueName); err != nil {
+		return "", err
+	} else {
+		return filepath.Join(imagesDir, filepath.Base(src)), nil
+	}
+}
+func loadImageIndex(ranobeProvider RanobeProvider, uniqueName string) (*imageIndex, error) {
+	ranobeDir, err := ConstructPath(ranobeProvider, uniqueName)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := imageIndexes.LoadOrStore(ranobeDir, &imageIndex{})
+	index := value.(*imageIndex)
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if !index.loaded {
+		index.urls = map[string]string{}
+
+		if present, err := isPresent(ranobeProvider, uniqueName, imageIndexFilename); err != nil {
+			return nil, err
+		} else if present {
+			if err := loadJson(ranobeProvider, uniqueName, imageIndexFilename, &index.urls); err != nil {
+				return nil, err
+			}
+		}
+		index.loaded = true
+	}
+	return index, nil
+}
+func (self *imageIndex) lookup(imagesDir string, url string) (string, bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if name, found := self.urls[url]; !found {
+		return "", false
+	} else if _, err := os.Stat(filepath.Join(imagesDir, name)); err != nil {
+		return "", false
+	} else {
+		return name, true
+	}
+}
+func (self *imageIndex) record(ranobeProvider RanobeProvider, uniqueName string, url string, name string) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.urls[url] = name
+	return SaveJson(ranobeProvider, uniqueName, imageIndexFilename, self.urls)
+}
+func saveHashed(imagesDir string, stream io.Reader, extension string) (string, error) {
+	file, err := os.CreateTemp(imagesDir, incompleteImagePrefix+"*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(io.MultiWriter(file, hash), stream); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	name := hex.EncodeToString(hash.Sum(nil)) + extension
+
+	if _, err := os.Stat(filepath.Join(imagesDir, name)); err == nil {
+		return name, nil
+	}
+	if err := os.Rename(file.Name(), filepath.Join(imagesDir, name)); err != nil {
+		return "", err
+	}
+	util.SyncDir(imagesDir)
+	return name, nil
+}
+func StoreImage(ranobeProvider RanobeProvider, uniqueName string, url string, extension string) (string, error) {
+	imagesDir, err := ImagesDir(ranobeProvider, uniqueName)
+	if err != nil {
+		return "", err
+	}
+	index, err := loadImageIndex(ranobeProvider, uniqueName)
+	if err != nil {
+		return "", err
+	}
+	if name, found := index.lookup(imagesDir, url); found {
+		return name, nil
+	}
+	if err := os.MkdirAll(imagesDir, 0777); err != nil {
+		return "", err
+	}
+	response, err := util.SendRequest(url)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if name, err := saveHashed(imagesDir, response.Body, extension); err != nil {
+		return "", err
+	} else {
+		return name, index.record(ranobeProvider, uniqueName, url, name)
+	}
+}
@@ -0,0 +1,75 @@
+package cachemgr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStoreImage(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.URL.Path == "/b.png" {
+			w.Write([]byte("second"))
+		} else {
+			w.Write([]byte("first"))
+		}
+	}))
+	defer server.Close()
+
+	if err := CreateRanobeDir(RanobeHub, "store"); err != nil {
+		t.Fatal(err)
+	}
+	first, err := StoreImage(RanobeHub, "store", server.URL+"/a.png", ".png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "a7937b64b8caa58f03721bb6bacf5c78cb235febe0e70b1b84cd99541461a08e.png"; first != expected {
+		t.Errorf("StoreImage() = %q; want %q", first, expected)
+	}
+	tests := []struct {
+		url      string
+		expected string
+		requests int32
+	}{
+		{"/a.png", first, 1},
+		{"/a.png?copy", first, 2},
+		{"/b.png", "16367aacb67a4a017c8da8ab95682ccb390863780f7114dda0a0e0c55644c7c4.png", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if name, err := StoreImage(RanobeHub, "store", server.URL+tt.url, ".png"); err != nil {
+				t.Fatal(err)
+			} else if name != tt.expected {
+				t.Errorf("StoreImage() = %q; want %q", name, tt.expected)
+			}
+			if count := requests.Load(); count != tt.requests {
+				t.Errorf("server received %d requests; want %d", count, tt.requests)
+			}
+		})
+	}
+	imagesDir, err := ImagesDir(RanobeHub, "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := os.ReadDir(imagesDir); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 {
+		t.Errorf("images directory has %d files; want 2", len(entries))
+	}
+	if path, err := ImagePath(RanobeHub, "store", first); err != nil {
+		t.Fatal(err)
+	} else if path != filepath.Join(imagesDir, first) {
+		t.Errorf("ImagePath() = %q", path)
+	}
+	if path, _ := ImagePath(RanobeHub, "store", "/legacy/11image0.jpg"); path != "/legacy/11image0.jpg" {
+		t.Errorf("ImagePath() of an absolute path = %q", path)
+	}
+}
@@ -11,5 +11,12 @@ func InCache(ranobeProvider RanobeProvider, uniqueName string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return ranobeInfo && pathInfo, nil
+	if !ranobeInfo || !pathInfo {
+		return false, nil
+	}
+	if pathInfo, err := LoadPathInfo(ranobeProvider, uniqueName); err != nil {
+		return false, nil
+	} else {
+		return pathInfo.Complete, nil
+	}
 }
@@ -0,0 +1,105 @@
+package cachemgr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	flatVersion  = 1
+	CacheVersion = 2
+)
+const versionFilename = "Version.json"
+
+type versionInfo struct {
+	Version int
+}
+
+func loadVersion(ranobeProvider RanobeProvider, uniqueName string) (int, error) {
+	if present, err := isPresent(ranobeProvider, uniqueName, versionFilename); err != nil {
+		return 0, err
+	} else if present {
+		var info versionInfo
+		return info.Version, loadJson(ranobeProvider, uniqueName, versionFilename, &info)
+	}
+	if present, err := pathInfoIsPresent(ranobeProvider, uniqueName); err != nil {
+		return 0, err
+	} else if present {
+		return flatVersion, nil
+	}
+	return CacheVersion, nil
+}
+func saveVersion(ranobeProvider RanobeProvider, uniqueName string) error {
+	return SaveJson(ranobeProvider, uniqueName, versionFilename, versionInfo{CacheVersion})
+}
+func moveChapter(source string, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+		return err
+	}
+	return os.Rename(source, target)
+}
+func migrateFlat(ranobeProvider RanobeProvider, uniqueName string) error {
+	pathInfo, err := LoadPathInfo(ranobeProvider, uniqueName)
+	if err != nil {
+		return err
+	}
+	owners := map[string]int{}
+
+	for _, chapter := range pathInfo.Data {
+		owners[chapter.Path]++
+	}
+	migrated := []Chapter{}
+
+	for _, chapter := range pathInfo.Data {
+		if owners[chapter.Path] > 1 {
+			pathInfo.Complete = false
+
+			if err := os.Remove(chapter.Path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		target, err := ChapterPath(ranobeProvider, uniqueName, chapter.Volume, chapter.Number)
+		if err != nil {
+			return err
+		}
+		if err := moveChapter(chapter.Path, target); err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+			pathInfo.Complete = false
+			continue
+		}
+		chapter.Path = target
+		migrated = append(migrated, chapter)
+	}
+	pathInfo.Data = migrated
+	return pathInfo.Save(ranobeProvider, uniqueName)
+}
+func Migrate(ranobeProvider RanobeProvider, uniqueName string) error {
+	if ranobeDir, err := ConstructPath(ranobeProvider, uniqueName); err != nil {
+		return err
+	} else if _, err := os.Stat(ranobeDir); os.IsNotExist(err) {
+		return nil
+	}
+	version, err := loadVersion(ranobeProvider, uniqueName)
+	if err != nil {
+		return err
+	}
+	switch {
+	case version == CacheVersion:
+		if present, err := isPresent(ranobeProvider, uniqueName, versionFilename); err != nil || present {
+			return err
+		}
+	case version > CacheVersion:
+		return fmt.Errorf("Cache version %d is newer than supported version %d, run 'ranobedl clear'", version, CacheVersion)
+	case version == flatVersion:
+		if err := migrateFlat(ranobeProvider, uniqueName); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Unknown cache version %d", version)
+	}
+	return saveVersion(ranobeProvider, uniqueName)
+}
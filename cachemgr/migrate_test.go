@@ -0,0 +1,109 @@
+package cachemgr
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestChapterPath(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	tests := []struct {
+		volume   string
+		number   string
+		expected string
+	}{
+		{"1", "12", filepath.Join("volumes", "1", "12.json")},
+		{"11", "2", filepath.Join("volumes", "11", "2.json")},
+		{"2", "10.5", filepath.Join("volumes", "2", "10.5.json")},
+		{"", "3", filepath.Join("volumes", "_", "3.json")},
+		{"1/2", "3", filepath.Join("volumes", "1%2F2", "3.json")},
+		{".", "4", filepath.Join("volumes", "%2E", "4.json")},
+		{"..", "5", filepath.Join("volumes", "%2E%2E", "5.json")},
+		{"1", "..", filepath.Join("volumes", "1", "%2E%2E.json")},
+	}
+	ranobeDir, err := ConstructPath(RanobeLib, "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.volume+":"+tt.number, func(t *testing.T) {
+			if path, err := ChapterPath(RanobeLib, "novel", tt.volume, tt.number); err != nil {
+				t.Fatal(err)
+			} else if path != filepath.Join(ranobeDir, tt.expected) {
+				t.Errorf("ChapterPath() = %q; want %q", path, tt.expected)
+			}
+		})
+	}
+}
+func TestMigrate(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	if err := Migrate(RanobeLib, "novel"); err != nil {
+		t.Fatalf("Migrate() of a missing cache = %v", err)
+	}
+	ranobeDir, err := ConstructPath(RanobeLib, "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(ranobeDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	flat := func(name string, content string) string {
+		path := filepath.Join(ranobeDir, name)
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	pathInfo := PathInfo{
+		Data: []Chapter{
+			{Path: flat("11.json", "v1c1"), Volume: "1", Number: "1"},
+			{Path: flat("112.json", "v11c2"), Volume: "1", Number: "12"},
+			{Path: filepath.Join(ranobeDir, "112.json"), Volume: "11", Number: "2"},
+			{Path: filepath.Join(ranobeDir, "missing.json"), Volume: "2", Number: "1"},
+		},
+		Complete: true,
+	}
+	if err := pathInfo.Save(RanobeLib, "novel"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(RanobeLib, "novel"); err != nil {
+		t.Fatal(err)
+	}
+	migrated, err := LoadPathInfo(RanobeLib, "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, _ := ChapterPath(RanobeLib, "novel", "1", "1")
+	expected := PathInfo{
+		Data:     []Chapter{{Path: target, Volume: "1", Number: "1"}},
+		Complete: false,
+	}
+	if !reflect.DeepEqual(migrated, expected) {
+		t.Errorf("LoadPathInfo() after migration = %+v; want %+v", migrated, expected)
+	}
+	if data, err := os.ReadFile(target); err != nil || string(data) != "v1c1" {
+		t.Errorf("migrated chapter = %q, %v", data, err)
+	}
+	for _, name := range []string{"11.json", "112.json"} {
+		if _, err := os.Stat(filepath.Join(ranobeDir, name)); !os.IsNotExist(err) {
+			t.Errorf("flat chapter %s was not removed", name)
+		}
+	}
+	if version, err := loadVersion(RanobeLib, "novel"); err != nil || version != CacheVersion {
+		t.Errorf("loadVersion() = %d, %v; want %d", version, err, CacheVersion)
+	}
+	if err := Migrate(RanobeLib, "novel"); err != nil {
+		t.Errorf("second Migrate() = %v", err)
+	}
+	if err := SaveJson(RanobeLib, "novel", versionFilename, versionInfo{CacheVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(RanobeLib, "novel"); err == nil {
+		t.Errorf("Migrate() of a newer cache expected error")
+	}
+}
@@ -1,13 +1,18 @@
 package cachemgr
 
 type Chapter struct {
-	Path   string
-	Number string
-	Volume string
+	Path     string
+	Number   string
+	Volume   string
+	Name     string
+	Revision string
+	Branch   string
+	Checksum string `json:",omitempty"`
 }
 
 type PathInfo struct {
-	Data []Chapter
+	Data     []Chapter
+	Complete bool
 }
 
 const pathInfoFilename = "PathInfo.json"
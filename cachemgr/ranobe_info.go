@@ -1,8 +1,24 @@
 package cachemgr
 
 type RanobeInfo struct {
-	Name   string
-	Author string
+	Name           string
+	OriginalName   string   `json:",omitempty"`
+	AltNames       []string `json:",omitempty"`
+	Authors        []string `json:",omitempty"`
+	Author         string   `json:",omitempty"`
+	Description    string   `json:",omitempty"`
+	Genres         []string `json:",omitempty"`
+	Tags           []string `json:",omitempty"`
+	Status         string   `json:",omitempty"`
+	AgeRating      string   `json:",omitempty"`
+	Language       string   `json:",omitempty"`
+	SourceLanguage string   `json:",omitempty"`
+	Publisher      string   `json:",omitempty"`
+	Year           string   `json:",omitempty"`
+	Url            string   `json:",omitempty"`
+	CoverUrl       string   `json:",omitempty"`
+	CoverPath      string   `json:",omitempty"`
+	CoverChecksum  string   `json:",omitempty"`
 }
 
 const ranobeInfoFilename string = "RanobeInfo.json"
@@ -13,12 +29,18 @@ func ranobeInfoIsPresent(ranobeProvider RanobeProvider, uniqueName string) (bool
 func LoadRanobeInfo(ranobeProvider RanobeProvider, uniqueName string) (RanobeInfo, error) {
 	var ranobeInfo RanobeInfo
 
-	return ranobeInfo, loadJson(
+	if err := loadJson(
 		ranobeProvider,
 		uniqueName,
 		ranobeInfoFilename,
 		&ranobeInfo,
-	)
+	); err != nil {
+		return ranobeInfo, err
+	}
+	if len(ranobeInfo.Authors) == 0 && ranobeInfo.Author != "" {
+		ranobeInfo.Authors = []string{ranobeInfo.Author}
+	}
+	return ranobeInfo, nil
 }
 func (self *RanobeInfo) Save(ranobeProvider RanobeProvider, uniqueName string) error {
 	return SaveJson(
@@ -2,8 +2,9 @@ package cachemgr
 
 import (
 	"encoding/json"
-	"os"
+	"io"
 	"path/filepath"
+	"ranobedl/util"
 )
 
 func SaveJson(ranobeProvider RanobeProvider, uniqueName string, path string, structure any) error {
@@ -12,19 +13,10 @@ func SaveJson(ranobeProvider RanobeProvider, uniqueName string, path string, str
 	if err != nil {
 		return err
 	}
-	file, err := os.Create(filepath.Join(ranobeDir, path))
+	return util.WriteFileAtomic(filepath.Join(ranobeDir, path), func(writer io.Writer) error {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
 
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-
-	if err := encoder.Encode(structure); err != nil {
-		return err
-	} else {
-		return nil
-	}
+		return encoder.Encode(structure)
+	})
 }
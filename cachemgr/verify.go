@@ -0,0 +1,172 @@
+package cachemgr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"ranobedl/schema"
+	"strings"
+)
+
+type Problem struct {
+	Path   string
+	Reason string
+}
+type VerifyReport struct {
+	Problems []Problem
+	Damaged  []Chapter
+	Rebuild  bool
+}
+
+func (self *VerifyReport) Empty() bool {
+	return len(self.Problems) == 0
+}
+
+type verifier struct {
+	RanobeProvider
+	UniqueName string
+
+	ranobeDir string
+	badImages map[string]bool
+	report    VerifyReport
+}
+
+func (self *verifier) problem(path string, reason string) error {
+	self.report.Problems = append(self.report.Problems, Problem{path, reason})
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+func (self *verifier) verifyRanobeInfo() error {
+	ranobeInfo, err := LoadRanobeInfo(self.RanobeProvider, self.UniqueName)
+	if err != nil {
+		return self.problem(filepath.Join(self.ranobeDir, ranobeInfoFilename), err.Error())
+	}
+	if ranobeInfo.CoverPath == "" || ranobeInfo.CoverChecksum == "" {
+		return nil
+	}
+	if checksum, err := Checksum(ranobeInfo.CoverPath); err != nil {
+		return self.problem(ranobeInfo.CoverPath, err.Error())
+	} else if checksum != ranobeInfo.CoverChecksum {
+		return self.problem(ranobeInfo.CoverPath, "checksum mismatch")
+	}
+	return nil
+}
+func (self *verifier) verifyImage(imagesDir string, name string) error {
+	path := filepath.Join(imagesDir, name)
+
+	if strings.HasPrefix(name, incompleteImagePrefix) {
+		return self.problem(path, "incomplete download")
+	}
+	if checksum, err := Checksum(path); err != nil {
+		return err
+	} else if checksum != strings.TrimSuffix(name, filepath.Ext(name)) {
+		self.badImages[name] = true
+		return self.problem(path, "checksum mismatch")
+	}
+	return nil
+}
+func (self *verifier) verifyImages() error {
+	imagesDir := filepath.Join(self.ranobeDir, imagesDirname)
+
+	entries, err := os.ReadDir(imagesDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := self.verifyImage(imagesDir, entry.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (self *verifier) imageProblem(node schema.Node) string {
+	if node.Type == schema.NodeTypeImage {
+		if src, err := node.ImageSrc(); err == nil {
+			path, err := ImagePath(self.RanobeProvider, self.UniqueName, src)
+			if err != nil {
+				return err.Error()
+			}
+			if _, err := os.Stat(path); err != nil || self.badImages[filepath.Base(path)] {
+				return fmt.Sprintf("image %s is missing", filepath.Base(path))
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if reason := self.imageProblem(child); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+func (self *verifier) chapterProblem(chapter Chapter) string {
+	if checksum, err := Checksum(chapter.Path); os.IsNotExist(err) {
+		return "chapter is missing"
+	} else if err != nil {
+		return err.Error()
+	} else if chapter.Checksum != "" && checksum != chapter.Checksum {
+		return "checksum mismatch"
+	}
+	if node, err := schema.FromFile(chapter.Path); err != nil {
+		return fmt.Sprintf("invalid chapter: %v", err)
+	} else {
+		return self.imageProblem(node)
+	}
+}
+func (self *verifier) verifyChapters() error {
+	pathInfo, err := LoadPathInfo(self.RanobeProvider, self.UniqueName)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		self.report.Rebuild = true
+		return self.problem(filepath.Join(self.ranobeDir, pathInfoFilename), err.Error())
+	}
+	intact := []Chapter{}
+
+	for _, chapter := range pathInfo.Data {
+		if reason := self.chapterProblem(chapter); reason == "" {
+			intact = append(intact, chapter)
+		} else if err := self.problem(chapter.Path, reason); err != nil {
+			return err
+		} else {
+			self.report.Damaged = append(self.report.Damaged, chapter)
+		}
+	}
+	if len(self.report.Damaged) == 0 {
+		return nil
+	}
+	pathInfo.Data = intact
+	pathInfo.Complete = false
+	return pathInfo.Save(self.RanobeProvider, self.UniqueName)
+}
+func (self *verifier) Verify() (VerifyReport, error) {
+	if ranobeDir, err := ConstructPath(self.RanobeProvider, self.UniqueName); err != nil {
+		return self.report, err
+	} else if _, err := os.Stat(ranobeDir); err != nil {
+		return self.report, fmt.Errorf("Ranobe is not in cache: %s", self.UniqueName)
+	} else {
+		self.ranobeDir = ranobeDir
+	}
+	if err := self.verifyRanobeInfo(); err != nil {
+		return self.report, err
+	}
+	if err := self.verifyImages(); err != nil {
+		return self.report, err
+	}
+	return self.report, self.verifyChapters()
+}
+
+func Verify(ranobeProvider RanobeProvider, uniqueName string) (VerifyReport, error) {
+	return (&verifier{
+		RanobeProvider: ranobeProvider,
+		UniqueName:     uniqueName,
+		badImages:      map[string]bool{},
+	}).Verify()
+}
@@ -0,0 +1,81 @@
+package cachemgr
+
+import (
+	"os"
+	"path/filepath"
+	"ranobedl/schema"
+	"strconv"
+	"testing"
+)
+
+func TestVerifyImages(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	if err := CreateRanobeDir(RanobeHub, "novel"); err != nil {
+		t.Fatal(err)
+	}
+	imagesDir, err := ImagesDir(RanobeHub, "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(imagesDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	intact := "a7937b64b8caa58f03721bb6bacf5c78cb235febe0e70b1b84cd99541461a08e.png"
+	tampered := "16367aacb67a4a017c8da8ab95682ccb390863780f7114dda0a0e0c55644c7c4.png"
+	files := map[string]string{intact: "first", tampered: "seco", "download-1": "partial"}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(imagesDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pathInfo := PathInfo{Complete: true}
+
+	for index, src := range []string{intact, tampered} {
+		number := strconv.Itoa(index + 1)
+		node := schema.Node{Type: schema.NodeTypeDoc, Content: []schema.Node{
+			{Type: schema.NodeTypeImage, Attrs: map[string]any{"src": src}},
+		}}
+		path, err := ChapterPath(RanobeHub, "novel", "1", number)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.MkdirAll(filepath.Dir(path), 0777)
+
+		if err := node.ToFile(path); err != nil {
+			t.Fatal(err)
+		}
+		checksum, err := Checksum(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pathInfo.Data = append(pathInfo.Data, Chapter{Path: path, Volume: "1", Number: number, Checksum: checksum})
+	}
+	if err := pathInfo.Save(RanobeHub, "novel"); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&RanobeInfo{Name: "novel"}).Save(RanobeHub, "novel"); err != nil {
+		t.Fatal(err)
+	}
+	report, err := Verify(RanobeHub, "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) != 3 {
+		t.Errorf("Verify() problems = %+v; want 3", report.Problems)
+	}
+	if len(report.Damaged) != 1 || report.Damaged[0].Number != "2" {
+		t.Errorf("Verify() damaged = %+v; want chapter 2", report.Damaged)
+	}
+	for name, expected := range map[string]bool{intact: true, tampered: false, "download-1": false} {
+		if _, err := os.Stat(filepath.Join(imagesDir, name)); (err == nil) != expected {
+			t.Errorf("image %s present = %v; want %v", name, err == nil, expected)
+		}
+	}
+	if pathInfo, err := LoadPathInfo(RanobeHub, "novel"); err != nil {
+		t.Fatal(err)
+	} else if pathInfo.Complete || len(pathInfo.Data) != 1 {
+		t.Errorf("LoadPathInfo() after Verify() = %+v", pathInfo)
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"ranobedl/provider"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type branchLister struct {
+	Cmd  *cobra.Command
+	Args []string
+}
+
+func newBranchLister(cmd *cobra.Command, args []string) *branchLister {
+	return &branchLister{cmd, args}
+}
+
+const BranchListerUrlIndex = 0
+
+func (self *branchLister) getUrl() string {
+	return self.Args[BranchListerUrlIndex]
+}
+func (self *branchLister) formatBranch(branch provider.Branch) string {
+	id := branch.Id
+	if id == "" {
+		id = "-"
+	}
+	return fmt.Sprintf("[%s] %s", id, strings.Join(branch.Teams, ", "))
+}
+func (self *branchLister) printSummary(chapters []provider.Chapter) {
+	counts := map[string]int{}
+	branches := []provider.Branch{}
+
+	for _, chapter := range chapters {
+		for _, branch := range chapter.Branches {
+			key := self.formatBranch(branch)
+
+			if counts[key] == 0 {
+				branches = append(branches, branch)
+			}
+			counts[key]++
+		}
+	}
+	sort.SliceStable(branches, func(i, j int) bool {
+		return counts[self.formatBranch(branches[i])] > counts[self.formatBranch(branches[j])]
+	})
+	fmt.Println("Branches:")
+
+	for _, branch := range branches {
+		fmt.Printf("  %s: %d chapters\n", self.formatBranch(branch), counts[self.formatBranch(branch)])
+	}
+}
+func (self *branchLister) printChapters(chapters []provider.Chapter) {
+	fmt.Println("Chapters:")
+
+	for _, chapter := range chapters {
+		formatted := make([]string, 0, len(chapter.Branches))
+
+		for _, branch := range chapter.Branches {
+			formatted = append(formatted, self.formatBranch(branch))
+		}
+		fmt.Printf("  Volume %s Chapter %s: %s\n", chapter.Volume, chapter.Number, strings.Join(formatted, "; "))
+	}
+}
+func (self *branchLister) Run() error {
+	ranobeProvider, err := provider.FromUrl(self.getUrl())
+	if err != nil {
+		return err
+	}
+	uniqueName, err := ranobeProvider.UniqueName(self.getUrl())
+	if err != nil {
+		return err
+	}
+	chapters, err := ranobeProvider.ListChapters(uniqueName)
+	if err != nil {
+		return err
+	}
+	self.printSummary(chapters)
+
+	if verbose, _ := self.Cmd.Flags().GetBool("chapters"); verbose {
+		self.printChapters(chapters)
+	}
+	return nil
+}
+func runBranchesCmd(cmd *cobra.Command, args []string) {
+	if err := newBranchLister(cmd, args).Run(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+var branchesCmd = &cobra.Command{
+	Use:   "branches",
+	Short: "List translation branches and teams",
+	Long:  "List translation branches and teams available for each chapter",
+	Args:  cobra.ExactArgs(1),
+	Run:   runBranchesCmd,
+}
+
+func init() {
+	branchesCmd.Flags().BoolP(
+		"chapters",
+		"c",
+		false,
+		"list branches of every chapter",
+	)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"ranobedl/cachemgr"
+	"ranobedl/provider"
+	"ranobedl/ranobe"
+
+	"github.com/spf13/cobra"
+)
+
+type verifier struct {
+	Cmd  *cobra.Command
+	Args []string
+}
+
+func newVerifier(cmd *cobra.Command, args []string) *verifier {
+	return &verifier{cmd, args}
+}
+
+const VerifierUrlIndex = 0
+
+func (self *verifier) getUrl() string {
+	return self.Args[VerifierUrlIndex]
+}
+func (self *verifier) printReport(report cachemgr.VerifyReport) {
+	if report.Empty() {
+		fmt.Println("Cache is intact")
+		return
+	}
+	fmt.Printf("Found %d problems:\n", len(report.Problems))
+
+	for _, problem := range report.Problems {
+		fmt.Printf("  %s: %s\n", problem.Path, problem.Reason)
+	}
+	if report.Rebuild {
+		fmt.Println("Chapter list was rebuilt")
+	} else {
+		fmt.Printf("Downloaded %d chapters again\n", len(report.Damaged))
+	}
+}
+func (self *verifier) Run() error {
+	ranobeProvider, err := provider.FromUrl(self.getUrl())
+	if err != nil {
+		return err
+	}
+	uniqueName, err := ranobeProvider.UniqueName(self.getUrl())
+	if err != nil {
+		return err
+	}
+	jobs, _ := self.Cmd.Flags().GetInt("jobs")
+
+	if report, err := ranobe.Verify(ranobeProvider, uniqueName, ranobe.Options{Jobs: jobs}, func(int, int) {}); err != nil {
+		return err
+	} else {
+		self.printReport(report)
+		return nil
+	}
+}
+
+func runCacheVerifyCmd(cmd *cobra.Command, args []string) {
+	if err := newVerifier(cmd, args).Run(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect cached ranobe",
+	Long:  "Inspect cached ranobe",
+}
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify cached ranobe and repair corrupt entries",
+	Long:  "Check cached chapters, images and the cover against their checksums and download corrupt entries again",
+	Args:  cobra.ExactArgs(1),
+	Run:   runCacheVerifyCmd,
+}
+
+func init() {
+	cacheVerifyCmd.Flags().IntP(
+		"jobs",
+		"j",
+		DefaultJobs,
+		"number of chapters downloaded in parallel",
+	)
+	cacheCmd.AddCommand(cacheVerifyCmd)
+}
@@ -3,36 +3,140 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"ranobedl/api/ranobelib"
-	"ranobedl/cachemgr"
 	"ranobedl/format"
+	"ranobedl/provider"
 	"ranobedl/ranobe"
+	"ranobedl/selector"
+	"strings"
 
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 )
 
 type downloader struct {
-	Cmd  *cobra.Command
-	Args []string
+	Cmd    *cobra.Command
+	Args   []string
+	Update bool
 }
 
 func newDownloader(cmd *cobra.Command, args []string) *downloader {
-	return &downloader{cmd, args}
+	return &downloader{cmd, args, false}
 }
 
 const DownloaderUrlIndex = 0
+const DefaultOutputName = "ranobe"
+const DefaultJobs = 4
+const DefaultSplitOutput = "."
 
 func (self *downloader) getUrl() string {
 	return self.Args[DownloaderUrlIndex]
 }
-func (self *downloader) getOutput() string {
+func (self *downloader) getFormat() (format.Format, error) {
+	formatStr, _ := self.Cmd.Flags().GetString("format")
+	return format.FormatFromString(formatStr)
+}
+func (self *downloader) getSplit() (format.Split, error) {
+	splitStr, _ := self.Cmd.Flags().GetString("split")
+	return format.SplitFromString(splitStr)
+}
+func (self *downloader) getPageSize() (format.PageSize, error) {
+	pageSizeStr, _ := self.Cmd.Flags().GetString("page-size")
+	return format.PageSizeFromString(pageSizeStr)
+}
+func (self *downloader) getOutput(outputFormat format.Format, split format.Split) string {
+	if !self.Cmd.Flags().Changed("output") {
+		if !split.Empty() {
+			return DefaultSplitOutput
+		}
+		return DefaultOutputName + outputFormat.Extension()
+	}
 	output, _ := self.Cmd.Flags().GetString("output")
 	return output
 }
+func (self *downloader) getPosition(name string) (*selector.Position, error) {
+	if str, _ := self.Cmd.Flags().GetString(name); str == "" {
+		return nil, nil
+	} else if position, err := selector.ParsePosition(str); err != nil {
+		return nil, err
+	} else {
+		return &position, nil
+	}
+}
+func (self *downloader) getSelector() (selector.Selector, error) {
+	var output selector.Selector
+	var err error
+
+	volumes, _ := self.Cmd.Flags().GetString("volumes")
+	if output.Volumes, err = selector.ParseRange(volumes); err != nil {
+		return output, err
+	}
+	chapters, _ := self.Cmd.Flags().GetString("chapters")
+	if output.Chapters, err = selector.ParseRange(chapters); err != nil {
+		return output, err
+	}
+	if output.From, err = self.getPosition("from"); err != nil {
+		return output, err
+	}
+	if output.To, err = self.getPosition("to"); err != nil {
+		return output, err
+	}
+	return output, nil
+}
+func (self *downloader) getBranchPolicy() (provider.BranchPolicy, error) {
+	branchId, _ := self.Cmd.Flags().GetString("branch")
+	team, _ := self.Cmd.Flags().GetString("team")
+	fallbackStr, _ := self.Cmd.Flags().GetString("branch-fallback")
+
+	if fallback, err := provider.FallbackFromString(fallbackStr); err != nil {
+		return provider.BranchPolicy{}, err
+	} else {
+		return provider.BranchPolicy{
+			BranchId: branchId,
+			Team:     team,
+			Fallback: fallback,
+		}, nil
+	}
+}
+func (self *downloader) getOptions(chapterSelector selector.Selector) (ranobe.Options, error) {
+	jobs, _ := self.Cmd.Flags().GetInt("jobs")
+
+	if branchPolicy, err := self.getBranchPolicy(); err != nil {
+		return ranobe.Options{}, err
+	} else {
+		return ranobe.Options{
+			Jobs:     jobs,
+			Selector: chapterSelector,
+			Branch:   branchPolicy,
+		}, nil
+	}
+}
 func (self *downloader) Run() error {
+	outputFormat, err := self.getFormat()
+	if err != nil {
+		return err
+	}
+	split, err := self.getSplit()
+	if err != nil {
+		return err
+	}
+	pageSize, err := self.getPageSize()
+	if err != nil {
+		return err
+	}
+	chapterSelector, err := self.getSelector()
+	if err != nil {
+		return err
+	}
+	options, err := self.getOptions(chapterSelector)
+	if err != nil {
+		return err
+	}
 
-	uniqueName, err := ranobelib.GetUniqueName(self.getUrl())
+	ranobeProvider, err := provider.FromUrl(self.getUrl())
+	if err != nil {
+		return err
+	}
+	uniqueName, err := ranobeProvider.UniqueName(self.getUrl())
 	if err != nil {
 		return err
 	}
@@ -52,14 +156,55 @@ func (self *downloader) Run() error {
 		}),
 	)
 	callback := func(current, total int) {
+		if total == 0 {
+			return
+		}
 		progressbar.Set(int(
-			float64(current) / float64(total-1) * 100,
+			float64(current) / float64(total) * 100,
 		))
 	}
-	if err := ranobe.Download(cachemgr.RanobeLib, uniqueName, callback); err != nil {
+	if self.Update {
+		if report, err := ranobe.Update(ranobeProvider, uniqueName, options, callback); err != nil {
+			return err
+		} else {
+			progressbar.Finish()
+			printReport(report)
+		}
+	} else if err := ranobe.Download(ranobeProvider, uniqueName, options, callback); err != nil {
 		return err
 	}
-	if err := format.Export(cachemgr.RanobeLib, uniqueName, self.getOutput()); err != nil {
+	titleTemplate, _ := self.Cmd.Flags().GetString("title-template")
+	volumeTemplate, _ := self.Cmd.Flags().GetString("volume-template")
+	cover, _ := self.Cmd.Flags().GetString("cover")
+	nameTemplate, _ := self.Cmd.Flags().GetString("name-template")
+	externalImages, _ := self.Cmd.Flags().GetBool("external-images")
+	wrap, _ := self.Cmd.Flags().GetInt("wrap")
+	margin, _ := self.Cmd.Flags().GetFloat64("margin")
+	fontSize, _ := self.Cmd.Flags().GetFloat64("font-size")
+	font, _ := self.Cmd.Flags().GetString("pdf-font")
+	imageMaxSize, _ := self.Cmd.Flags().GetInt("image-max-size")
+	jpegQuality, _ := self.Cmd.Flags().GetInt("jpeg-quality")
+	grayscale, _ := self.Cmd.Flags().GetBool("grayscale")
+
+	if err := format.Export(ranobeProvider.Id(), uniqueName, format.Options{
+		Format:         outputFormat,
+		Output:         self.getOutput(outputFormat, split),
+		Selector:       chapterSelector,
+		TitleTemplate:  titleTemplate,
+		VolumeTemplate: volumeTemplate,
+		Cover:          cover,
+		Split:          split,
+		NameTemplate:   nameTemplate,
+		ExternalImages: externalImages,
+		Wrap:           wrap,
+		PageSize:       pageSize,
+		Margin:         margin,
+		FontSize:       fontSize,
+		Font:           font,
+		ImageMaxSize:   imageMaxSize,
+		JpegQuality:    jpegQuality,
+		Grayscale:      grayscale,
+	}); err != nil {
 		return err
 	}
 	fmt.Println("Success!")
@@ -80,17 +225,131 @@ var downloadCmd = &cobra.Command{
 	Run:   runDownloadCmd,
 }
 
-func init() {
-	downloadCmd.Flags().StringP(
+func addDownloadFlags(command *cobra.Command) {
+	command.Flags().StringP(
 		"format",
 		"f",
 		"fb2",
-		"format (fb2, epub)",
+		fmt.Sprintf("format (%s)", strings.Join(format.SupportedFormats(), ", ")),
 	)
-	downloadCmd.Flags().StringP(
+	command.Flags().StringP(
 		"output",
 		"o",
-		"ranobe.fb2",
-		"output path",
+		"",
+		fmt.Sprintf("output path (default \"%s.<format>\", a directory with --split)", DefaultOutputName),
+	)
+	command.Flags().IntP(
+		"jobs",
+		"j",
+		DefaultJobs,
+		"number of chapters downloaded in parallel",
+	)
+	command.Flags().String(
+		"volumes",
+		"",
+		"volumes to download and export, e.g. 1-3,5",
+	)
+	command.Flags().String(
+		"chapters",
+		"",
+		"chapter numbers to download and export, e.g. 10-50,12.5",
+	)
+	command.Flags().String(
+		"from",
+		"",
+		"first position to download and export, <volume> or <volume>:<chapter>",
+	)
+	command.Flags().String(
+		"to",
+		"",
+		"last position to download and export, <volume> or <volume>:<chapter>",
+	)
+	command.Flags().String(
+		"branch",
+		"",
+		"preferred translation branch id (see 'ranobedl branches')",
+	)
+	command.Flags().String(
+		"team",
+		"",
+		"preferred translation team name (see 'ranobedl branches')",
+	)
+	command.Flags().String(
+		"branch-fallback",
+		"first",
+		"branch used when the preferred one is missing (first, newest, fail)",
+	)
+	command.Flags().String(
+		"title-template",
+		format.DefaultTitleTemplate,
+		"chapter title template, fields: .Volume .Number .Name",
 	)
+	command.Flags().String(
+		"volume-template",
+		format.DefaultVolumeTemplate,
+		"volume title template, fields: .Volume",
+	)
+	command.Flags().String(
+		"cover",
+		"",
+		"custom cover image, local path or url",
+	)
+	command.Flags().String(
+		"split",
+		"none",
+		"write one book per part (none, volume, chapters=N)",
+	)
+	command.Flags().String(
+		"name-template",
+		"",
+		"split book name template, fields: .Name .Index .Volume .FirstVolume .FirstNumber .LastVolume .LastNumber",
+	)
+	command.Flags().Bool(
+		"external-images",
+		false,
+		"keep html images as side files instead of inlining them",
+	)
+	command.Flags().Int(
+		"wrap",
+		0,
+		"wrap txt lines at the given number of columns, 0 disables wrapping",
+	)
+	command.Flags().String(
+		"page-size",
+		format.DefaultPageSize,
+		"pdf page size (a4, a5, a6, letter, <width>x<height>mm)",
+	)
+	command.Flags().Float64(
+		"margin",
+		format.DefaultMargin,
+		"pdf page margin in millimeters",
+	)
+	command.Flags().Float64(
+		"font-size",
+		format.DefaultFontSize,
+		"pdf body font size in points",
+	)
+	command.Flags().String(
+		"pdf-font",
+		"",
+		"pdf body font, path to a TrueType file or name of an installed font (default: bundled Go fonts)",
+	)
+	command.Flags().Int(
+		"image-max-size",
+		0,
+		"downscale images so that the longest side fits the given number of pixels, 0 keeps the size",
+	)
+	command.Flags().Int(
+		"jpeg-quality",
+		0,
+		"recompress jpeg images with the given quality (1-100), 0 keeps the original data",
+	)
+	command.Flags().Bool(
+		"grayscale",
+		false,
+		"convert images to grayscale",
+	)
+}
+func init() {
+	addDownloadFlags(downloadCmd)
 }
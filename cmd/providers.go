@@ -0,0 +1,6 @@
+package cmd
+
+import (
+	_ "ranobedl/provider/ranobehub"
+	_ "ranobedl/provider/ranobelib"
+)
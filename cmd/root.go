@@ -20,7 +20,10 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(downloadCmd)
+	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(branchesCmd)
 	rootCmd.AddCommand(clearCmd)
+	rootCmd.AddCommand(cacheCmd)
 }
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"ranobedl/provider"
+	"ranobedl/ranobe"
+
+	"github.com/spf13/cobra"
+)
+
+func printChapters(header string, chapters []provider.Chapter) {
+	if len(chapters) == 0 {
+		return
+	}
+	fmt.Println(header)
+
+	for _, chapter := range chapters {
+		if chapter.Name != "" {
+			fmt.Printf("  Volume %s Chapter %s - %s\n", chapter.Volume, chapter.Number, chapter.Name)
+		} else {
+			fmt.Printf("  Volume %s Chapter %s\n", chapter.Volume, chapter.Number)
+		}
+	}
+}
+func printReport(report ranobe.Report) {
+	if report.Empty() {
+		fmt.Println("No new chapters")
+		return
+	}
+	printChapters(fmt.Sprintf("Added %d chapters:", len(report.Added)), report.Added)
+	printChapters(fmt.Sprintf("Updated %d chapters:", len(report.Changed)), report.Changed)
+}
+func runUpdateCmd(cmd *cobra.Command, args []string) {
+	downloader := newDownloader(cmd, args)
+	downloader.Update = true
+
+	if err := downloader.Run(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Download new chapters of cached ranobe",
+	Long:  "Fetch the chapter list again, download new or changed chapters and export the ranobe",
+	Args:  cobra.ExactArgs(1),
+	Run:   runUpdateCmd,
+}
+
+func init() {
+	addDownloadFlags(updateCmd)
+}
@@ -0,0 +1,29 @@
+package format
+
+import (
+	"fmt"
+	"os"
+	"ranobedl/cachemgr"
+	"strings"
+)
+
+func isUrl(str string) bool {
+	return strings.HasPrefix(str, "http://") || strings.HasPrefix(str, "https://")
+}
+func (e *exporter) coverPath(ranobeInfo cachemgr.RanobeInfo) (string, error) {
+	if cover := e.Options.Cover; cover == "" {
+		if ranobeInfo.CoverPath == "" {
+			return "", nil
+		}
+		if _, err := os.Stat(ranobeInfo.CoverPath); err != nil {
+			return "", nil
+		}
+		return ranobeInfo.CoverPath, nil
+	} else if isUrl(cover) {
+		return cachemgr.DownloadCover(e.RanobeProvider, e.UniqueName, cover, cachemgr.CustomCoverName)
+	} else if _, err := os.Stat(cover); err != nil {
+		return "", fmt.Errorf("Cover not found: %s", cover)
+	} else {
+		return cover, nil
+	}
+}
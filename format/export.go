@@ -1,27 +1,69 @@
 package format
 
 import (
-	"fmt"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"ranobedl/cachemgr"
 	"ranobedl/format/internal/builder"
+	"ranobedl/format/internal/docx"
+	"ranobedl/format/internal/epub"
 	"ranobedl/format/internal/fb2"
+	"ranobedl/format/internal/htmlbook"
+	"ranobedl/format/internal/imageproc"
+	"ranobedl/format/internal/markdown"
 	"ranobedl/format/internal/nodehandler"
+	"ranobedl/format/internal/odt"
+	"ranobedl/format/internal/pdf"
+	"ranobedl/format/internal/txt"
 	"ranobedl/schema"
 )
 
-type Format int
-
-const (
-	FB2 Format = iota
-	Epub
-)
-
-func newBuilder(format Format) builder.Builder {
-	switch format {
+func newPdfBuilder(options Options) (builder.Builder, error) {
+	config := pdf.Config{
+		PageWidth:  options.PageSize.Width,
+		PageHeight: options.PageSize.Height,
+		Margin:     options.Margin * pointsPerMillimeter,
+		FontSize:   options.FontSize,
+		FontPath:   options.Font,
+	}
+	if options.PageSize.Empty() {
+		if size, err := PageSizeFromString(DefaultPageSize); err != nil {
+			return nil, err
+		} else {
+			config.PageWidth, config.PageHeight = size.Width, size.Height
+		}
+	}
+	if options.Margin <= 0 {
+		config.Margin = DefaultMargin * pointsPerMillimeter
+	}
+	if options.FontSize <= 0 {
+		config.FontSize = DefaultFontSize
+	}
+	if 2*config.Margin >= min(config.PageWidth, config.PageHeight) {
+		return nil, errors.New("Page margins are larger than the page")
+	}
+	return pdf.NewBuilder(config)
+}
+func newBuilder(options Options) (builder.Builder, error) {
+	switch options.Format {
 	case FB2:
-		return fb2.NewBuilder()
+		return fb2.NewBuilder(), nil
 	case Epub:
-		panic("Unimplemented")
+		return epub.NewBuilder(), nil
+	case Markdown:
+		return markdown.NewBuilder(), nil
+	case Html:
+		return htmlbook.NewBuilder(options.ExternalImages), nil
+	case Txt:
+		return txt.NewBuilder(options.Wrap), nil
+	case Pdf:
+		return newPdfBuilder(options)
+	case Docx:
+		return docx.NewBuilder(), nil
+	case Odt:
+		return odt.NewBuilder(), nil
 	default:
 		panic("Unreachable")
 	}
@@ -31,7 +73,19 @@ func getRenderInlineFn(format Format) nodehandler.RenderInline {
 	case FB2:
 		return fb2.RenderInline
 	case Epub:
-		panic("Unimplemented")
+		return epub.RenderInline
+	case Markdown:
+		return markdown.RenderInline
+	case Html:
+		return htmlbook.RenderInline
+	case Txt:
+		return txt.RenderInline
+	case Pdf:
+		return pdf.RenderInline
+	case Docx:
+		return docx.RenderInline
+	case Odt:
+		return odt.RenderInline
 	default:
 		panic("Unreachable")
 	}
@@ -42,55 +96,184 @@ type exporter struct {
 	RenderInlineFn nodehandler.RenderInline
 	RanobeProvider cachemgr.RanobeProvider
 	UniqueName     string
+	Options        Options
+	metadata       builder.Metadata
+	images         *imageproc.Processor
 }
 
-func newExporter(ranobeProvider cachemgr.RanobeProvider, uniqueName string) *exporter {
+func newExporter(ranobeProvider cachemgr.RanobeProvider, uniqueName string, options Options) *exporter {
 	return &exporter{
 		RanobeProvider: ranobeProvider,
 		UniqueName:     uniqueName,
-		Builder:        newBuilder(FB2),
-		RenderInlineFn: getRenderInlineFn(FB2),
+		Options:        options,
+		RenderInlineFn: getRenderInlineFn(options.Format),
 	}
 }
 
 func (e *exporter) prepare() error {
-	if ranobeInfo, err := cachemgr.LoadRanobeInfo(
+	ranobeInfo, err := cachemgr.LoadRanobeInfo(
 		e.RanobeProvider,
-		e.UniqueName); err != nil {
+		e.UniqueName)
+	if err != nil {
+		return err
+	}
+	e.metadata = newMetadata(ranobeInfo)
+
+	if cover, err := e.coverPath(ranobeInfo); err != nil || cover == "" {
 		return err
 	} else {
-		e.Builder.SetTitle(ranobeInfo.Name)
-		return nil
+		e.metadata.CoverPath, err = e.images.Process(cover)
+		return err
 	}
 }
-func (e *exporter) pushChapter(chapterPath string, number string, volume string) error {
-	e.Builder.PushChapter(fmt.Sprintf("n%sv%s", number, volume))
-
-	if node, err := schema.FromFile(chapterPath); err != nil {
+func (e *exporter) processImages(node schema.Node) error {
+	if node.Type == schema.NodeTypeImage {
+		if src, found := node.Attrs["src"].(string); found && src != "" && !isUrl(src) {
+			if path, err := cachemgr.ImagePath(e.RanobeProvider, e.UniqueName, src); err != nil {
+				return err
+			} else if path, err := e.images.Process(path); err != nil {
+				return err
+			} else {
+				node.Attrs["src"] = path
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if err := e.processImages(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (e *exporter) pushVolume(titles *titleRenderer, chapter cachemgr.Chapter) error {
+	if title, err := titles.Volume(chapter); err != nil {
 		return err
 	} else {
-		return nodehandler.PushBlock(e.Builder, e.RenderInlineFn, node)
+		return e.Builder.PushVolume(title)
 	}
 }
-func (e *exporter) Export(outputPath string) error {
-	if err := e.prepare(); err != nil {
+func (e *exporter) pushChapter(titles *titleRenderer, chapter cachemgr.Chapter) error {
+	if title, err := titles.Chapter(chapter); err != nil {
+		return err
+	} else if err := e.Builder.PushChapter(title); err != nil {
 		return err
 	}
+	if node, err := schema.FromFile(chapter.Path); err != nil {
+		return err
+	} else if err := e.processImages(node); err != nil {
+		return err
+	} else {
+		return nodehandler.PushBlock(e.Builder, e.RenderInlineFn, node)
+	}
+}
+func (e *exporter) selectChapters() ([]cachemgr.Chapter, error) {
 	pathInfo, err := cachemgr.LoadPathInfo(
 		e.RanobeProvider,
 		e.UniqueName,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	selected := []cachemgr.Chapter{}
+
 	for _, chapter := range pathInfo.Data {
-		if err := e.pushChapter(chapter.Path, chapter.Number, chapter.Volume); err != nil {
+		if e.Options.Selector.Match(chapter.Volume, chapter.Number) {
+			selected = append(selected, chapter)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errors.New("No chapters selected for export")
+	}
+	return selected, nil
+}
+func (e *exporter) hasVolumes(chapters []cachemgr.Chapter) bool {
+	for _, chapter := range chapters {
+		if chapter.Volume != chapters[0].Volume {
+			return true
+		}
+	}
+	return false
+}
+func (e *exporter) exportBook(titles *titleRenderer, chapters []cachemgr.Chapter, metadata builder.Metadata, output string) error {
+	if bookBuilder, err := newBuilder(e.Options); err != nil {
+		return err
+	} else {
+		e.Builder = bookBuilder
+	}
+	if closer, found := e.Builder.(io.Closer); found {
+		defer closer.Close()
+	}
+	e.Builder.SetMetadata(metadata)
+
+	nested := e.hasVolumes(chapters)
+
+	for index, chapter := range chapters {
+		if nested && (index == 0 || chapter.Volume != chapters[index-1].Volume) {
+			if err := e.pushVolume(titles, chapter); err != nil {
+				return err
+			}
+		}
+		if err := e.pushChapter(titles, chapter); err != nil {
+			return err
+		}
+	}
+	return e.Builder.Build(output)
+}
+func (e *exporter) exportParts(titles *titleRenderer, chapters []cachemgr.Chapter) error {
+	names, err := newPartNamer(e.Options.NameTemplate, e.Options.Split)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(e.Options.Output, 0755); err != nil {
+		return err
+	}
+	for index, part := range e.Options.Split.Parts(chapters) {
+		name, err := names.Name(e.metadata.Title, index+1, part)
+		if err != nil {
 			return err
 		}
+		metadata := e.metadata
+		metadata.Title = name
+		metadata.Series = e.metadata.Title
+		metadata.SeriesNumber = index + 1
+
+		output := filepath.Join(e.Options.Output, filename(name)+e.Options.Format.Extension())
+
+		if err := e.exportBook(titles, part, metadata, output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (e *exporter) Export() error {
+	titles, err := newTitleRenderer(e.Options.TitleTemplate, e.Options.VolumeTemplate)
+	if err != nil {
+		return err
+	}
+	images, err := imageproc.NewProcessor(imageproc.Options{
+		MaxSize:   e.Options.ImageMaxSize,
+		Quality:   e.Options.JpegQuality,
+		Grayscale: e.Options.Grayscale,
+	})
+	if err != nil {
+		return err
+	}
+	e.images = images
+	defer e.images.Close()
+
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	chapters, err := e.selectChapters()
+	if err != nil {
+		return err
+	}
+	if e.Options.Split.Empty() {
+		return e.exportBook(titles, chapters, e.metadata, e.Options.Output)
 	}
-	return e.Builder.Build(outputPath)
+	return e.exportParts(titles, chapters)
 }
 
-func Export(ranobeProvider cachemgr.RanobeProvider, uniqueName string, outputPath string) error {
-	return newExporter(ranobeProvider, uniqueName).Export(outputPath)
+func Export(ranobeProvider cachemgr.RanobeProvider, uniqueName string, options Options) error {
+	return newExporter(ranobeProvider, uniqueName, options).Export()
 }
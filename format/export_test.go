@@ -0,0 +1,390 @@
+package format
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"ranobedl/cachemgr"
+	"ranobedl/schema"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testProvider cachemgr.RanobeProvider = "test"
+const testUniqueName = "novel"
+
+func writeTestCache(t *testing.T, chapters []cachemgr.Chapter) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	if err := cachemgr.CreateRanobeDir(testProvider, testUniqueName); err != nil {
+		t.Fatal(err)
+	}
+	ranobeDir, err := cachemgr.ConstructPath(testProvider, testUniqueName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index := range chapters {
+		node := schema.Node{
+			Type: schema.NodeTypeDoc,
+			Content: []schema.Node{{
+				Type: schema.NodeTypeParagraph,
+				Content: []schema.Node{{
+					Type: schema.NodeTypeText,
+					Text: "Text of " + chapters[index].Name,
+				}},
+			}},
+		}
+		chapters[index].Path = filepath.Join(ranobeDir, chapters[index].Volume+"-"+chapters[index].Number+".json")
+
+		if err := node.ToFile(chapters[index].Path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ranobeInfo := cachemgr.RanobeInfo{Name: "Novel", Author: "Author"}
+	if err := ranobeInfo.Save(testProvider, testUniqueName); err != nil {
+		t.Fatal(err)
+	}
+	pathInfo := cachemgr.PathInfo{Data: chapters, Complete: true}
+	if err := pathInfo.Save(testProvider, testUniqueName); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type fb2Section struct {
+	Title    string       `xml:"title>p"`
+	Sections []fb2Section `xml:"section"`
+}
+type fb2Document struct {
+	Sections []fb2Section `xml:"body>section"`
+}
+
+func readFB2(t *testing.T, path string) fb2Document {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document fb2Document
+	if err := xml.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func TestExportTitlesAndVolumes(t *testing.T) {
+	writeTestCache(t, []cachemgr.Chapter{
+		{Volume: "1", Number: "1", Name: "Начало"},
+		{Volume: "1", Number: "2"},
+		{Volume: "2", Number: "15", Name: "Буря"},
+	})
+	output := filepath.Join(t.TempDir(), "out.fb2")
+
+	if err := Export(testProvider, testUniqueName, Options{Format: FB2, Output: output}); err != nil {
+		t.Fatal(err)
+	}
+	document := readFB2(t, output)
+
+	if len(document.Sections) != 2 {
+		t.Fatalf("Export() produced %d volume sections; want 2", len(document.Sections))
+	}
+	expected := []struct {
+		title    string
+		chapters []string
+	}{
+		{"Том 1", []string{"Том 1 Глава 1 — Начало", "Том 1 Глава 2"}},
+		{"Том 2", []string{"Том 2 Глава 15 — Буря"}},
+	}
+	for index, volume := range expected {
+		section := document.Sections[index]
+
+		if section.Title != volume.title {
+			t.Errorf("volume %d title = %q; want %q", index, section.Title, volume.title)
+		}
+		if len(section.Sections) != len(volume.chapters) {
+			t.Fatalf("volume %d has %d chapters; want %d", index, len(section.Sections), len(volume.chapters))
+		}
+		for chapterIndex, title := range volume.chapters {
+			if section.Sections[chapterIndex].Title != title {
+				t.Errorf("chapter title = %q; want %q", section.Sections[chapterIndex].Title, title)
+			}
+		}
+	}
+}
+func TestExportSingleVolumeIsFlat(t *testing.T) {
+	writeTestCache(t, []cachemgr.Chapter{
+		{Volume: "1", Number: "1", Name: "Начало"},
+		{Volume: "1", Number: "2", Name: "Продолжение"},
+	})
+	output := filepath.Join(t.TempDir(), "out.fb2")
+
+	if err := Export(testProvider, testUniqueName, Options{
+		Format:        FB2,
+		Output:        output,
+		TitleTemplate: "{{.Number}}. {{.Name}}",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	document := readFB2(t, output)
+
+	if len(document.Sections) != 2 || document.Sections[1].Title != "2. Продолжение" {
+		t.Errorf("Export() sections = %+v", document.Sections)
+	}
+}
+func TestExportFailureRemovesTemporaryFiles(t *testing.T) {
+	chapters := []cachemgr.Chapter{
+		{Volume: "1", Number: "1", Name: "Начало"},
+		{Volume: "1", Number: "2", Name: "Повреждённая"},
+	}
+	writeTestCache(t, chapters)
+
+	if err := os.WriteFile(chapters[1].Path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	temp := t.TempDir()
+	t.Setenv("TMPDIR", temp)
+
+	if err := Export(testProvider, testUniqueName, Options{
+		Format: FB2,
+		Output: filepath.Join(t.TempDir(), "out.fb2"),
+	}); err == nil {
+		t.Fatal("Export() of a damaged chapter expected error")
+	}
+	if entries, err := os.ReadDir(temp); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 0 {
+		t.Errorf("Export() left %d temporary files", len(entries))
+	}
+}
+func TestExportInvalidTemplate(t *testing.T) {
+	writeTestCache(t, []cachemgr.Chapter{{Volume: "1", Number: "1"}})
+
+	err := Export(testProvider, testUniqueName, Options{
+		Format:        FB2,
+		Output:        filepath.Join(t.TempDir(), "out.fb2"),
+		TitleTemplate: "{{.Missing",
+	})
+	if err == nil {
+		t.Errorf("Export() expected template error")
+	}
+}
+
+type fb2Author struct {
+	FirstName string `xml:"first-name"`
+	LastName  string `xml:"last-name"`
+	Nickname  string `xml:"nickname"`
+}
+type fb2TitleInfo struct {
+	Genres     []string    `xml:"genre"`
+	Authors    []fb2Author `xml:"author"`
+	BookTitle  string      `xml:"book-title"`
+	Annotation []string    `xml:"annotation>p"`
+	Lang       string      `xml:"lang"`
+	SrcLang    string      `xml:"src-lang"`
+}
+type fb2Description struct {
+	TitleInfo    fb2TitleInfo `xml:"description>title-info"`
+	SrcTitleInfo fb2TitleInfo `xml:"description>src-title-info"`
+	Publisher    string       `xml:"description>publish-info>publisher"`
+}
+
+func TestExportMetadata(t *testing.T) {
+	writeTestCache(t, []cachemgr.Chapter{{Volume: "1", Number: "1"}})
+
+	ranobeInfo := cachemgr.RanobeInfo{
+		Name:           "Повелитель тайн",
+		OriginalName:   "诡秘之主",
+		Authors:        []string{"Каракатица, Любящая Ныряние", "Иван Петров"},
+		Description:    "Первая строка\nВторая <строка>",
+		Genres:         []string{"Фэнтези", "Мистика", "Неизвестный"},
+		SourceLanguage: "zh",
+		Publisher:      "Qidian",
+	}
+	if err := ranobeInfo.Save(testProvider, testUniqueName); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "out.fb2")
+
+	if err := Export(testProvider, testUniqueName, Options{Format: FB2, Output: output}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var description fb2Description
+	if err := xml.Unmarshal(data, &description); err != nil {
+		t.Fatal(err)
+	}
+	titleInfo := description.TitleInfo
+
+	expectedAuthors := []fb2Author{
+		{Nickname: "Каракатица, Любящая Ныряние"},
+		{FirstName: "Иван", LastName: "Петров"},
+	}
+	if !reflect.DeepEqual(titleInfo.Authors, expectedAuthors) {
+		t.Errorf("title-info authors = %+v; want %+v", titleInfo.Authors, expectedAuthors)
+	}
+	if !reflect.DeepEqual(titleInfo.Genres, []string{"sf_fantasy", "sf_horror"}) {
+		t.Errorf("title-info genres = %v", titleInfo.Genres)
+	}
+	if !reflect.DeepEqual(titleInfo.Annotation, []string{"Первая строка", "Вторая <строка>"}) {
+		t.Errorf("title-info annotation = %v", titleInfo.Annotation)
+	}
+	if titleInfo.BookTitle != "Повелитель тайн" || titleInfo.Lang != "ru" || titleInfo.SrcLang != "zh" {
+		t.Errorf("title-info = %+v", titleInfo)
+	}
+	if description.SrcTitleInfo.BookTitle != "诡秘之主" || description.SrcTitleInfo.Lang != "zh" {
+		t.Errorf("src-title-info = %+v", description.SrcTitleInfo)
+	}
+	if description.Publisher != "Qidian" {
+		t.Errorf("publish-info publisher = %q", description.Publisher)
+	}
+}
+func TestExportLegacyAuthor(t *testing.T) {
+	writeTestCache(t, []cachemgr.Chapter{{Volume: "1", Number: "1"}})
+	output := filepath.Join(t.TempDir(), "out.fb2")
+
+	if err := Export(testProvider, testUniqueName, Options{Format: FB2, Output: output}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var description fb2Description
+	if err := xml.Unmarshal(data, &description); err != nil {
+		t.Fatal(err)
+	}
+	if authors := description.TitleInfo.Authors; len(authors) != 1 || authors[0].Nickname != "Author" {
+		t.Errorf("title-info authors = %+v", authors)
+	}
+}
+
+type fb2Cover struct {
+	Image struct {
+		Href string `xml:"href,attr"`
+	} `xml:"description>title-info>coverpage>image"`
+	Binaries []struct {
+		Id string `xml:"id,attr"`
+	} `xml:"binary"`
+}
+
+func readCover(t *testing.T, path string) fb2Cover {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cover fb2Cover
+	if err := xml.Unmarshal(data, &cover); err != nil {
+		t.Fatal(err)
+	}
+	return cover
+}
+func TestExportCover(t *testing.T) {
+	writeTestCache(t, []cachemgr.Chapter{{Volume: "1", Number: "1"}})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	}))
+	defer server.Close()
+
+	localCover := filepath.Join(t.TempDir(), "cover.png")
+	if err := os.WriteFile(localCover, []byte("\x89PNG\r\n\x1a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		cover string
+		found bool
+	}{
+		{"none", "", false},
+		{"local", localCover, true},
+		{"url", server.URL + "/cover.png", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "out.fb2")
+
+			if err := Export(testProvider, testUniqueName, Options{
+				Format: FB2,
+				Output: output,
+				Cover:  tt.cover,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			cover := readCover(t, output)
+
+			if found := cover.Image.Href == "#cover"; found != tt.found {
+				t.Errorf("coverpage href = %q; want found = %v", cover.Image.Href, tt.found)
+			}
+			if found := len(cover.Binaries) == 1 && cover.Binaries[0].Id == "cover"; found != tt.found {
+				t.Errorf("binaries = %+v; want cover = %v", cover.Binaries, tt.found)
+			}
+		})
+	}
+}
+func TestExportMissingCover(t *testing.T) {
+	writeTestCache(t, []cachemgr.Chapter{{Volume: "1", Number: "1"}})
+
+	if err := Export(testProvider, testUniqueName, Options{
+		Format: FB2,
+		Output: filepath.Join(t.TempDir(), "out.fb2"),
+		Cover:  filepath.Join(t.TempDir(), "missing.png"),
+	}); err == nil {
+		t.Errorf("Export() expected missing cover error")
+	}
+}
+
+type fb2Sequence struct {
+	Title    string `xml:"description>title-info>book-title"`
+	Sequence struct {
+		Name   string `xml:"name,attr"`
+		Number int    `xml:"number,attr"`
+	} `xml:"description>title-info>sequence"`
+	Sections []fb2Section `xml:"body>section"`
+}
+
+func TestExportSplitVolume(t *testing.T) {
+	writeTestCache(t, []cachemgr.Chapter{
+		{Volume: "1", Number: "1", Name: "Начало"},
+		{Volume: "1", Number: "2"},
+		{Volume: "2", Number: "3", Name: "Буря"},
+	})
+	output := t.TempDir()
+
+	if err := Export(testProvider, testUniqueName, Options{
+		Format: FB2,
+		Output: output,
+		Split:  Split{Mode: SplitVolume},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		filename string
+		chapters int
+	}{
+		{"Novel - Том 1.fb2", 2},
+		{"Novel - Том 2.fb2", 1},
+	}
+	for index, book := range expected {
+		data, err := os.ReadFile(filepath.Join(output, book.filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var document fb2Sequence
+		if err := xml.Unmarshal(data, &document); err != nil {
+			t.Fatal(err)
+		}
+		if document.Sequence.Name != "Novel" || document.Sequence.Number != index+1 {
+			t.Errorf("%s sequence = %+v", book.filename, document.Sequence)
+		}
+		if document.Title != strings.TrimSuffix(book.filename, ".fb2") {
+			t.Errorf("%s book-title = %q", book.filename, document.Title)
+		}
+		if len(document.Sections) != book.chapters {
+			t.Errorf("%s has %d sections; want %d", book.filename, len(document.Sections), book.chapters)
+		}
+	}
+}
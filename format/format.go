@@ -0,0 +1,81 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Format int
+
+const (
+	FB2 Format = iota
+	Epub
+	Markdown
+	Html
+	Txt
+	Pdf
+	Docx
+	Odt
+)
+
+var supportedFormats = []Format{
+	FB2,
+	Epub,
+	Markdown,
+	Html,
+	Txt,
+	Pdf,
+	Docx,
+	Odt,
+}
+
+func SupportedFormats() []string {
+	output := make([]string, 0, len(supportedFormats))
+
+	for _, format := range supportedFormats {
+		output = append(output, format.String())
+	}
+	return output
+}
+func FormatFromString(str string) (Format, error) {
+	for _, format := range supportedFormats {
+		if strings.EqualFold(str, format.String()) {
+			return format, nil
+		}
+	}
+	return -1, fmt.Errorf(
+		"Unsupported format: %s (supported: %s)",
+		str,
+		strings.Join(SupportedFormats(), ", "),
+	)
+}
+func (self Format) String() string {
+	switch self {
+	case FB2:
+		return "fb2"
+	case Epub:
+		return "epub"
+	case Markdown:
+		return "markdown"
+	case Html:
+		return "html"
+	case Txt:
+		return "txt"
+	case Pdf:
+		return "pdf"
+	case Docx:
+		return "docx"
+	case Odt:
+		return "odt"
+	default:
+		panic(fmt.Sprintf("Undefined Format: %d", self))
+	}
+}
+func (self Format) Extension() string {
+	switch self {
+	case Markdown:
+		return ".md"
+	default:
+		return "." + self.String()
+	}
+}
@@ -0,0 +1,57 @@
+package format
+
+import (
+	"testing"
+)
+
+func TestFormatFromString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Format
+		wantErr  bool
+	}{
+		{"fb2", FB2, false},
+		{"epub", Epub, false},
+		{"EPUB", Epub, false},
+		{"markdown", Markdown, false},
+		{"html", Html, false},
+		{"TXT", Txt, false},
+		{"PDF", Pdf, false},
+		{"docx", Docx, false},
+		{"ODT", Odt, false},
+		{"mobi", -1, true},
+		{"", -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := FormatFromString(tt.input)
+
+			if result != tt.expected {
+				t.Errorf("FormatFromString(%q) = %v; want %v", tt.input, int(result), int(tt.expected))
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FormatFromString(%q) error = %v; wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+func TestFormatExtension(t *testing.T) {
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{FB2, ".fb2"},
+		{Epub, ".epub"},
+		{Markdown, ".md"},
+		{Html, ".html"},
+		{Txt, ".txt"},
+		{Pdf, ".pdf"},
+		{Docx, ".docx"},
+		{Odt, ".odt"},
+	}
+	for _, tt := range tests {
+		if tt.format.Extension() != tt.expected {
+			t.Errorf("Format(%d).Extension() = %q; want %q", tt.format, tt.format.Extension(), tt.expected)
+		}
+	}
+}
@@ -1,12 +1,41 @@
 package builder
 
+import "errors"
+
+const UnsupportedImageText = "[Unsupported image]"
+
+var ErrUnsupportedImage = errors.New("Unsupported image")
+
 type Builder interface {
-	SetTitle(name string)
-	SetAuthor(author string)
+	SetMetadata(metadata Metadata)
 
+	PushVolume(volumeTitle string) error
 	PushChapter(chapterTitle string) error
 	PushParagraph(text string) error
+	PushHeading(level int, text string) error
 	PushImage(imagePath string) error
+	PushCode(code string) error
+	PushHorizontalRule() error
+
+	BeginList(ordered bool) error
+	BeginListItem() error
+	EndListItem() error
+	EndList() error
+
+	BeginBlockquote() error
+	EndBlockquote() error
+
+	BeginTable() error
+	BeginTableRow() error
+	PushTableCell(header bool, text string) error
+	EndTableRow() error
+	EndTable() error
+
+	BeginPoem() error
+	BeginStanza() error
+	PushVerse(text string) error
+	EndStanza() error
+	EndPoem() error
 
 	Build(filename string) error
 }
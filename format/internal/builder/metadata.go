@@ -0,0 +1,21 @@
+package builder
+
+type Metadata struct {
+	Title          string
+	OriginalTitle  string
+	AltTitles      []string
+	Authors        []string
+	Annotation     string
+	Genres         []string
+	Tags           []string
+	Status         string
+	AgeRating      string
+	Language       string
+	SourceLanguage string
+	Publisher      string
+	Year           string
+	Source         string
+	CoverPath      string
+	Series         string
+	SeriesNumber   int
+}
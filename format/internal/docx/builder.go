@@ -0,0 +1,355 @@
+package docx
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/office"
+	"strings"
+)
+
+const maxHeadingLevel = 6
+const twipsPerIndent = 720
+const emuPerMillimeter = 36000
+const maxImageWidth = 170
+const maxImageHeight = 230
+const tableWidth = 9638
+
+type list struct {
+	Ordered bool
+	NumId   int
+}
+type tableCell struct {
+	Header  bool
+	Content string
+}
+type builder struct {
+	metadata    base.Metadata
+	body        strings.Builder
+	images      []office.Image
+	imageIndex  map[string]int
+	drawings    int
+	numbering   []list
+	lists       []list
+	quotes      int
+	table       [][]tableCell
+	verses      []string
+	pendingItem bool
+	inVolume    bool
+	hasChapter  bool
+}
+
+func NewBuilder() *builder {
+	return &builder{imageIndex: map[string]int{}}
+}
+func (self *builder) SetMetadata(metadata base.Metadata) {
+	self.metadata = metadata
+}
+func (self *builder) properties() string {
+	left := twipsPerIndent * (len(self.lists) + self.quotes)
+
+	if self.pendingItem && len(self.lists) != 0 {
+		self.pendingItem = false
+		current := self.lists[len(self.lists)-1]
+		output := fmt.Sprintf(
+			`<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`,
+			len(self.lists)-1, current.NumId,
+		)
+		if self.quotes != 0 {
+			output += fmt.Sprintf(`<w:ind w:left="%d" w:hanging="360"/>`, left)
+		}
+		return output
+	}
+	if left != 0 {
+		return fmt.Sprintf(`<w:ind w:left="%d" w:firstLine="0"/>`, left)
+	}
+	return ""
+}
+func (self *builder) writeParagraph(style string, properties string, content string) {
+	fmt.Fprintf(
+		&self.body, `<w:p><w:pPr><w:pStyle w:val="%s"/>%s</w:pPr>%s</w:p>`+"\n",
+		style, properties, content,
+	)
+}
+func (self *builder) pushParagraph(style string, content string) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	self.writeParagraph(style, self.properties(), content)
+	return nil
+}
+func (self *builder) pushTitle(level int, title string) {
+	self.writeParagraph(
+		fmt.Sprintf("Heading%d", level),
+		"<w:pageBreakBefore/>",
+		textRun("", title),
+	)
+}
+func (self *builder) PushVolume(volumeTitle string) error {
+	self.inVolume = true
+	self.hasChapter = false
+	self.pushTitle(1, volumeTitle)
+	return nil
+}
+func (self *builder) chapterLevel() int {
+	if self.inVolume {
+		return 2
+	}
+	return 1
+}
+func (self *builder) PushChapter(chapterTitle string) error {
+	self.hasChapter = true
+	self.lists = nil
+	self.quotes = 0
+	self.pushTitle(self.chapterLevel(), chapterTitle)
+	return nil
+}
+func (self *builder) PushParagraph(text string) error {
+	if self.quotes != 0 {
+		return self.pushParagraph("Quote", text)
+	}
+	if len(self.lists) != 0 {
+		return self.pushParagraph("ListParagraph", text)
+	}
+	return self.pushParagraph("Normal", text)
+}
+func (self *builder) PushHeading(level int, text string) error {
+	level = min(self.chapterLevel()+level, maxHeadingLevel)
+	return self.pushParagraph(fmt.Sprintf("Heading%d", level), text)
+}
+func (self *builder) PushCode(code string) error {
+	lines := []string{}
+
+	for _, line := range strings.Split(strings.TrimRight(code, "\n"), "\n") {
+		lines = append(lines, textRun("", line))
+	}
+	return self.pushParagraph("SourceCode", strings.Join(lines, "<w:r><w:br/></w:r>"))
+}
+func (self *builder) PushHorizontalRule() error {
+	return self.pushParagraph("HorizontalLine", "")
+}
+func (self *builder) BeginList(ordered bool) error {
+	current := list{Ordered: ordered, NumId: len(self.numbering) + 1}
+
+	self.numbering = append(self.numbering, current)
+	self.lists = append(self.lists, current)
+	return nil
+}
+func (self *builder) BeginListItem() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	self.pendingItem = true
+	return nil
+}
+func (self *builder) EndListItem() error {
+	if len(self.lists) == 0 {
+		return errors.New("List item is not created")
+	}
+	self.pendingItem = false
+	return nil
+}
+func (self *builder) EndList() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	self.lists = self.lists[:len(self.lists)-1]
+	return nil
+}
+func (self *builder) BeginBlockquote() error {
+	self.quotes++
+	return nil
+}
+func (self *builder) EndBlockquote() error {
+	if self.quotes == 0 {
+		return errors.New("Blockquote is not created")
+	}
+	self.quotes--
+	return nil
+}
+func (self *builder) BeginTable() error {
+	self.table = [][]tableCell{}
+	return nil
+}
+func (self *builder) BeginTableRow() error {
+	self.table = append(self.table, []tableCell{})
+	return nil
+}
+func (self *builder) PushTableCell(header bool, text string) error {
+	if len(self.table) == 0 {
+		return errors.New("Table row is not created")
+	}
+	row := &self.table[len(self.table)-1]
+	*row = append(*row, tableCell{Header: header, Content: text})
+	return nil
+}
+func (self *builder) EndTableRow() error {
+	return nil
+}
+func isHeaderRow(row []tableCell) bool {
+	for _, cell := range row {
+		if !cell.Header {
+			return false
+		}
+	}
+	return len(row) != 0
+}
+func (self *builder) EndTable() error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	rows := self.table
+	self.table = nil
+	columns := 0
+
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return nil
+	}
+	indent := twipsPerIndent * (len(self.lists) + self.quotes)
+	columnWidth := (tableWidth - indent) / columns
+
+	fmt.Fprintf(
+		&self.body, `<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="%d" w:type="dxa"/><w:tblInd w:w="%d" w:type="dxa"/></w:tblPr><w:tblGrid>`,
+		columnWidth*columns, indent,
+	)
+	self.body.WriteString(strings.Repeat(fmt.Sprintf(`<w:gridCol w:w="%d"/>`, columnWidth), columns))
+	self.body.WriteString("</w:tblGrid>\n")
+
+	for _, row := range rows {
+		self.body.WriteString("<w:tr>")
+		if isHeaderRow(row) {
+			self.body.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+		for index := range columns {
+			cell := tableCell{}
+			if index < len(row) {
+				cell = row[index]
+			}
+			style := "TableContents"
+			if cell.Header {
+				style = "TableHeading"
+			}
+			fmt.Fprintf(
+				&self.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/></w:tcPr><w:p><w:pPr><w:pStyle w:val="%s"/></w:pPr>%s</w:p></w:tc>`,
+				columnWidth, style, cell.Content,
+			)
+		}
+		self.body.WriteString("</w:tr>\n")
+	}
+	self.body.WriteString("</w:tbl>\n")
+	return nil
+}
+func (self *builder) BeginPoem() error {
+	return nil
+}
+func (self *builder) BeginStanza() error {
+	self.verses = []string{}
+	return nil
+}
+func (self *builder) PushVerse(text string) error {
+	self.verses = append(self.verses, text)
+	return nil
+}
+func (self *builder) EndStanza() error {
+	if len(self.verses) == 0 {
+		return nil
+	}
+	verses := self.verses
+	self.verses = nil
+	return self.pushParagraph("Verse", strings.Join(verses, "<w:r><w:br/></w:r>"))
+}
+func (self *builder) EndPoem() error {
+	return nil
+}
+func (self *builder) addImage(imagePath string) (int, office.Image, error) {
+	if index, found := self.imageIndex[imagePath]; found {
+		return index, self.images[index-1], nil
+	}
+	image, err := office.NewImage(imagePath)
+	if err != nil {
+		return 0, office.Image{}, err
+	}
+	self.images = append(self.images, image)
+	self.imageIndex[imagePath] = len(self.images)
+	return len(self.images), image, nil
+}
+func (self *builder) drawing(imagePath string) (string, error) {
+	index, image, err := self.addImage(imagePath)
+	if err != nil {
+		return "", err
+	}
+	self.drawings++
+	width, height := image.Size(maxImageWidth, maxImageHeight)
+	cx, cy := int(width*emuPerMillimeter), int(height*emuPerMillimeter)
+
+	return fmt.Sprintf(
+		`<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0">`+
+			`<wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d"/>`+
+			`<wp:cNvGraphicFramePr><a:graphicFrameLocks noChangeAspect="1"/></wp:cNvGraphicFramePr>`+
+			`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+			`<pic:pic><pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+			`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+			`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm>`+
+			`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr></pic:pic>`+
+			`</a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, self.drawings, self.drawings, self.drawings, html.EscapeString(imageName(index, image)), imageId(index), cx, cy,
+	), nil
+}
+func (self *builder) PushImage(imagePath string) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	if drawing, err := self.drawing(imagePath); err != nil {
+		return err
+	} else {
+		return self.pushParagraph("Figure", drawing)
+	}
+}
+func (self *builder) renderTitlePage() (string, error) {
+	var output strings.Builder
+	metadata := self.metadata
+
+	properties := ""
+
+	if metadata.CoverPath != "" {
+		if drawing, err := self.drawing(metadata.CoverPath); err == nil {
+			fmt.Fprintf(&output, `<w:p><w:pPr><w:pStyle w:val="Figure"/></w:pPr>%s</w:p>`+"\n", drawing)
+			properties = "<w:pageBreakBefore/>"
+		} else if !errors.Is(err, base.ErrUnsupportedImage) {
+			return "", err
+		}
+	}
+	fmt.Fprintf(&output, `<w:p><w:pPr><w:pStyle w:val="Title"/>%s</w:pPr>%s</w:p>`+"\n", properties, textRun("", metadata.Title))
+
+	if len(metadata.Authors) != 0 {
+		fmt.Fprintf(
+			&output, `<w:p><w:pPr><w:pStyle w:val="Subtitle"/></w:pPr>%s</w:p>`+"\n",
+			textRun("", strings.Join(metadata.Authors, ", ")),
+		)
+	}
+	for _, line := range strings.Split(metadata.Annotation, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(&output, `<w:p><w:pPr><w:pStyle w:val="Normal"/></w:pPr>%s</w:p>`+"\n", textRun("", line))
+		}
+	}
+	return output.String(), nil
+}
+func (self *builder) Build(filename string) error {
+	titlePage, err := self.renderTitlePage()
+	if err != nil {
+		return err
+	}
+	archive, err := office.CreateArchive(filename)
+	if err != nil {
+		return err
+	}
+	if err := self.writeContent(archive, titlePage); err != nil {
+		archive.Close()
+		return err
+	}
+	return archive.Close()
+}
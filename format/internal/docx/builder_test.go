@@ -0,0 +1,149 @@
+package docx
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/nodehandler"
+	"ranobedl/format/internal/testutil"
+	"ranobedl/schema"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "image.png")
+
+	if file, err := os.Create(imagePath); err != nil {
+		t.Fatal(err)
+	} else if err := png.Encode(file, image.NewGray(image.Rect(0, 0, 96, 48))); err != nil {
+		t.Fatal(err)
+	} else {
+		file.Close()
+	}
+	doc := schema.Node{
+		Type: schema.NodeTypeDoc,
+		Content: []schema.Node{
+			testutil.Paragraph(testutil.Text("Первый "), testutil.Text("жирный", schema.MarkTypeBold), testutil.Text(" & "), testutil.Text("код", schema.MarkTypeCode)),
+			{Type: schema.NodeTypeHeading, Attrs: map[string]any{"level": 1}, Content: []schema.Node{testutil.Text("Заголовок")}},
+			{Type: schema.NodeTypeOrderedList, Content: []schema.Node{
+				testutil.Item(testutil.Paragraph(testutil.Text("первый"))),
+				testutil.Item(testutil.Paragraph(testutil.Text("второй")), testutil.Paragraph(testutil.Text("продолжение"))),
+			}},
+			{Type: schema.NodeTypeBlockquote, Content: []schema.Node{testutil.Text("цитата")}},
+			{Type: schema.NodeTypeCodeBlock, Content: []schema.Node{testutil.Text("a\n  b")}},
+			testutil.Image(imagePath),
+			{Type: schema.NodeTypeHorizontalRule},
+			{Type: schema.NodeTypeTable, Content: []schema.Node{
+				{Type: schema.NodeTypeTableRow, Content: []schema.Node{
+					{Type: schema.NodeTypeTableHeader, Content: []schema.Node{testutil.Text("Имя")}},
+					{Type: schema.NodeTypeTableHeader, Content: []schema.Node{testutil.Text("Значение")}},
+				}},
+				{Type: schema.NodeTypeTableRow, Content: []schema.Node{
+					{Type: schema.NodeTypeTableCell, Content: []schema.Node{testutil.Text("ячейка")}},
+				}},
+			}},
+			{Type: schema.NodeTypePoem, Content: []schema.Node{
+				testutil.Paragraph(testutil.Text("строка"), schema.Node{Type: schema.NodeTypeHardBreak}, testutil.Text("вторая")),
+			}},
+		},
+	}
+	book := NewBuilder()
+	book.SetMetadata(base.Metadata{Title: "Книга", Authors: []string{"Автор"}, CoverPath: imagePath})
+
+	if err := book.PushParagraph("text"); err == nil {
+		t.Errorf("PushParagraph() without chapter expected error")
+	}
+	if err := book.PushVolume("Том 1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, chapter := range []string{"Глава 1", "Глава 2"} {
+		if err := book.PushChapter(chapter); err != nil {
+			t.Fatal(err)
+		}
+		if err := nodehandler.PushBlock(book, RenderInline, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(dir, "book.docx")
+	if err := book.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	files := testutil.ReadArchive(t, output)
+	document := files["word/document.xml"]
+
+	expected := []string{
+		`<w:trPr><w:tblHeader/></w:trPr>`,
+		`<w:pStyle w:val="TableHeading"/></w:pPr><w:r><w:t xml:space="preserve">Имя</w:t>`,
+		`<w:pStyle w:val="Verse"/></w:pPr><w:r><w:t xml:space="preserve">строка</w:t></w:r><w:r><w:br/></w:r>`,
+		`<w:pStyle w:val="Heading1"/><w:pageBreakBefore/></w:pPr><w:r><w:t xml:space="preserve">Том 1</w:t>`,
+		`<w:pStyle w:val="Heading2"/><w:pageBreakBefore/></w:pPr><w:r><w:t xml:space="preserve">Глава 2</w:t>`,
+		`<w:pStyle w:val="Heading3"/></w:pPr><w:r><w:t xml:space="preserve">Заголовок</w:t>`,
+		`<w:rPr><w:b/><w:bCs/></w:rPr><w:t xml:space="preserve">жирный</w:t>`,
+		`<w:t xml:space="preserve"> &amp; </w:t>`,
+		`<w:rStyle w:val="VerbatimChar"/>`,
+		`<w:pStyle w:val="ListParagraph"/><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr>`,
+		`<w:pStyle w:val="ListParagraph"/><w:ind w:left="720" w:firstLine="0"/></w:pPr><w:r><w:t xml:space="preserve">продолжение`,
+		`<w:pStyle w:val="Quote"/><w:ind w:left="720" w:firstLine="0"/></w:pPr><w:r><w:t xml:space="preserve">цитата`,
+		`<w:t xml:space="preserve">a</w:t></w:r><w:r><w:br/></w:r><w:r><w:t xml:space="preserve">  b</w:t>`,
+		`<w:pStyle w:val="HorizontalLine"/>`,
+		`<a:blip r:embed="rIdImage1"/>`,
+		`<w:pStyle w:val="Title"/><w:pageBreakBefore/></w:pPr><w:r><w:t xml:space="preserve">Книга</w:t>`,
+	}
+	for _, fragment := range expected {
+		if !strings.Contains(document, fragment) {
+			t.Errorf("document.xml does not contain %q", fragment)
+		}
+	}
+	if count := strings.Count(files["word/numbering.xml"], "<w:num "); count != 2 {
+		t.Errorf("numbering.xml has %d lists; want 2", count)
+	}
+	if _, found := files["word/media/image0001.png"]; !found {
+		t.Errorf("Build() output does not contain word/media/image0001.png")
+	}
+	if _, found := files["word/media/image0002.png"]; found {
+		t.Errorf("Build() embedded the same image twice")
+	}
+	for _, id := range []string{`<wp:docPr id="1"`, `<wp:docPr id="2"`, `<wp:docPr id="3"`} {
+		if !strings.Contains(document, id) {
+			t.Errorf("document.xml does not contain %q", id)
+		}
+	}
+	if !strings.Contains(files["docProps/core.xml"], "<dc:title>Книга</dc:title>") {
+		t.Errorf("core.xml does not contain title")
+	}
+}
+func TestUnsupportedImage(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "image.png")
+
+	if err := os.WriteFile(imagePath, []byte("\x89PNG\r\n\x1a\nbroken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	book := NewBuilder()
+	book.SetMetadata(base.Metadata{Title: "Книга", CoverPath: imagePath})
+
+	if err := book.PushChapter("Глава"); err != nil {
+		t.Fatal(err)
+	}
+	image := testutil.Image(imagePath)
+
+	if err := nodehandler.PushBlock(book, RenderInline, image); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "book.docx")
+	if err := book.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	document := testutil.ReadArchive(t, output)["word/document.xml"]
+
+	if !strings.Contains(document, base.UnsupportedImageText) {
+		t.Errorf("document.xml does not contain the image placeholder")
+	}
+	if strings.Contains(document, "<w:drawing>") {
+		t.Errorf("document.xml contains a drawing of an unsupported image")
+	}
+}
@@ -0,0 +1,104 @@
+package docx
+
+import (
+	"fmt"
+	"html"
+	"ranobedl/schema"
+	"strings"
+)
+
+const imagePlaceholder = "[Иллюстрация]"
+
+func textRun(properties string, text string) string {
+	var output strings.Builder
+
+	output.WriteString("<w:r>")
+	if properties != "" {
+		output.WriteString("<w:rPr>" + properties + "</w:rPr>")
+	}
+	for index, part := range strings.Split(text, "\t") {
+		if index > 0 {
+			output.WriteString("<w:tab/>")
+		}
+		if part != "" {
+			fmt.Fprintf(&output, `<w:t xml:space="preserve">%s</w:t>`, html.EscapeString(part))
+		}
+	}
+	output.WriteString("</w:r>")
+	return output.String()
+}
+func runProperties(node schema.Node) string {
+	var style, bold, italic, strike, underline string
+
+	for _, mark := range node.Marks {
+		switch mark.Type {
+		case schema.MarkTypeBold:
+			bold = "<w:b/><w:bCs/>"
+		case schema.MarkTypeItalic:
+			italic = "<w:i/><w:iCs/>"
+		case schema.MarkTypeUnderline:
+			underline = `<w:u w:val="single"/>`
+		case schema.MarkTypeStrike:
+			strike = "<w:strike/>"
+		case schema.MarkTypeCode:
+			style = `<w:rStyle w:val="VerbatimChar"/>`
+		case schema.MarkTypeLink:
+			if style == "" {
+				style = `<w:rStyle w:val="Hyperlink"/>`
+			}
+		default:
+			panic(fmt.Sprintf("Undefined MarkType: %d", mark.Type))
+		}
+	}
+	return style + bold + italic + strike + underline
+}
+func linkHref(node schema.Node) (string, error) {
+	for _, mark := range node.Marks {
+		if mark.Type == schema.MarkTypeLink {
+			return mark.LinkHref()
+		}
+	}
+	return "", nil
+}
+func renderText(node schema.Node) (string, error) {
+	text := strings.NewReplacer("\r\n", " ", "\n", " ").Replace(node.Text)
+	output := textRun(runProperties(node), text)
+
+	if href, err := linkHref(node); err != nil {
+		return "", err
+	} else if href != "" {
+		output = `<w:r><w:fldChar w:fldCharType="begin"/></w:r>` +
+			fmt.Sprintf(
+				`<w:r><w:instrText xml:space="preserve"> HYPERLINK "%s" </w:instrText></w:r>`,
+				html.EscapeString(strings.ReplaceAll(href, `"`, "%22")),
+			) +
+			`<w:r><w:fldChar w:fldCharType="separate"/></w:r>` +
+			output +
+			`<w:r><w:fldChar w:fldCharType="end"/></w:r>`
+	}
+	return output, nil
+}
+func renderInline(node schema.Node) (string, error) {
+	switch node.Type {
+	case schema.NodeTypeText:
+		return renderText(node)
+	case schema.NodeTypeHardBreak:
+		return "<w:r><w:br/></w:r>", nil
+	case schema.NodeTypeImage:
+		return textRun("", imagePlaceholder), nil
+	default:
+		panic("Unreachable code")
+	}
+}
+func RenderInline(node []schema.Node) (string, error) {
+	output := ""
+
+	for _, child := range node {
+		if rendered, err := renderInline(child); err != nil {
+			return "", err
+		} else {
+			output += rendered
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,320 @@
+package epub
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/xhtml"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxHeadingLevel = 6
+
+type builder struct {
+	identifier     string
+	metadata       base.Metadata
+	chapters       []chapter
+	images         []image
+	hrefs          map[string]string
+	currentChapter *chapter
+	inVolume       bool
+	lists          []string
+}
+type chapter struct {
+	Id     string
+	Href   string
+	Title  string
+	Level  int
+	Blocks []string
+}
+type image struct {
+	Id        string
+	Href      string
+	MediaType string
+	Path      string
+}
+
+func NewBuilder() *builder {
+	return &builder{
+		identifier: "urn:uuid:" + uuid.NewString(),
+		chapters:   []chapter{},
+		images:     []image{},
+		hrefs:      map[string]string{},
+	}
+}
+func (self *builder) SetMetadata(metadata base.Metadata) {
+	self.metadata = metadata
+}
+func (self *builder) pushDocument(title string, level int) {
+	index := len(self.chapters) + 1
+
+	self.chapters = append(self.chapters, chapter{
+		Id:    fmt.Sprintf("chapter%04d", index),
+		Href:  fmt.Sprintf("chapter%04d.xhtml", index),
+		Title: title,
+		Level: level,
+	})
+}
+func (self *builder) PushVolume(volumeTitle string) error {
+	self.pushDocument(volumeTitle, 0)
+	self.currentChapter = nil
+	self.inVolume = true
+	return nil
+}
+func (self *builder) PushChapter(chapterTitle string) error {
+	if self.inVolume {
+		self.pushDocument(chapterTitle, 1)
+	} else {
+		self.pushDocument(chapterTitle, 0)
+	}
+	self.currentChapter = &self.chapters[len(self.chapters)-1]
+	return nil
+}
+func (self *builder) pushBlock(block string) error {
+	if self.currentChapter == nil {
+		return errors.New("Chapter is not created")
+	}
+	self.currentChapter.Blocks = append(self.currentChapter.Blocks, block)
+	return nil
+}
+func (self *builder) inline(text string) (string, error) {
+	return xhtml.ReplaceImages(text, self.addImage)
+}
+func (self *builder) PushParagraph(text string) error {
+	if text, err := self.inline(text); err != nil {
+		return err
+	} else {
+		return self.pushBlock(fmt.Sprintf("<p>%s</p>", text))
+	}
+}
+func (self *builder) PushHeading(level int, text string) error {
+	text, err := self.inline(text)
+	if err != nil {
+		return err
+	}
+	level = min(level+1, maxHeadingLevel)
+	return self.pushBlock(fmt.Sprintf("<h%d>%s</h%d>", level, text, level))
+}
+func (self *builder) PushCode(code string) error {
+	return self.pushBlock(fmt.Sprintf("<pre><code>%s</code></pre>", html.EscapeString(code)))
+}
+func (self *builder) PushHorizontalRule() error {
+	return self.pushBlock("<hr/>")
+}
+func (self *builder) BeginList(ordered bool) error {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	self.lists = append(self.lists, tag)
+	return self.pushBlock("<" + tag + ">")
+}
+func (self *builder) BeginListItem() error {
+	return self.pushBlock("<li>")
+}
+func (self *builder) EndListItem() error {
+	return self.pushBlock("</li>")
+}
+func (self *builder) EndList() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	tag := self.lists[len(self.lists)-1]
+	self.lists = self.lists[:len(self.lists)-1]
+
+	return self.pushBlock("</" + tag + ">")
+}
+func (self *builder) BeginBlockquote() error {
+	return self.pushBlock("<blockquote>")
+}
+func (self *builder) EndBlockquote() error {
+	return self.pushBlock("</blockquote>")
+}
+func (self *builder) BeginTable() error {
+	return self.pushBlock("<table>")
+}
+func (self *builder) BeginTableRow() error {
+	return self.pushBlock("<tr>")
+}
+func (self *builder) PushTableCell(header bool, text string) error {
+	text, err := self.inline(text)
+	if err != nil {
+		return err
+	}
+	if header {
+		return self.pushBlock(fmt.Sprintf("<th>%s</th>", text))
+	}
+	return self.pushBlock(fmt.Sprintf("<td>%s</td>", text))
+}
+func (self *builder) EndTableRow() error {
+	return self.pushBlock("</tr>")
+}
+func (self *builder) EndTable() error {
+	return self.pushBlock("</table>")
+}
+func (self *builder) BeginPoem() error {
+	return self.pushBlock(`<div class="poem">`)
+}
+func (self *builder) BeginStanza() error {
+	return self.pushBlock(`<div class="stanza">`)
+}
+func (self *builder) PushVerse(text string) error {
+	if text, err := self.inline(text); err != nil {
+		return err
+	} else {
+		return self.pushBlock(fmt.Sprintf("<p>%s</p>", text))
+	}
+}
+func (self *builder) EndStanza() error {
+	return self.pushBlock("</div>")
+}
+func (self *builder) EndPoem() error {
+	return self.pushBlock("</div>")
+}
+func mediaType(imagePath string) string {
+	switch strings.ToLower(filepath.Ext(imagePath)) {
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".svg":
+		return "image/svg+xml"
+	default:
+		return "image/jpeg"
+	}
+}
+func (self *builder) addImage(imagePath string) (string, error) {
+	if href, found := self.hrefs[imagePath]; found {
+		return href, nil
+	}
+	if _, err := os.Stat(imagePath); err != nil {
+		return "", err
+	}
+	index := len(self.images) + 1
+
+	image := image{
+		Id:        fmt.Sprintf("image%04d", index),
+		Href:      fmt.Sprintf("images/image%04d%s", index, strings.ToLower(filepath.Ext(imagePath))),
+		MediaType: mediaType(imagePath),
+		Path:      imagePath,
+	}
+	self.images = append(self.images, image)
+	self.hrefs[imagePath] = image.Href
+	return image.Href, nil
+}
+func (self *builder) PushImage(imagePath string) error {
+	if self.currentChapter == nil {
+		return errors.New("Chapter is not created")
+	}
+	href, err := self.addImage(imagePath)
+	if err != nil {
+		return err
+	}
+	return self.pushBlock(fmt.Sprintf(
+		`<div class="image"><img src="%s" alt=""/></div>`,
+		html.EscapeString(href),
+	))
+}
+func (self *builder) writeMimetype(writer *zip.Writer) error {
+	if file, err := writer.CreateHeader(&zip.FileHeader{
+		Name:   "mimetype",
+		Method: zip.Store,
+	}); err != nil {
+		return err
+	} else {
+		_, err := io.WriteString(file, "application/epub+zip")
+		return err
+	}
+}
+func (self *builder) writeString(writer *zip.Writer, name string, content string) error {
+	if file, err := writer.Create(name); err != nil {
+		return err
+	} else {
+		_, err := io.WriteString(file, content)
+		return err
+	}
+}
+func (self *builder) writeImage(writer *zip.Writer, image image) error {
+	source, err := os.Open(image.Path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	if file, err := writer.Create("OEBPS/" + image.Href); err != nil {
+		return err
+	} else {
+		_, err := io.Copy(file, source)
+		return err
+	}
+}
+func (self *builder) cover() *image {
+	if self.metadata.CoverPath == "" {
+		return nil
+	}
+	return &image{
+		Id:        "cover-image",
+		Href:      "images/cover" + strings.ToLower(filepath.Ext(self.metadata.CoverPath)),
+		MediaType: mediaType(self.metadata.CoverPath),
+		Path:      self.metadata.CoverPath,
+	}
+}
+func (self *builder) writeContent(writer *zip.Writer) error {
+	if err := self.writeMimetype(writer); err != nil {
+		return err
+	}
+	if err := self.writeString(writer, "META-INF/container.xml", containerXml); err != nil {
+		return err
+	}
+	if err := self.writeString(writer, "OEBPS/style.css", styleCss); err != nil {
+		return err
+	}
+	if err := self.writeString(writer, "OEBPS/content.opf", self.renderPackage()); err != nil {
+		return err
+	}
+	if err := self.writeString(writer, "OEBPS/nav.xhtml", self.renderNav()); err != nil {
+		return err
+	}
+	for _, chapter := range self.chapters {
+		if err := self.writeString(writer, "OEBPS/"+chapter.Href, self.renderChapter(chapter)); err != nil {
+			return err
+		}
+	}
+	for _, image := range self.images {
+		if err := self.writeImage(writer, image); err != nil {
+			return err
+		}
+	}
+	if cover := self.cover(); cover != nil {
+		return self.writeImage(writer, *cover)
+	}
+	return nil
+}
+func (self *builder) Build(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+
+	if err := self.writeContent(writer); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+func (self *builder) modified() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05Z")
+}
@@ -0,0 +1,119 @@
+package epub
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/nodehandler"
+	"ranobedl/format/internal/testutil"
+	"ranobedl/schema"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var manifestItem = regexp.MustCompile(`<item id="([^"]*)" href="([^"]*)"`)
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "image.png")
+
+	if err := os.WriteFile(imagePath, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc := testutil.Block(schema.NodeTypeDoc,
+		testutil.Paragraph(testutil.Text("Первый "), testutil.Text("жирный", schema.MarkTypeBold), testutil.Text(" & "), testutil.Image(imagePath)),
+		testutil.Image(imagePath),
+		testutil.Block(schema.NodeTypeBulletList, testutil.Item(testutil.Paragraph(testutil.Text("пункт")))),
+	)
+	book := NewBuilder()
+	book.SetMetadata(base.Metadata{Title: "Книга", Authors: []string{"Автор"}, Language: "ru", CoverPath: imagePath})
+
+	if err := book.PushParagraph("text"); err == nil {
+		t.Errorf("PushParagraph() without chapter expected error")
+	}
+	for _, volume := range []string{"Том 1", "Том 2"} {
+		if err := book.PushVolume(volume); err != nil {
+			t.Fatal(err)
+		}
+		for _, chapter := range []string{"Глава 1", "Глава 2"} {
+			if err := book.PushChapter(chapter); err != nil {
+				t.Fatal(err)
+			}
+			if err := nodehandler.PushBlock(book, RenderInline, doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	output := filepath.Join(dir, "book.epub")
+	if err := book.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := reader.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("Build() first entry = %s (method %d); want stored mimetype", first.Name, first.Method)
+	}
+	reader.Close()
+
+	files := testutil.ReadArchive(t, output)
+	pkg := files["OEBPS/content.opf"]
+
+	if files["mimetype"] != "application/epub+zip" {
+		t.Errorf("mimetype = %q", files["mimetype"])
+	}
+	if !strings.Contains(files["META-INF/container.xml"], `full-path="OEBPS/content.opf"`) {
+		t.Errorf("container.xml does not point to content.opf")
+	}
+	ids := []string{}
+
+	for _, match := range manifestItem.FindAllStringSubmatch(pkg, -1) {
+		ids = append(ids, match[1])
+
+		if _, found := files["OEBPS/"+match[2]]; !found {
+			t.Errorf("manifest item %s is missing from the archive", match[2])
+		}
+	}
+	expectedIds := "nav style chapter0001 chapter0002 chapter0003 chapter0004 chapter0005 chapter0006 image0001 cover-image"
+	if strings.Join(ids, " ") != expectedIds {
+		t.Errorf("manifest ids = %v; want %s", ids, expectedIds)
+	}
+	expected := []string{
+		`<item id="image0001" href="images/image0001.png" media-type="image/png"/>`,
+		`<item id="cover-image" href="images/cover.png" media-type="image/png" properties="cover-image"/>`,
+		`<meta name="cover" content="cover-image"/>`,
+		`<dc:creator>Автор</dc:creator>`,
+		"<spine>\n    <itemref idref=\"chapter0001\"/>\n    <itemref idref=\"chapter0002\"/>\n    <itemref idref=\"chapter0003\"/>\n" +
+			"    <itemref idref=\"chapter0004\"/>\n    <itemref idref=\"chapter0005\"/>\n    <itemref idref=\"chapter0006\"/>\n  </spine>",
+	}
+	for _, fragment := range expected {
+		if !strings.Contains(pkg, fragment) {
+			t.Errorf("content.opf does not contain %q", fragment)
+		}
+	}
+	nav := "<li><a href=\"chapter0004.xhtml\">Том 2</a>\n        <ol>\n" +
+		"          <li><a href=\"chapter0005.xhtml\">Глава 1</a></li>\n" +
+		"          <li><a href=\"chapter0006.xhtml\">Глава 2</a></li>\n        </ol>\n      </li>"
+	if !strings.Contains(files["OEBPS/nav.xhtml"], nav) {
+		t.Errorf("nav.xhtml does not contain the nested volume:\n%s", files["OEBPS/nav.xhtml"])
+	}
+	chapter := files["OEBPS/chapter0002.xhtml"]
+
+	expected = []string{
+		`<h1>Глава 1</h1>`,
+		`<p>Первый <strong>жирный</strong> &amp; <img src="images/image0001.png" alt=""/></p>`,
+		`<div class="image"><img src="images/image0001.png" alt=""/></div>`,
+		"<ul>\n  <li>\n  <p>пункт</p>\n  </li>\n  </ul>",
+	}
+	for _, fragment := range expected {
+		if !strings.Contains(chapter, fragment) {
+			t.Errorf("chapter0002.xhtml does not contain %q", fragment)
+		}
+	}
+	if strings.Contains(files["OEBPS/chapter0001.xhtml"], "<p>") {
+		t.Errorf("volume document contains chapter content")
+	}
+}
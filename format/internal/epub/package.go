@@ -0,0 +1,204 @@
+package epub
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const defaultLanguage = "ru"
+const containerXml = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+const styleCss = `body {
+  margin: 0 5%;
+  text-align: justify;
+}
+h1 {
+  text-align: center;
+  margin: 1em 0;
+}
+p {
+  margin: 0;
+  text-indent: 1.5em;
+}
+div.image {
+  text-align: center;
+  margin: 1em 0;
+}
+div.image img {
+  max-width: 100%;
+}
+table {
+  border-collapse: collapse;
+  margin: 1em 0;
+}
+th, td {
+  border: 1px solid;
+  padding: 0.2em 0.5em;
+  text-align: left;
+}
+div.poem {
+  margin: 1em 10%;
+}
+div.stanza {
+  margin: 1em 0;
+}
+div.stanza p {
+  text-indent: 0;
+  text-align: left;
+}
+`
+
+func (self *builder) renderPackage() string {
+	var output strings.Builder
+
+	output.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&output, "<package xmlns=\"http://www.idpf.org/2007/opf\" version=\"3.0\" unique-identifier=\"book-id\" xml:lang=\"%s\">\n", self.language())
+
+	output.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` + "\n")
+	fmt.Fprintf(&output, "    <dc:identifier id=\"book-id\">%s</dc:identifier>\n", html.EscapeString(self.identifier))
+	self.renderMetadata(&output)
+	fmt.Fprintf(&output, "    <meta property=\"dcterms:modified\">%s</meta>\n", self.modified())
+	if self.cover() != nil {
+		output.WriteString(`    <meta name="cover" content="cover-image"/>` + "\n")
+	}
+	output.WriteString("  </metadata>\n")
+
+	output.WriteString("  <manifest>\n")
+	output.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	output.WriteString(`    <item id="style" href="style.css" media-type="text/css"/>` + "\n")
+	for _, chapter := range self.chapters {
+		fmt.Fprintf(&output, "    <item id=\"%s\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", chapter.Id, chapter.Href)
+	}
+	for _, image := range self.images {
+		fmt.Fprintf(&output, "    <item id=\"%s\" href=\"%s\" media-type=\"%s\"/>\n", image.Id, html.EscapeString(image.Href), image.MediaType)
+	}
+	if cover := self.cover(); cover != nil {
+		fmt.Fprintf(&output, "    <item id=\"%s\" href=\"%s\" media-type=\"%s\" properties=\"cover-image\"/>\n", cover.Id, html.EscapeString(cover.Href), cover.MediaType)
+	}
+	output.WriteString("  </manifest>\n")
+
+	output.WriteString("  <spine>\n")
+	for _, chapter := range self.chapters {
+		fmt.Fprintf(&output, "    <itemref idref=\"%s\"/>\n", chapter.Id)
+	}
+	output.WriteString("  </spine>\n")
+	output.WriteString("</package>\n")
+
+	return output.String()
+}
+func (self *builder) language() string {
+	if self.metadata.Language != "" {
+		return html.EscapeString(self.metadata.Language)
+	}
+	return defaultLanguage
+}
+func (self *builder) renderElement(output *strings.Builder, name string, value string) {
+	if value != "" {
+		fmt.Fprintf(output, "    <%s>%s</%s>\n", name, html.EscapeString(value), name)
+	}
+}
+func (self *builder) renderMetadata(output *strings.Builder) {
+	metadata := self.metadata
+
+	output.WriteString("    <dc:title id=\"title\">" + html.EscapeString(metadata.Title) + "</dc:title>\n")
+	output.WriteString("    <meta refines=\"#title\" property=\"title-type\">main</meta>\n")
+	if metadata.OriginalTitle != metadata.Title {
+		self.renderElement(output, "dc:title", metadata.OriginalTitle)
+	}
+	for _, altTitle := range metadata.AltTitles {
+		self.renderElement(output, "dc:title", altTitle)
+	}
+	for _, author := range metadata.Authors {
+		self.renderElement(output, "dc:creator", author)
+	}
+	self.renderElement(output, "dc:description", metadata.Annotation)
+	for _, subject := range append(append([]string{}, metadata.Genres...), metadata.Tags...) {
+		self.renderElement(output, "dc:subject", subject)
+	}
+	fmt.Fprintf(output, "    <dc:language>%s</dc:language>\n", self.language())
+	self.renderElement(output, "dc:publisher", metadata.Publisher)
+	self.renderElement(output, "dc:date", metadata.Year)
+	self.renderElement(output, "dc:source", metadata.Source)
+
+	if metadata.Series != "" {
+		fmt.Fprintf(output, "    <meta property=\"belongs-to-collection\" id=\"series\">%s</meta>\n", html.EscapeString(metadata.Series))
+		output.WriteString("    <meta refines=\"#series\" property=\"collection-type\">series</meta>\n")
+		fmt.Fprintf(output, "    <meta refines=\"#series\" property=\"group-position\">%d</meta>\n", metadata.SeriesNumber)
+		fmt.Fprintf(output, "    <meta name=\"calibre:series\" content=\"%s\"/>\n", html.EscapeString(metadata.Series))
+		fmt.Fprintf(output, "    <meta name=\"calibre:series_index\" content=\"%d\"/>\n", metadata.SeriesNumber)
+	}
+}
+func (self *builder) renderDocument(title string, body string) string {
+	var output strings.Builder
+
+	output.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	output.WriteString("<!DOCTYPE html>\n")
+	fmt.Fprintf(&output, "<html xmlns=\"http://www.w3.org/1999/xhtml\" xmlns:epub=\"http://www.idpf.org/2007/ops\" xml:lang=\"%s\" lang=\"%s\">\n", self.language(), self.language())
+	output.WriteString("<head>\n")
+	fmt.Fprintf(&output, "  <title>%s</title>\n", html.EscapeString(title))
+	output.WriteString(`  <link rel="stylesheet" type="text/css" href="style.css"/>` + "\n")
+	output.WriteString("</head>\n")
+	output.WriteString("<body>\n")
+	output.WriteString(body)
+	output.WriteString("</body>\n")
+	output.WriteString("</html>\n")
+
+	return output.String()
+}
+
+type navEntry struct {
+	chapter
+	Children []chapter
+}
+
+func (self *builder) navEntries() []navEntry {
+	entries := []navEntry{}
+
+	for _, chapter := range self.chapters {
+		if chapter.Level == 0 || len(entries) == 0 {
+			entries = append(entries, navEntry{chapter: chapter})
+		} else {
+			last := &entries[len(entries)-1]
+			last.Children = append(last.Children, chapter)
+		}
+	}
+	return entries
+}
+func (self *builder) renderNav() string {
+	var body strings.Builder
+
+	body.WriteString("  <nav epub:type=\"toc\" id=\"toc\">\n")
+	fmt.Fprintf(&body, "    <h1>%s</h1>\n", html.EscapeString(self.metadata.Title))
+	body.WriteString("    <ol>\n")
+	for _, entry := range self.navEntries() {
+		fmt.Fprintf(&body, "      <li><a href=\"%s\">%s</a>", entry.Href, html.EscapeString(entry.Title))
+
+		if len(entry.Children) != 0 {
+			body.WriteString("\n        <ol>\n")
+			for _, child := range entry.Children {
+				fmt.Fprintf(&body, "          <li><a href=\"%s\">%s</a></li>\n", child.Href, html.EscapeString(child.Title))
+			}
+			body.WriteString("        </ol>\n      ")
+		}
+		body.WriteString("</li>\n")
+	}
+	body.WriteString("    </ol>\n")
+	body.WriteString("  </nav>\n")
+
+	return self.renderDocument(self.metadata.Title, body.String())
+}
+func (self *builder) renderChapter(chapter chapter) string {
+	var body strings.Builder
+
+	fmt.Fprintf(&body, "  <h1>%s</h1>\n", html.EscapeString(chapter.Title))
+	for _, block := range chapter.Blocks {
+		body.WriteString("  " + block + "\n")
+	}
+	return self.renderDocument(chapter.Title, body.String())
+}
@@ -1,149 +1,469 @@
 package fb2
 
 import (
+	"bufio"
 	"encoding/base64"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"html"
+	"io"
 	"os"
-	"path"
-	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/imageproc"
+	"regexp"
 	"strings"
-	"time"
 )
 
+const emptyLine = "<empty-line/>"
+const horizontalRule = "* * *"
+
 type builder struct {
-	document       document
-	currentSection *section
+	metadata     base.Metadata
+	body         *os.File
+	output       *bufio.Writer
+	lists        []list
+	itemPrefix   string
+	quotes       int
+	quote        strings.Builder
+	poem         strings.Builder
+	verses       []string
+	rows         []string
+	row          strings.Builder
+	images       map[string]string
+	binaries     []binary
+	sections     int
+	inVolume     bool
+	hasChapter   bool
+	volumeBlocks int
+	blocks       int
+	slotImage    bool
 }
-type document struct {
-	XMLName          xml.Name    `xml:"FictionBook"`
-	XMLNamespace     string      `xml:"xmlns,attr"`
-	XMLNamespaceLink string      `xml:"xmlns:l,attr"`
-	Description      description `xml:"description"`
-	Body             body        `xml:"body"`
-	Binary           []binary    `xml:"binary"`
+type paragraph struct {
+	Text string `xml:",innerxml"`
 }
-type description struct {
-	TitleInfo titleInfo `xml:"title-info"`
+type binary struct {
+	ID   string
+	Path string
 }
-type titleInfo struct {
-	BookTitle string   `xml:"book-title"`
-	Author    []author `xml:"author"`
-	Date      string   `xml:"date"`
+
+type list struct {
+	Ordered bool
+	Index   int
 }
-type author struct {
-	FirstName string `xml:"first-name"`
-	LastName  string `xml:"last-name"`
+
+var inlineImage = regexp.MustCompile(`<image l:href="([^"]*)"/>`)
+var leadingSpaces = regexp.MustCompile(`^ +`)
+
+func NewBuilder() *builder {
+	return &builder{
+		images: map[string]string{},
+	}
 }
-type body struct {
-	Sections []section `xml:"section"`
+func (self *builder) SetMetadata(metadata base.Metadata) {
+	self.metadata = metadata
 }
-type section struct {
-	Title      title       `xml:"title"`
-	Paragraphs []paragraph `xml:"p"`
+func (self *builder) writeBody(text string) error {
+	if self.body == nil {
+		if file, err := os.CreateTemp("", "ranobedl-*.fb2"); err != nil {
+			return err
+		} else {
+			self.body = file
+			self.output = bufio.NewWriter(file)
+		}
+	}
+	_, err := self.output.WriteString(text)
+	return err
 }
-type title struct {
-	Paragraph string `xml:"p"`
+func (self *builder) closeChapter() error {
+	if !self.hasChapter {
+		return nil
+	}
+	self.hasChapter = false
+
+	if self.blocks == 0 || self.blocks == 1 && self.slotImage {
+		if err := self.writeBody(emptyLine + "\n"); err != nil {
+			return err
+		}
+	}
+	return self.writeBody("</section>\n")
 }
-type paragraph struct {
-	Text string `xml:",innerxml"`
+func (self *builder) closeVolume() error {
+	if err := self.closeChapter(); err != nil {
+		return err
+	}
+	if !self.inVolume {
+		return nil
+	}
+	self.inVolume = false
+
+	if self.volumeBlocks == 0 {
+		if err := self.writeBody(emptyLine + "\n"); err != nil {
+			return err
+		}
+	}
+	return self.writeBody("</section>\n")
 }
-type binary struct {
-	ID          string `xml:"id,attr"`
-	ContentType string `xml:"content-type,attr"`
-	Data        string `xml:",chardata"`
+func (self *builder) openSection(sectionTitle string) error {
+	self.sections++
+	return self.writeBody(fmt.Sprintf("<section>\n<title><p>%s</p></title>\n", html.EscapeString(sectionTitle)))
+}
+func (self *builder) PushVolume(volumeTitle string) error {
+	if err := self.closeVolume(); err != nil {
+		return err
+	}
+	self.inVolume = true
+	self.volumeBlocks = 0
+	return self.openSection(volumeTitle)
+}
+func (self *builder) PushChapter(chapterTitle string) error {
+	if err := self.closeChapter(); err != nil {
+		return err
+	}
+	if err := self.openSection(chapterTitle); err != nil {
+		return err
+	}
+	self.hasChapter = true
+	self.volumeBlocks++
+	self.blocks = 0
+	self.slotImage = false
+	self.lists = nil
+	self.itemPrefix = ""
+	self.quotes = 0
+	self.quote.Reset()
+	return nil
 }
+func (self *builder) write(block string) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	if self.quotes != 0 {
+		self.quote.WriteString(block)
+		return nil
+	}
+	self.blocks++
+	return self.writeBody(block + "\n")
+}
+func checkImage(imagePath string) error {
+	if format, err := imageproc.SniffFile(imagePath); err != nil {
+		return err
+	} else if format != imageproc.FormatUnknown && !format.Native() {
+		return fmt.Errorf("%w %s: %s is not supported by FB2 readers", base.ErrUnsupportedImage, imagePath, format.MediaType())
+	}
+	return nil
+}
+func (self *builder) registerImage(imagePath string) (string, error) {
+	if id, found := self.images[imagePath]; found {
+		return id, nil
+	}
+	if err := checkImage(imagePath); err != nil {
+		return "", err
+	}
+	id := fmt.Sprintf("image%04d", len(self.images)+1)
+	self.images[imagePath] = id
+	self.binaries = append(self.binaries, binary{ID: id, Path: imagePath})
 
-func NewBuilder() *builder {
-	fb2 := document{
-		Description: description{
-			TitleInfo: titleInfo{
-				BookTitle: "",
-				Author: []author{{
-					FirstName: "",
-					LastName:  "",
-				}},
-				Date: time.Now().Format("2006-01-02"),
-			},
-		},
-		Body: body{
-			Sections: []section{},
-		},
+	return id, nil
+}
+func (self *builder) inline(text string) (string, error) {
+	var err error
+
+	output := inlineImage.ReplaceAllStringFunc(text, func(match string) string {
+		imagePath := html.UnescapeString(inlineImage.FindStringSubmatch(match)[1])
+
+		if id, registerErr := self.registerImage(imagePath); errors.Is(registerErr, base.ErrUnsupportedImage) {
+			return html.EscapeString(base.UnsupportedImageText)
+		} else if registerErr != nil {
+			err = registerErr
+			return match
+		} else {
+			return fmt.Sprintf(`<image l:href="#%s"/>`, id)
+		}
+	})
+	return output, err
+}
+func (self *builder) PushParagraph(text string) error {
+	text, err := self.inline(text)
+	if err != nil {
+		return err
 	}
-	return &builder{
-		document: fb2,
+	prefix := self.itemPrefix
+	self.itemPrefix = ""
+
+	for _, line := range strings.Split(text, emptyLine) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := self.write(fmt.Sprintf("<p>%s%s</p>", prefix, line)); err != nil {
+			return err
+		}
+		prefix = ""
+	}
+	return nil
+}
+func (self *builder) PushHeading(level int, text string) error {
+	if text, err := self.inline(text); err != nil {
+		return err
+	} else {
+		return self.write(fmt.Sprintf("<subtitle>%s</subtitle>", strings.ReplaceAll(text, emptyLine, " ")))
 	}
 }
-func (self *builder) SetTitle(name string) {
-	self.document.Description.TitleInfo.BookTitle = name
+func (self *builder) PushCode(code string) error {
+	for _, line := range strings.Split(strings.TrimRight(code, "\n"), "\n") {
+		block := emptyLine
+
+		if strings.TrimSpace(line) != "" {
+			line = leadingSpaces.ReplaceAllStringFunc(line, func(spaces string) string {
+				return strings.Repeat("\u00a0", len(spaces))
+			})
+			block = fmt.Sprintf("<p><code>%s</code></p>", html.EscapeString(line))
+		}
+		if err := self.write(block); err != nil {
+			return err
+		}
+	}
+	return nil
 }
-func (self *builder) SetAuthor(author string) {
+func (self *builder) PushHorizontalRule() error {
+	return self.write(fmt.Sprintf("<subtitle>%s</subtitle>", horizontalRule))
 }
-func (self *builder) PushChapter(chapterTitle string) error {
-	section := section{
-		Title: title{Paragraph: chapterTitle},
+func (self *builder) BeginList(ordered bool) error {
+	self.lists = append(self.lists, list{Ordered: ordered})
+	return nil
+}
+func (self *builder) BeginListItem() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	current := &self.lists[len(self.lists)-1]
+	current.Index++
+
+	indent := strings.Repeat("\u00a0", 4*(len(self.lists)-1))
+	if current.Ordered {
+		self.itemPrefix = fmt.Sprintf("%s%d. ", indent, current.Index)
+	} else {
+		self.itemPrefix = indent + "• "
 	}
-	self.document.Body.Sections = append(self.document.Body.Sections, section)
-	self.currentSection = &self.document.Body.Sections[len(self.document.Body.Sections)-1]
 	return nil
 }
-func (self *builder) PushParagraph(text string) error {
-	if self.currentSection == nil {
-		return errors.New("Chapter is not created")
+func (self *builder) EndListItem() error {
+	self.itemPrefix = ""
+	return nil
+}
+func (self *builder) EndList() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
 	}
-	paragraph := paragraph{
-		Text: text,
+	self.lists = self.lists[:len(self.lists)-1]
+	return nil
+}
+func (self *builder) BeginBlockquote() error {
+	self.quotes++
+	return nil
+}
+func (self *builder) EndBlockquote() error {
+	if self.quotes == 0 {
+		return errors.New("Blockquote is not created")
+	}
+	if self.quotes--; self.quotes != 0 || self.quote.Len() == 0 {
+		return nil
+	}
+	content := self.quote.String()
+	self.quote.Reset()
+	return self.write(fmt.Sprintf("<cite>%s</cite>", content))
+}
+func (self *builder) BeginTable() error {
+	self.rows = []string{}
+	return nil
+}
+func (self *builder) BeginTableRow() error {
+	self.row.Reset()
+	return nil
+}
+func (self *builder) PushTableCell(header bool, text string) error {
+	text, err := self.inline(text)
+	if err != nil {
+		return err
+	}
+	text = strings.ReplaceAll(text, emptyLine, " ")
+
+	if header {
+		fmt.Fprintf(&self.row, "<th>%s</th>", text)
+	} else {
+		fmt.Fprintf(&self.row, "<td>%s</td>", text)
+	}
+	return nil
+}
+func (self *builder) EndTableRow() error {
+	if self.row.Len() != 0 {
+		self.rows = append(self.rows, fmt.Sprintf("<tr>%s</tr>", self.row.String()))
+	}
+	self.row.Reset()
+	return nil
+}
+func (self *builder) EndTable() error {
+	if len(self.rows) == 0 {
+		return nil
+	}
+	rows := self.rows
+	self.rows = nil
+	return self.write(fmt.Sprintf("<table>%s</table>", strings.Join(rows, "")))
+}
+func (self *builder) BeginPoem() error {
+	self.poem.Reset()
+	return nil
+}
+func (self *builder) BeginStanza() error {
+	self.verses = []string{}
+	return nil
+}
+func (self *builder) PushVerse(text string) error {
+	if text, err := self.inline(text); err != nil {
+		return err
+	} else {
+		self.verses = append(self.verses, fmt.Sprintf("<v>%s</v>", strings.ReplaceAll(text, emptyLine, " ")))
+		return nil
 	}
-	self.currentSection.Paragraphs = append(self.currentSection.Paragraphs, paragraph)
+}
+func (self *builder) EndStanza() error {
+	if len(self.verses) != 0 {
+		fmt.Fprintf(&self.poem, "<stanza>%s</stanza>", strings.Join(self.verses, ""))
+	}
+	self.verses = nil
 	return nil
 }
+func (self *builder) EndPoem() error {
+	if self.poem.Len() == 0 {
+		return nil
+	}
+	content := self.poem.String()
+	self.poem.Reset()
+	return self.write(fmt.Sprintf("<poem>%s</poem>", content))
+}
 func (self *builder) PushImage(imagePath string) error {
-	if self.currentSection == nil {
+	if !self.hasChapter {
 		return errors.New("Chapter is not created")
 	}
+	id, err := self.registerImage(imagePath)
+	if err != nil {
+		return err
+	}
+	image := fmt.Sprintf(`<image l:href="#%s"/>`, id)
+
+	switch {
+	case self.quotes != 0, self.blocks == 1 && self.slotImage:
+		return self.write("<p>" + image + "</p>")
+	case self.blocks == 0:
+		self.slotImage = true
+	}
+	return self.write(image)
+}
+func contentType(imagePath string) string {
+	if format, err := imageproc.SniffFile(imagePath); err != nil || format == imageproc.FormatUnknown {
+		return "image/jpeg"
+	} else {
+		return format.MediaType()
+	}
+}
+func (self *builder) finishBody() error {
+	if err := self.closeVolume(); err != nil {
+		return err
+	}
+	if self.sections == 0 {
+		if err := self.writeBody("<section>\n" + emptyLine + "\n</section>\n"); err != nil {
+			return err
+		}
+	}
+	if err := self.output.Flush(); err != nil {
+		return err
+	}
+	_, err := self.body.Seek(0, io.SeekStart)
+	return err
+}
+func (self *builder) Close() error {
+	if self.body == nil {
+		return nil
+	}
+	body := self.body
+	self.body = nil
+	body.Close()
+	return os.Remove(body.Name())
+}
+func writeBinary(writer *bufio.Writer, current binary) error {
+	source, err := os.Open(current.Path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
 
-	filename := filepath.Base(imagePath)
-	imageID := strings.TrimSuffix(filename, path.Ext(filename))
+	fmt.Fprintf(writer, "  <binary id=\"%s\" content-type=\"%s\">", current.ID, contentType(current.Path))
 
-	data, err := os.ReadFile(imagePath)
+	encoder := base64.NewEncoder(base64.StdEncoding, writer)
+	if _, err := io.Copy(encoder, source); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	_, err = writer.WriteString("</binary>\n")
+	return err
+}
+func (self *builder) cover() (*binary, error) {
+	if self.metadata.CoverPath == "" {
+		return nil, nil
+	}
+	if err := checkImage(self.metadata.CoverPath); errors.Is(err, base.ErrUnsupportedImage) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &binary{ID: coverId, Path: self.metadata.CoverPath}, nil
+}
+func (self *builder) writeDocument(writer *bufio.Writer, cover *binary) error {
+	description, err := xml.MarshalIndent(newDescription(self.metadata, cover != nil), "  ", "  ")
 	if err != nil {
 		return err
 	}
-	base64Data := base64.StdEncoding.EncodeToString(data)
+	writer.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	writer.WriteString(`<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0" xmlns:l="http://www.w3.org/1999/xlink">` + "\n  ")
+	writer.Write(description)
+	writer.WriteString("\n  <body>\n")
 
-	var contentType string
-	if strings.HasSuffix(strings.ToLower(imagePath), ".png") {
-		contentType = "image/png"
-	} else {
-		contentType = "image/jpeg"
+	if _, err := io.Copy(writer, self.body); err != nil {
+		return err
 	}
-	self.PushParagraph(fmt.Sprintf("<image l:href=\"#%s\"/>", imageID))
+	writer.WriteString("  </body>\n")
 
-	binary := binary{
-		ID:          imageID,
-		ContentType: contentType,
-		Data:        base64Data,
+	binaries := self.binaries
+	if cover != nil {
+		binaries = append(binaries, *cover)
 	}
-	self.document.Binary = append(self.document.Binary, binary)
-	return nil
+	for _, current := range binaries {
+		if err := writeBinary(writer, current); err != nil {
+			return err
+		}
+	}
+	writer.WriteString("</FictionBook>\n")
+	return writer.Flush()
 }
-func (c *builder) Build(filename string) error {
+func (self *builder) Build(filename string) error {
+	defer self.Close()
+
+	cover, err := self.cover()
+	if err != nil {
+		return err
+	}
+	if err := self.finishBody(); err != nil {
+		return err
+	}
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	file.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
-
-	c.document.XMLNamespace = "http://www.gribuser.ru/xml/fictionbook/2.0"
-	c.document.XMLNamespaceLink = "http://www.w3.org/1999/xlink"
-
-	encoder := xml.NewEncoder(file)
-	encoder.Indent("", "  ")
-	if err := encoder.Encode(c.document); err != nil {
+	if err := self.writeDocument(bufio.NewWriter(file), cover); err != nil {
 		return err
 	}
-	return nil
+	return file.Close()
 }
@@ -0,0 +1,177 @@
+package fb2
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/nodehandler"
+	"ranobedl/format/internal/testutil"
+	"ranobedl/schema"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "1.png")
+
+	if err := os.WriteFile(imagePath, []byte("\x89PNG\r\n\x1a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := testutil.Text("ссылка")
+	link.Marks = []schema.Mark{{Type: schema.MarkTypeLink, Attrs: map[string]any{"href": "https://example.com/?a=1&b=2"}}}
+
+	doc := testutil.Block(schema.NodeTypeDoc,
+		testutil.Image(imagePath),
+		testutil.Image(imagePath),
+		testutil.Paragraph(
+			testutil.Text("Первый "), testutil.Text("жирный", schema.MarkTypeBold), testutil.Text(" "),
+			testutil.Text("зачёркнутый", schema.MarkTypeStrike), testutil.Text(" "), testutil.Text("подчёркнутый", schema.MarkTypeUnderline),
+			testutil.Text(" & "), link, schema.Node{Type: schema.NodeTypeHardBreak}, testutil.Text("вторая строка "), testutil.Image(imagePath),
+		),
+		schema.Node{Type: schema.NodeTypeHeading, Attrs: map[string]any{"level": 1}, Content: []schema.Node{testutil.Text("Заголовок")}},
+		testutil.Block(schema.NodeTypeBulletList,
+			testutil.Block(schema.NodeTypeListItem, testutil.Paragraph(testutil.Text("пункт"))),
+			testutil.Block(schema.NodeTypeListItem, testutil.Block(schema.NodeTypeOrderedList,
+				testutil.Block(schema.NodeTypeListItem, testutil.Paragraph(testutil.Text("вложенный"))),
+			)),
+		),
+		testutil.Block(schema.NodeTypeBlockquote,
+			testutil.Paragraph(testutil.Text("цитата")),
+			testutil.Block(schema.NodeTypeBlockquote, testutil.Paragraph(testutil.Text("вложенная"))),
+			testutil.Image(imagePath),
+		),
+		testutil.Block(schema.NodeTypeBlockquote),
+		testutil.Block(schema.NodeTypeCodeBlock, testutil.Text("if a < b {\n  return\n\n}")),
+		testutil.Block(schema.NodeTypeTable,
+			testutil.Block(schema.NodeTypeTableRow,
+				testutil.Block(schema.NodeTypeTableHeader, testutil.Paragraph(testutil.Text("Имя"))),
+				testutil.Block(schema.NodeTypeTableHeader, testutil.Paragraph(testutil.Text("Значение"))),
+			),
+			testutil.Block(schema.NodeTypeTableRow,
+				testutil.Block(schema.NodeTypeTableCell, testutil.Paragraph(testutil.Text("a")), testutil.Paragraph(testutil.Text("b"))),
+				testutil.Block(schema.NodeTypeTableCell, testutil.Paragraph(testutil.Text("1"))),
+			),
+		),
+		testutil.Block(schema.NodeTypePoem,
+			testutil.Block(schema.NodeTypeStanza, testutil.Paragraph(testutil.Text("строка один"), schema.Node{Type: schema.NodeTypeHardBreak}, testutil.Text("строка два"))),
+			testutil.Block(schema.NodeTypeStanza, testutil.Paragraph(testutil.Text("строка три"))),
+		),
+		testutil.Block(schema.NodeTypeHorizontalRule),
+	)
+	book := NewBuilder()
+	book.SetMetadata(base.Metadata{
+		Title:      "Книга",
+		Authors:    []string{"Автор"},
+		Genres:     []string{"Фэнтези", "Неизвестный"},
+		Annotation: "Описание",
+		Year:       "2020",
+		CoverPath:  imagePath,
+	})
+	if err := book.PushParagraph("text"); err == nil {
+		t.Errorf("PushParagraph() without chapter expected error")
+	}
+	if err := book.PushVolume("Том 1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, chapter := range []string{"Глава 1", "Глава 2"} {
+		if err := book.PushChapter(chapter); err != nil {
+			t.Fatal(err)
+		}
+		if err := nodehandler.PushBlock(book, RenderInline, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := book.PushChapter("Пустая глава"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.PushVolume("Пустой том"); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "book.fb2")
+	if err := book.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	if book.body != nil {
+		t.Errorf("Build() left the temporary body file open")
+	}
+	if err := NewBuilder().PushImage(filepath.Join(dir, "missing.png")); err == nil {
+		t.Errorf("PushImage() without chapter expected error")
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+
+	expected := []string{
+		"</title>\n<image l:href=\"#image0001\"/>\n<p><image l:href=\"#image0001\"/></p>\n<p>Первый",
+		`<strong>жирный</strong> <strikethrough>зачёркнутый</strikethrough> <style name="underline">подчёркнутый</style> &amp; `,
+		"<a l:href=\"https://example.com/?a=1&amp;b=2\">ссылка</a></p>\n<p>вторая строка <image l:href=\"#image0001\"/></p>",
+		`<subtitle>Заголовок</subtitle>`,
+		"<p>• пункт</p>\n<p>\u00a0\u00a0\u00a0\u00a01. вложенный</p>",
+		"<cite><p>цитата</p><p>вложенная</p><p><image l:href=\"#image0001\"/></p></cite>\n<p><code>",
+		"<p><code>if a &lt; b {</code></p>\n<p><code>\u00a0\u00a0return</code></p>\n<empty-line/>\n<p><code>}</code></p>",
+		`<table><tr><th>Имя</th><th>Значение</th></tr><tr><td>a b</td><td>1</td></tr></table>`,
+		`<poem><stanza><v>строка один</v><v>строка два</v></stanza><stanza><v>строка три</v></stanza></poem>`,
+		`<subtitle>* * *</subtitle>`,
+	}
+	for _, fragment := range expected {
+		if !strings.Contains(content, fragment) {
+			t.Errorf("book does not contain %q", fragment)
+		}
+	}
+	if count := strings.Count(content, "</title>\n<empty-line/>"); count != 2 {
+		t.Errorf("book has %d empty sections; want 2", count)
+	}
+	if count := strings.Count(content, "<binary "); count != 2 {
+		t.Errorf("book has %d binaries; want 2", count)
+	}
+	if _, err := exec.LookPath("xmllint"); err != nil {
+		t.Skip("xmllint is not installed")
+	}
+	validate := exec.Command("xmllint", "--noout", "--schema", filepath.Join("testdata", "FictionBook.xsd"), output)
+	if result, err := validate.CombinedOutput(); err != nil {
+		t.Errorf("xmllint: %v\n%s", err, result)
+	}
+}
+func TestUnsupportedImage(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "1.avif")
+
+	if err := os.WriteFile(imagePath, []byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00avifmif1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc := testutil.Block(schema.NodeTypeDoc,
+		testutil.Image(imagePath),
+		testutil.Paragraph(testutil.Text("текст "), testutil.Image(imagePath)),
+	)
+	book := NewBuilder()
+	book.SetMetadata(base.Metadata{Title: "Книга", CoverPath: imagePath})
+
+	if err := book.PushChapter("Глава"); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodehandler.PushBlock(book, RenderInline, doc); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "book.fb2")
+	if err := book.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+
+	if strings.Contains(content, "image/avif") || strings.Contains(content, "<coverpage>") {
+		t.Errorf("book embeds an AVIF image")
+	}
+	placeholder := "<p>" + base.UnsupportedImageText + "</p>\n<p>текст " + base.UnsupportedImageText + "</p>"
+
+	if !strings.Contains(content, placeholder) {
+		t.Errorf("book does not contain %q", placeholder)
+	}
+}
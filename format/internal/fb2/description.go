@@ -0,0 +1,185 @@
+package fb2
+
+import (
+	"html"
+	base "ranobedl/format/internal/builder"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type description struct {
+	TitleInfo    titleInfo    `xml:"title-info"`
+	SrcTitleInfo *titleInfo   `xml:"src-title-info,omitempty"`
+	DocumentInfo documentInfo `xml:"document-info"`
+	PublishInfo  *publishInfo `xml:"publish-info,omitempty"`
+	CustomInfo   []customInfo `xml:"custom-info,omitempty"`
+}
+type titleInfo struct {
+	Genre      []string    `xml:"genre"`
+	Author     []author    `xml:"author"`
+	BookTitle  string      `xml:"book-title"`
+	Annotation *annotation `xml:"annotation,omitempty"`
+	Keywords   string      `xml:"keywords,omitempty"`
+	Date       string      `xml:"date,omitempty"`
+	Coverpage  *coverpage  `xml:"coverpage,omitempty"`
+	Lang       string      `xml:"lang"`
+	SrcLang    string      `xml:"src-lang,omitempty"`
+	Sequence   []sequence  `xml:"sequence,omitempty"`
+}
+type sequence struct {
+	Name   string `xml:"name,attr"`
+	Number int    `xml:"number,attr,omitempty"`
+}
+type author struct {
+	FirstName string `xml:"first-name,omitempty"`
+	LastName  string `xml:"last-name,omitempty"`
+	Nickname  string `xml:"nickname,omitempty"`
+}
+type annotation struct {
+	Paragraphs []paragraph `xml:"p"`
+}
+type coverpage struct {
+	Image imageRef `xml:"image"`
+}
+type imageRef struct {
+	Href string `xml:"l:href,attr"`
+}
+type documentInfo struct {
+	Author      []author `xml:"author"`
+	ProgramUsed string   `xml:"program-used"`
+	Date        string   `xml:"date"`
+	SrcUrl      string   `xml:"src-url,omitempty"`
+	Id          string   `xml:"id"`
+	Version     string   `xml:"version"`
+}
+type publishInfo struct {
+	BookName  string     `xml:"book-name,omitempty"`
+	Publisher string     `xml:"publisher,omitempty"`
+	Year      string     `xml:"year,omitempty"`
+	Sequence  []sequence `xml:"sequence,omitempty"`
+}
+type customInfo struct {
+	InfoType string `xml:"info-type,attr"`
+	Text     string `xml:",chardata"`
+}
+
+const defaultLanguage = "ru"
+const programName = "ranobedl"
+const coverId = "cover"
+
+func convertAuthor(name string) author {
+	if parts := strings.Fields(name); len(parts) == 2 {
+		return author{FirstName: parts[0], LastName: parts[1]}
+	}
+	return author{Nickname: name}
+}
+func convertAuthors(names []string) []author {
+	output := []author{}
+
+	for _, name := range names {
+		if strings.TrimSpace(name) != "" {
+			output = append(output, convertAuthor(strings.TrimSpace(name)))
+		}
+	}
+	if len(output) == 0 {
+		output = append(output, author{Nickname: "Unknown"})
+	}
+	return output
+}
+func convertAnnotation(text string) *annotation {
+	output := annotation{}
+
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			output.Paragraphs = append(output.Paragraphs, paragraph{Text: html.EscapeString(line)})
+		}
+	}
+	if len(output.Paragraphs) == 0 {
+		return nil
+	}
+	return &output
+}
+func language(metadata base.Metadata) string {
+	if metadata.Language != "" {
+		return metadata.Language
+	}
+	return defaultLanguage
+}
+func newSequence(metadata base.Metadata) []sequence {
+	if metadata.Series == "" {
+		return nil
+	}
+	return []sequence{{Name: metadata.Series, Number: metadata.SeriesNumber}}
+}
+func newTitleInfo(metadata base.Metadata, hasCover bool) titleInfo {
+	output := titleInfo{
+		Genre:      convertGenres(metadata.Genres),
+		Author:     convertAuthors(metadata.Authors),
+		BookTitle:  metadata.Title,
+		Annotation: convertAnnotation(metadata.Annotation),
+		Keywords:   strings.Join(metadata.Tags, ", "),
+		Date:       metadata.Year,
+		Lang:       language(metadata),
+		SrcLang:    metadata.SourceLanguage,
+		Sequence:   newSequence(metadata),
+	}
+	if hasCover {
+		output.Coverpage = &coverpage{Image: imageRef{Href: "#" + coverId}}
+	}
+	return output
+}
+func newSrcTitleInfo(metadata base.Metadata) *titleInfo {
+	if metadata.OriginalTitle == "" || metadata.SourceLanguage == "" {
+		return nil
+	}
+	return &titleInfo{
+		Genre:     convertGenres(metadata.Genres),
+		Author:    convertAuthors(metadata.Authors),
+		BookTitle: metadata.OriginalTitle,
+		Date:      metadata.Year,
+		Lang:      metadata.SourceLanguage,
+	}
+}
+func newPublishInfo(metadata base.Metadata) *publishInfo {
+	if metadata.Publisher == "" && metadata.Year == "" && metadata.Series == "" {
+		return nil
+	}
+	return &publishInfo{
+		BookName:  metadata.Title,
+		Publisher: metadata.Publisher,
+		Year:      metadata.Year,
+		Sequence:  newSequence(metadata),
+	}
+}
+func newCustomInfo(metadata base.Metadata) []customInfo {
+	output := []customInfo{}
+
+	if len(metadata.AltTitles) != 0 {
+		output = append(output, customInfo{"alt-titles", strings.Join(metadata.AltTitles, "; ")})
+	}
+	if metadata.Status != "" {
+		output = append(output, customInfo{"status", metadata.Status})
+	}
+	if metadata.AgeRating != "" {
+		output = append(output, customInfo{"age-rating", metadata.AgeRating})
+	}
+	return output
+}
+func newDescription(metadata base.Metadata, hasCover bool) description {
+	return description{
+		TitleInfo:    newTitleInfo(metadata, hasCover),
+		SrcTitleInfo: newSrcTitleInfo(metadata),
+		DocumentInfo: documentInfo{
+			Author:      []author{{Nickname: programName}},
+			ProgramUsed: programName,
+			Date:        time.Now().Format("2006-01-02"),
+			SrcUrl:      metadata.Source,
+			Id:          uuid.NewString(),
+			Version:     "1.0",
+		},
+		PublishInfo: newPublishInfo(metadata),
+		CustomInfo:  newCustomInfo(metadata),
+	}
+}
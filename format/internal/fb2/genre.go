@@ -0,0 +1,48 @@
+package fb2
+
+import "strings"
+
+const defaultGenre = "prose_contemporary"
+
+var genreCodes = map[string]string{
+	"фэнтези":            "sf_fantasy",
+	"фантастика":         "sf",
+	"научная фантастика": "sf",
+	"боевик":             "sf_action",
+	"боевые искусства":   "sf_action",
+	"уся":                "sf_action",
+	"сянься":             "sf_fantasy",
+	"сюаньхуань":         "sf_fantasy",
+	"исекай":             "sf_fantasy",
+	"романтика":          "love_contemporary",
+	"сёдзё":              "love_contemporary",
+	"детектив":           "detective",
+	"триллер":            "thriller",
+	"ужасы":              "sf_horror",
+	"мистика":            "sf_horror",
+	"комедия":            "humor",
+	"приключения":        "adventure",
+	"история":            "sf_history",
+	"исторический":       "sf_history",
+	"киберпанк":          "sf_cyberpunk",
+	"драма":              "prose_contemporary",
+	"повседневность":     "prose_contemporary",
+	"психология":         "prose_contemporary",
+	"трагедия":           "prose_contemporary",
+}
+
+func convertGenres(genres []string) []string {
+	output := []string{}
+	seen := map[string]bool{}
+
+	for _, genre := range genres {
+		if code, found := genreCodes[strings.ToLower(strings.TrimSpace(genre))]; found && !seen[code] {
+			seen[code] = true
+			output = append(output, code)
+		}
+	}
+	if len(output) == 0 {
+		output = append(output, defaultGenre)
+	}
+	return output
+}
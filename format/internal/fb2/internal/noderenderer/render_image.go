@@ -2,6 +2,7 @@ package noderenderer
 
 import (
 	"fmt"
+	"html"
 	"ranobedl/schema"
 )
 
@@ -9,6 +10,6 @@ func renderImage(node schema.Node) (string, error) {
 	if src, err := node.ImageSrc(); err != nil {
 		return "", err
 	} else {
-		return fmt.Sprintf(`<image l:href="%s"/>`, src), nil
+		return fmt.Sprintf(`<image l:href="%s"/>`, html.EscapeString(src)), nil
 	}
 }
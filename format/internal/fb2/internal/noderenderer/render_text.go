@@ -21,10 +21,10 @@ func (tr *textRenderer) handleItalic(text string) (string, error) {
 	return fmt.Sprintf("<emphasis>%s</emphasis>", text), nil
 }
 func (tr *textRenderer) handleUnderline(text string) (string, error) {
-	return fmt.Sprintf("<span class=\"underline\">%s</span>", text), nil
+	return fmt.Sprintf("<style name=\"underline\">%s</style>", text), nil
 }
 func (tr *textRenderer) handleStrike(text string) (string, error) {
-	return fmt.Sprintf("<span class=\"strike\">%s</span>", text), nil
+	return fmt.Sprintf("<strikethrough>%s</strikethrough>", text), nil
 }
 func (tr *textRenderer) handleCode(text string) (string, error) {
 	return fmt.Sprintf("<code>%s</code>", text), nil
@@ -33,12 +33,10 @@ func (tr *textRenderer) handleLink(text string, mark schema.Mark) (string, error
 	if href, err := mark.LinkHref(); err != nil {
 		return "", err
 	} else {
-		return fmt.Sprintf("<a href=\"%s\">%s</a>", text, href), nil
+		return fmt.Sprintf("<a l:href=\"%s\">%s</a>", html.EscapeString(href), text), nil
 	}
 }
 func (tr *textRenderer) renderMark(text string, mark schema.Mark) (string, error) {
-	text = html.EscapeString(text)
-
 	switch mark.Type {
 	case schema.MarkTypeBold:
 		return tr.handleBold(text)
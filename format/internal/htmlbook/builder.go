@@ -0,0 +1,270 @@
+package htmlbook
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/xhtml"
+	"strings"
+)
+
+const maxHeadingLevel = 6
+
+type block struct {
+	Html      string
+	ImagePath string
+}
+type chapter struct {
+	Id     string
+	Title  string
+	Level  int
+	Blocks []block
+}
+type builder struct {
+	metadata       base.Metadata
+	externalImages bool
+	chapters       []chapter
+	currentChapter *chapter
+	inVolume       bool
+	lists          []string
+	images         map[string]string
+}
+
+func NewBuilder(externalImages bool) *builder {
+	return &builder{
+		externalImages: externalImages,
+		chapters:       []chapter{},
+		images:         map[string]string{},
+	}
+}
+func (self *builder) SetMetadata(metadata base.Metadata) {
+	self.metadata = metadata
+}
+func (self *builder) pushSection(title string, level int) {
+	self.chapters = append(self.chapters, chapter{
+		Id:    fmt.Sprintf("chapter%04d", len(self.chapters)+1),
+		Title: title,
+		Level: level,
+	})
+}
+func (self *builder) PushVolume(volumeTitle string) error {
+	self.pushSection(volumeTitle, 0)
+	self.currentChapter = nil
+	self.inVolume = true
+	return nil
+}
+func (self *builder) PushChapter(chapterTitle string) error {
+	if self.inVolume {
+		self.pushSection(chapterTitle, 1)
+	} else {
+		self.pushSection(chapterTitle, 0)
+	}
+	self.currentChapter = &self.chapters[len(self.chapters)-1]
+	return nil
+}
+func (self *builder) pushBlock(block block) error {
+	if self.currentChapter == nil {
+		return errors.New("Chapter is not created")
+	}
+	self.currentChapter.Blocks = append(self.currentChapter.Blocks, block)
+	return nil
+}
+func (self *builder) pushHtml(text string) error {
+	return self.pushBlock(block{Html: text})
+}
+func (self *builder) PushParagraph(text string) error {
+	return self.pushHtml(fmt.Sprintf("<p>%s</p>", text))
+}
+func (self *builder) PushHeading(level int, text string) error {
+	level = min(level, maxHeadingLevel)
+	return self.pushHtml(fmt.Sprintf("<h%d>%s</h%d>", level, text, level))
+}
+func (self *builder) PushCode(code string) error {
+	return self.pushHtml(fmt.Sprintf("<pre><code>%s</code></pre>", html.EscapeString(code)))
+}
+func (self *builder) PushHorizontalRule() error {
+	return self.pushHtml("<hr/>")
+}
+func (self *builder) BeginList(ordered bool) error {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	self.lists = append(self.lists, tag)
+	return self.pushHtml("<" + tag + ">")
+}
+func (self *builder) BeginListItem() error {
+	return self.pushHtml("<li>")
+}
+func (self *builder) EndListItem() error {
+	return self.pushHtml("</li>")
+}
+func (self *builder) EndList() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	tag := self.lists[len(self.lists)-1]
+	self.lists = self.lists[:len(self.lists)-1]
+
+	return self.pushHtml("</" + tag + ">")
+}
+func (self *builder) BeginBlockquote() error {
+	return self.pushHtml("<blockquote>")
+}
+func (self *builder) EndBlockquote() error {
+	return self.pushHtml("</blockquote>")
+}
+func (self *builder) BeginTable() error {
+	return self.pushHtml("<table>")
+}
+func (self *builder) BeginTableRow() error {
+	return self.pushHtml("<tr>")
+}
+func (self *builder) PushTableCell(header bool, text string) error {
+	if header {
+		return self.pushHtml(fmt.Sprintf("<th>%s</th>", text))
+	}
+	return self.pushHtml(fmt.Sprintf("<td>%s</td>", text))
+}
+func (self *builder) EndTableRow() error {
+	return self.pushHtml("</tr>")
+}
+func (self *builder) EndTable() error {
+	return self.pushHtml("</table>")
+}
+func (self *builder) BeginPoem() error {
+	return self.pushHtml(`<div class="poem">`)
+}
+func (self *builder) BeginStanza() error {
+	return self.pushHtml(`<div class="stanza">`)
+}
+func (self *builder) PushVerse(text string) error {
+	return self.pushHtml(fmt.Sprintf("<p>%s</p>", text))
+}
+func (self *builder) EndStanza() error {
+	return self.pushHtml("</div>")
+}
+func (self *builder) EndPoem() error {
+	return self.pushHtml("</div>")
+}
+func (self *builder) PushImage(imagePath string) error {
+	if _, err := os.Stat(imagePath); err != nil {
+		return err
+	}
+	return self.pushBlock(block{ImagePath: imagePath})
+}
+func mediaType(imagePath string) string {
+	switch strings.ToLower(filepath.Ext(imagePath)) {
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".svg":
+		return "image/svg+xml"
+	default:
+		return "image/jpeg"
+	}
+}
+func filesDir(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + "_files"
+}
+func (self *builder) copyImage(filename string, imagePath string) (string, error) {
+	if src, found := self.images[imagePath]; found {
+		return src, nil
+	}
+	directory := filesDir(filename)
+	name := fmt.Sprintf("image%04d%s", len(self.images)+1, strings.ToLower(filepath.Ext(imagePath)))
+
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return "", err
+	}
+	source, err := os.Open(imagePath)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	destination, err := os.Create(filepath.Join(directory, name))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(destination, source); err != nil {
+		destination.Close()
+		return "", err
+	}
+	if err := destination.Close(); err != nil {
+		return "", err
+	}
+	src := filepath.Base(directory) + "/" + name
+	self.images[imagePath] = src
+
+	return src, nil
+}
+func (self *builder) writeDataUri(writer io.Writer, imagePath string) error {
+	source, err := os.Open(imagePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	fmt.Fprintf(writer, "data:%s;base64,", mediaType(imagePath))
+
+	encoder := base64.NewEncoder(base64.StdEncoding, writer)
+	if _, err := io.Copy(encoder, source); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+func (self *builder) imageSrc(filename string, imagePath string) (string, error) {
+	if self.externalImages {
+		return self.copyImage(filename, imagePath)
+	}
+	var output strings.Builder
+
+	if err := self.writeDataUri(&output, imagePath); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+func (self *builder) inline(filename string, text string) (string, error) {
+	return xhtml.ReplaceImages(text, func(src string) (string, error) {
+		return self.imageSrc(filename, src)
+	})
+}
+func (self *builder) writeImage(writer *bufio.Writer, filename string, imagePath string, class string) error {
+	fmt.Fprintf(writer, `<img class="%s" src="`, class)
+
+	if self.externalImages {
+		if src, err := self.copyImage(filename, imagePath); err != nil {
+			return err
+		} else {
+			writer.WriteString(html.EscapeString(src))
+		}
+	} else if err := self.writeDataUri(writer, imagePath); err != nil {
+		return err
+	}
+	_, err := writer.WriteString(`" alt=""/>`)
+	return err
+}
+func (self *builder) Build(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+
+	if err := self.writeDocument(writer, filename); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
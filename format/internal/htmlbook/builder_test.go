@@ -0,0 +1,140 @@
+package htmlbook
+
+import (
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/nodehandler"
+	"ranobedl/format/internal/testutil"
+	"ranobedl/schema"
+	"strings"
+	"testing"
+)
+
+func chapterBody(t *testing.T, document string, title string) string {
+	start := strings.Index(document, "<h2>"+title+"</h2>\n")
+	if start == -1 {
+		t.Fatalf("chapter %q not found", title)
+	}
+	start += len("<h2>" + title + "</h2>\n")
+	end := strings.Index(document[start:], "</section>")
+
+	return document[start : start+end]
+}
+func TestRoundTrip(t *testing.T) {
+	link := testutil.Text("ссылка", schema.MarkTypeItalic)
+	link.Marks = append(link.Marks, schema.Mark{Type: schema.MarkTypeLink, Attrs: map[string]any{"href": "https://example.com/?a=1&b=2"}})
+
+	doc := schema.Node{
+		Type: schema.NodeTypeDoc,
+		Content: []schema.Node{
+			{Type: schema.NodeTypeHeading, Attrs: map[string]any{"level": 2}, Content: []schema.Node{testutil.Text("Пролог")}},
+			testutil.Paragraph(
+				testutil.Text("Обычный <текст> & "),
+				testutil.Text("жирный", schema.MarkTypeBold, schema.MarkTypeItalic),
+				schema.Node{Type: schema.NodeTypeHardBreak},
+				testutil.Text("зачёркнутый", schema.MarkTypeStrike),
+				testutil.Text("подчёркнутый", schema.MarkTypeUnderline),
+				testutil.Text("код", schema.MarkTypeCode),
+				link,
+			),
+			{Type: schema.NodeTypeBulletList, Content: []schema.Node{
+				{Type: schema.NodeTypeListItem, Content: []schema.Node{testutil.Paragraph(testutil.Text("первый"))}},
+				{Type: schema.NodeTypeListItem, Content: []schema.Node{
+					testutil.Paragraph(testutil.Text("второй")),
+					{Type: schema.NodeTypeOrderedList, Content: []schema.Node{
+						{Type: schema.NodeTypeListItem, Content: []schema.Node{testutil.Paragraph(testutil.Text("вложенный"))}},
+					}},
+				}},
+			}},
+			{Type: schema.NodeTypeBlockquote, Content: []schema.Node{testutil.Paragraph(testutil.Text("цитата")), testutil.Paragraph(testutil.Text("вторая"))}},
+			{Type: schema.NodeTypeCodeBlock, Content: []schema.Node{testutil.Text("if a < b {\n\treturn\n}")}},
+			{Type: schema.NodeTypeHorizontalRule},
+		},
+	}
+	book := NewBuilder(false)
+	book.SetMetadata(base.Metadata{Title: "Книга"})
+
+	if err := book.PushChapter("Глава"); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodehandler.PushBlock(book, RenderInline, doc); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "book.html")
+
+	if err := book.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := schema.FromHtmlString(chapterBody(t, string(data), "Глава"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := doc.ToString()
+	result, _ := parsed.ToString()
+
+	if result != expected {
+		t.Errorf("round trip =\n%s\nwant\n%s", result, expected)
+	}
+}
+func TestImages(t *testing.T) {
+	directory := t.TempDir()
+	imagePath := filepath.Join(directory, "image.png")
+
+	if err := os.WriteFile(imagePath, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		external bool
+		src      string
+	}{
+		{"inline", false, `src="data:image/png;base64,cG5n"`},
+		{"external", true, `src="book_files/image0001.png"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := NewBuilder(tt.external)
+			book.SetMetadata(base.Metadata{Title: "Книга", CoverPath: imagePath})
+
+			if err := book.PushVolume("Том 1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := book.PushChapter("Глава"); err != nil {
+				t.Fatal(err)
+			}
+			if err := book.PushImage(imagePath); err != nil {
+				t.Fatal(err)
+			}
+			inline := testutil.Paragraph(testutil.Text("текст "), testutil.Image(imagePath))
+
+			if err := nodehandler.PushBlock(book, RenderInline, inline); err != nil {
+				t.Fatal(err)
+			}
+			output := filepath.Join(t.TempDir(), "book.html")
+
+			if err := book.Build(output); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			document := string(data)
+
+			if count := strings.Count(document, tt.src); count != 3 {
+				t.Errorf("image src %s found %d times; want 3", tt.src, count)
+			}
+			if !strings.Contains(document, `<a href="#chapter0002">Глава</a>`) {
+				t.Errorf("table of contents has no chapter link")
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(output), "book_files", "image0001.png")); (err == nil) != tt.external {
+				t.Errorf("side file exists = %v; want %v", err == nil, tt.external)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package htmlbook
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"strings"
+)
+
+const defaultLanguage = "ru"
+const styleCss = `body {
+  max-width: 42em;
+  margin: 0 auto;
+  padding: 1em;
+  font-family: Georgia, serif;
+  line-height: 1.6;
+  text-align: justify;
+}
+header.book, h1.book-title, section.volume > h2 {
+  text-align: center;
+}
+nav#toc ol {
+  list-style: none;
+  padding-left: 1em;
+}
+nav#toc a {
+  text-decoration: none;
+}
+section.chapter > h2, section.chapter > h3 {
+  text-align: center;
+  margin: 2em 0 1em;
+}
+p {
+  margin: 0;
+  text-indent: 1.5em;
+}
+li p, blockquote p, div.stanza p {
+  text-indent: 0;
+}
+blockquote {
+  margin: 1em 2em;
+  font-style: italic;
+}
+table {
+  border-collapse: collapse;
+  margin: 1em 0;
+}
+th, td {
+  border: 1px solid #999;
+  padding: 0.2em 0.5em;
+  text-align: left;
+}
+div.poem {
+  margin: 1em 10%;
+  text-align: left;
+}
+div.stanza {
+  margin: 1em 0;
+}
+pre {
+  white-space: pre-wrap;
+  text-align: left;
+}
+img.illustration, img.cover {
+  display: block;
+  max-width: 100%;
+  margin: 1em auto;
+}
+`
+
+func (self *builder) language() string {
+	if self.metadata.Language != "" {
+		return html.EscapeString(self.metadata.Language)
+	}
+	return defaultLanguage
+}
+func (self *builder) writeHead(writer *bufio.Writer) {
+	metadata := self.metadata
+
+	writer.WriteString("<!DOCTYPE html>\n")
+	fmt.Fprintf(writer, "<html lang=\"%s\">\n", self.language())
+	writer.WriteString("<head>\n")
+	writer.WriteString("  <meta charset=\"utf-8\"/>\n")
+	writer.WriteString("  <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\"/>\n")
+	fmt.Fprintf(writer, "  <title>%s</title>\n", html.EscapeString(metadata.Title))
+
+	if len(metadata.Authors) != 0 {
+		fmt.Fprintf(writer, "  <meta name=\"author\" content=\"%s\"/>\n", html.EscapeString(strings.Join(metadata.Authors, ", ")))
+	}
+	if metadata.Annotation != "" {
+		fmt.Fprintf(writer, "  <meta name=\"description\" content=\"%s\"/>\n", html.EscapeString(metadata.Annotation))
+	}
+	if keywords := append(append([]string{}, metadata.Genres...), metadata.Tags...); len(keywords) != 0 {
+		fmt.Fprintf(writer, "  <meta name=\"keywords\" content=\"%s\"/>\n", html.EscapeString(strings.Join(keywords, ", ")))
+	}
+	writer.WriteString("  <style>\n" + styleCss + "  </style>\n")
+	writer.WriteString("</head>\n")
+}
+func (self *builder) writeHeader(writer *bufio.Writer, filename string) error {
+	metadata := self.metadata
+
+	writer.WriteString("<header class=\"book\">\n")
+	fmt.Fprintf(writer, "  <h1 class=\"book-title\">%s</h1>\n", html.EscapeString(metadata.Title))
+
+	if len(metadata.Authors) != 0 {
+		fmt.Fprintf(writer, "  <p class=\"authors\">%s</p>\n", html.EscapeString(strings.Join(metadata.Authors, ", ")))
+	}
+	if metadata.Series != "" {
+		fmt.Fprintf(writer, "  <p class=\"series\">%s #%d</p>\n", html.EscapeString(metadata.Series), metadata.SeriesNumber)
+	}
+	if metadata.CoverPath != "" {
+		writer.WriteString("  ")
+		if err := self.writeImage(writer, filename, metadata.CoverPath, "cover"); err != nil {
+			return err
+		}
+		writer.WriteString("\n")
+	}
+	for _, line := range strings.Split(metadata.Annotation, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(writer, "  <p class=\"annotation\">%s</p>\n", html.EscapeString(line))
+		}
+	}
+	writer.WriteString("</header>\n")
+	return nil
+}
+
+type tocEntry struct {
+	chapter
+	Children []chapter
+}
+
+func (self *builder) tocEntries() []tocEntry {
+	entries := []tocEntry{}
+
+	for _, chapter := range self.chapters {
+		if chapter.Level == 0 || len(entries) == 0 {
+			entries = append(entries, tocEntry{chapter: chapter})
+		} else {
+			last := &entries[len(entries)-1]
+			last.Children = append(last.Children, chapter)
+		}
+	}
+	return entries
+}
+func (self *builder) writeToc(writer *bufio.Writer) {
+	writer.WriteString("<nav id=\"toc\">\n")
+	writer.WriteString("  <h2>Оглавление</h2>\n")
+	writer.WriteString("  <ol>\n")
+
+	for _, entry := range self.tocEntries() {
+		fmt.Fprintf(writer, "    <li><a href=\"#%s\">%s</a>", entry.Id, html.EscapeString(entry.Title))
+
+		if len(entry.Children) != 0 {
+			writer.WriteString("\n      <ol>\n")
+			for _, child := range entry.Children {
+				fmt.Fprintf(writer, "        <li><a href=\"#%s\">%s</a></li>\n", child.Id, html.EscapeString(child.Title))
+			}
+			writer.WriteString("      </ol>\n    ")
+		}
+		writer.WriteString("</li>\n")
+	}
+	writer.WriteString("  </ol>\n")
+	writer.WriteString("</nav>\n")
+}
+func (self *builder) writeChapter(writer *bufio.Writer, filename string, chapter chapter) error {
+	if self.inVolume && chapter.Level == 0 {
+		fmt.Fprintf(writer, "<section class=\"volume\" id=\"%s\">\n", chapter.Id)
+		fmt.Fprintf(writer, "<h2>%s</h2>\n", html.EscapeString(chapter.Title))
+		return nil
+	}
+	tag := "h2"
+	if chapter.Level == 1 {
+		tag = "h3"
+	}
+	fmt.Fprintf(writer, "<section class=\"chapter\" id=\"%s\">\n", chapter.Id)
+	fmt.Fprintf(writer, "<%s>%s</%s>\n", tag, html.EscapeString(chapter.Title), tag)
+
+	for _, block := range chapter.Blocks {
+		if block.ImagePath != "" {
+			if err := self.writeImage(writer, filename, block.ImagePath, "illustration"); err != nil {
+				return err
+			}
+			writer.WriteString("\n")
+		} else if text, err := self.inline(filename, block.Html); err != nil {
+			return err
+		} else {
+			writer.WriteString(text + "\n")
+		}
+	}
+	writer.WriteString("</section>\n")
+	return nil
+}
+func (self *builder) writeDocument(writer *bufio.Writer, filename string) error {
+	self.writeHead(writer)
+	writer.WriteString("<body>\n")
+
+	if err := self.writeHeader(writer, filename); err != nil {
+		return err
+	}
+	self.writeToc(writer)
+	writer.WriteString("<main>\n")
+
+	for index, chapter := range self.chapters {
+		if self.inVolume && chapter.Level == 0 && index != 0 {
+			writer.WriteString("</section>\n")
+		}
+		if err := self.writeChapter(writer, filename, chapter); err != nil {
+			return err
+		}
+	}
+	if self.inVolume && len(self.chapters) != 0 {
+		writer.WriteString("</section>\n")
+	}
+	writer.WriteString("</main>\n")
+	writer.WriteString("</body>\n")
+	_, err := writer.WriteString("</html>\n")
+	return err
+}
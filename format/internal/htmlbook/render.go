@@ -0,0 +1,10 @@
+package htmlbook
+
+import (
+	"ranobedl/format/internal/xhtml"
+	"ranobedl/schema"
+)
+
+func RenderInline(node []schema.Node) (string, error) {
+	return xhtml.RenderInline(node)
+}
@@ -0,0 +1,103 @@
+package imageproc
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatJpeg
+	FormatPng
+	FormatGif
+	FormatWebp
+	FormatAvif
+)
+
+const sniffLength = 32
+
+var avifBrands = [][]byte{[]byte("avif"), []byte("avis")}
+
+func isAvif(data []byte) bool {
+	if len(data) < 12 || !bytes.Equal(data[4:8], []byte("ftyp")) {
+		return false
+	}
+	for offset := 8; offset+4 <= len(data); offset += 4 {
+		for _, brand := range avifBrands {
+			if bytes.Equal(data[offset:offset+4], brand) {
+				return true
+			}
+		}
+	}
+	return false
+}
+func Sniff(data []byte) Format {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return FormatJpeg
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPng
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return FormatGif
+	case len(data) >= 12 && bytes.HasPrefix(data, []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return FormatWebp
+	case isAvif(data):
+		return FormatAvif
+	default:
+		return FormatUnknown
+	}
+}
+func SniffFile(path string) (Format, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return FormatUnknown, err
+	}
+	defer file.Close()
+
+	data := make([]byte, sniffLength)
+	if count, err := io.ReadFull(file, data); err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return FormatUnknown, err
+	} else {
+		return Sniff(data[:count]), nil
+	}
+}
+func (self Format) String() string {
+	switch self {
+	case FormatUnknown:
+		return "unknown"
+	case FormatJpeg:
+		return "jpeg"
+	case FormatPng:
+		return "png"
+	case FormatGif:
+		return "gif"
+	case FormatWebp:
+		return "webp"
+	case FormatAvif:
+		return "avif"
+	default:
+		panic("Unreachable")
+	}
+}
+func (self Format) Extension() string {
+	switch self {
+	case FormatUnknown:
+		return ""
+	case FormatJpeg:
+		return ".jpg"
+	default:
+		return "." + self.String()
+	}
+}
+func (self Format) MediaType() string {
+	if self == FormatUnknown {
+		return "application/octet-stream"
+	}
+	return "image/" + self.String()
+}
+func (self Format) Native() bool {
+	return self == FormatJpeg || self == FormatPng
+}
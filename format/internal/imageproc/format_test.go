@@ -0,0 +1,53 @@
+package imageproc
+
+import "testing"
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected Format
+	}{
+		{"jpeg", "\xff\xd8\xff\xe0\x00\x10JFIF", FormatJpeg},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", FormatPng},
+		{"gif87a", "GIF87a\x01\x00", FormatGif},
+		{"gif89a", "GIF89a\x01\x00", FormatGif},
+		{"webp", "RIFF\x24\x00\x00\x00WEBPVP8 ", FormatWebp},
+		{"avif", "\x00\x00\x00\x1cftypavif\x00\x00\x00\x00avifmif1", FormatAvif},
+		{"avif compatible brand", "\x00\x00\x00\x1cftypmif1\x00\x00\x00\x00mif1avif", FormatAvif},
+		{"heic", "\x00\x00\x00\x18ftypheic\x00\x00\x00\x00heicmif1", FormatUnknown},
+		{"riff without webp", "RIFF\x24\x00\x00\x00WAVEfmt ", FormatUnknown},
+		{"html", "<!DOCTYPE html>", FormatUnknown},
+		{"empty", "", FormatUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sniff([]byte(tt.data)); got != tt.expected {
+				t.Errorf("Sniff() = %v; want %v", got, tt.expected)
+			}
+		})
+	}
+}
+func TestFormatExtension(t *testing.T) {
+	tests := []struct {
+		format    Format
+		extension string
+		mediaType string
+	}{
+		{FormatJpeg, ".jpg", "image/jpeg"},
+		{FormatPng, ".png", "image/png"},
+		{FormatWebp, ".webp", "image/webp"},
+		{FormatAvif, ".avif", "image/avif"},
+		{FormatUnknown, "", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			if got := tt.format.Extension(); got != tt.extension {
+				t.Errorf("Extension() = %q; want %q", got, tt.extension)
+			}
+			if got := tt.format.MediaType(); got != tt.mediaType {
+				t.Errorf("MediaType() = %q; want %q", got, tt.mediaType)
+			}
+		})
+	}
+}
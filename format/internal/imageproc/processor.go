@@ -0,0 +1,218 @@
+package imageproc
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gen2brain/avif"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const DefaultQuality = 90
+
+type Options struct {
+	MaxSize   int
+	Quality   int
+	Grayscale bool
+}
+type Processor struct {
+	options   Options
+	directory string
+	outputs   map[string]string
+}
+
+func NewProcessor(options Options) (*Processor, error) {
+	if options.Quality < 0 || options.Quality > 100 {
+		return nil, errors.New("JPEG quality must be between 0 and 100")
+	}
+	if options.MaxSize < 0 {
+		return nil, errors.New("Maximum image size must not be negative")
+	}
+	return &Processor{
+		options: options,
+		outputs: map[string]string{},
+	}, nil
+}
+func (self *Processor) outputPath(extension string) (string, error) {
+	if self.directory == "" {
+		if directory, err := os.MkdirTemp("", "ranobedl-images-*"); err != nil {
+			return "", err
+		} else {
+			self.directory = directory
+		}
+	}
+	return filepath.Join(self.directory, fmt.Sprintf("image%04d%s", len(self.outputs)+1, extension)), nil
+}
+func (self *Processor) resized(width int, height int) bool {
+	return self.options.MaxSize > 0 && max(width, height) > self.options.MaxSize
+}
+func (self *Processor) changes(format Format, config image.Config) bool {
+	return !format.Native() ||
+		self.resized(config.Width, config.Height) ||
+		self.options.Grayscale && config.ColorModel != color.GrayModel ||
+		format == FormatJpeg && self.options.Quality > 0
+}
+func (self *Processor) copy(path string, format Format) (string, error) {
+	output, err := self.outputPath(format.Extension())
+	if err != nil {
+		return "", err
+	}
+	source, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	destination, err := os.Create(output)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(destination, source); err != nil {
+		destination.Close()
+		return "", err
+	}
+	return output, destination.Close()
+}
+func opaque(decoded image.Image) bool {
+	if current, found := decoded.(interface{ Opaque() bool }); found {
+		return current.Opaque()
+	}
+	return false
+}
+func (self *Processor) transform(decoded image.Image) image.Image {
+	bounds := decoded.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if self.resized(width, height) {
+		scale := float64(self.options.MaxSize) / float64(max(width, height))
+		width = max(int(float64(width)*scale+0.5), 1)
+		height = max(int(float64(height)*scale+0.5), 1)
+	}
+	target := image.Rect(0, 0, width, height)
+
+	if self.options.Grayscale {
+		gray := image.NewGray(target)
+		draw.Draw(gray, target, image.White, image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(gray, target, decoded, bounds, draw.Over, nil)
+		return gray
+	}
+	if target.Size() == bounds.Size() {
+		return decoded
+	}
+	scaled := image.NewNRGBA(target)
+	draw.CatmullRom.Scale(scaled, target, decoded, bounds, draw.Src, nil)
+	return scaled
+}
+func (self *Processor) target(format Format, decoded image.Image) Format {
+	if format.Native() {
+		return format
+	}
+	if self.options.Grayscale || opaque(decoded) {
+		return FormatJpeg
+	}
+	return FormatPng
+}
+func decodeImageConfig(reader io.Reader, format Format) (image.Config, error) {
+	if format == FormatAvif {
+		return avif.DecodeConfig(reader)
+	}
+	config, _, err := image.DecodeConfig(reader)
+	return config, err
+}
+func decodeImage(reader io.Reader, format Format) (image.Image, error) {
+	if format == FormatAvif {
+		return avif.Decode(reader)
+	}
+	decoded, _, err := image.Decode(reader)
+	return decoded, err
+}
+func (self *Processor) encode(path string, format Format) (string, error) {
+	source, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	decoded, err := decodeImage(source, format)
+	if err != nil {
+		return "", fmt.Errorf("Unsupported image %s: %w", path, err)
+	}
+	target := self.target(format, decoded)
+	decoded = self.transform(decoded)
+
+	output, err := self.outputPath(target.Extension())
+	if err != nil {
+		return "", err
+	}
+	destination, err := os.Create(output)
+	if err != nil {
+		return "", err
+	}
+	if target == FormatJpeg {
+		quality := self.options.Quality
+		if quality == 0 {
+			quality = DefaultQuality
+		}
+		err = jpeg.Encode(destination, decoded, &jpeg.Options{Quality: quality})
+	} else {
+		err = png.Encode(destination, decoded)
+	}
+	if err != nil {
+		destination.Close()
+		return "", err
+	}
+	return output, destination.Close()
+}
+func (self *Processor) process(path string) (string, error) {
+	format, err := SniffFile(path)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	config, err := decodeImageConfig(file, format)
+	file.Close()
+
+	switch {
+	case err != nil && format == FormatUnknown:
+		return path, nil
+	case err != nil, !self.changes(format, config):
+		if strings.EqualFold(filepath.Ext(path), format.Extension()) {
+			return path, nil
+		}
+		return self.copy(path, format)
+	default:
+		return self.encode(path, format)
+	}
+}
+func (self *Processor) Process(path string) (string, error) {
+	if output, found := self.outputs[path]; found {
+		return output, nil
+	}
+	if output, err := self.process(path); err != nil {
+		return "", err
+	} else {
+		self.outputs[path] = output
+		return output, nil
+	}
+}
+func (self *Processor) Close() error {
+	if self.directory == "" {
+		return nil
+	}
+	directory := self.directory
+	self.directory = ""
+	return os.RemoveAll(directory)
+}
@@ -0,0 +1,239 @@
+package imageproc
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gen2brain/avif"
+)
+
+func writeImage(t *testing.T, name string, encode func(*bytes.Buffer) error) string {
+	var buffer bytes.Buffer
+
+	if err := encode(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+
+	if err := os.WriteFile(path, buffer.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+func colored(width int, height int, fill color.Color) *image.NRGBA {
+	result := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		for x := range width {
+			result.Set(x, y, fill)
+		}
+	}
+	return result
+}
+func pngImage(t *testing.T, name string, source image.Image) string {
+	return writeImage(t, name, func(buffer *bytes.Buffer) error { return png.Encode(buffer, source) })
+}
+func jpegImage(t *testing.T, name string, source image.Image) string {
+	return writeImage(t, name, func(buffer *bytes.Buffer) error {
+		return jpeg.Encode(buffer, source, &jpeg.Options{Quality: 100})
+	})
+}
+func gifImage(t *testing.T, name string, transparent bool) string {
+	palette := color.Palette{color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 0, 0}}
+	source := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+
+	if transparent {
+		source.SetColorIndex(0, 0, 1)
+	}
+	return writeImage(t, name, func(buffer *bytes.Buffer) error { return gif.Encode(buffer, source, nil) })
+}
+func avifImage(t *testing.T, name string, source image.Image) string {
+	return writeImage(t, name, func(buffer *bytes.Buffer) error {
+		return avif.Encode(buffer, source, avif.Options{Quality: 100, Speed: avif.DefaultSpeed})
+	})
+}
+func decode(t *testing.T, path string) (image.Image, Format) {
+	format, err := SniffFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	decoded, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded, format
+}
+
+func TestProcess(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+
+	tests := []struct {
+		name      string
+		path      func(t *testing.T) string
+		options   Options
+		unchanged bool
+		format    Format
+		size      image.Point
+		gray      bool
+	}{
+		{
+			name:      "png is kept",
+			path:      func(t *testing.T) string { return pngImage(t, "a.png", colored(8, 4, red)) },
+			unchanged: true,
+			format:    FormatPng,
+			size:      image.Pt(8, 4),
+		},
+		{
+			name:      "small image is not resized",
+			path:      func(t *testing.T) string { return pngImage(t, "a.png", colored(8, 4, red)) },
+			options:   Options{MaxSize: 8},
+			unchanged: true,
+			format:    FormatPng,
+			size:      image.Pt(8, 4),
+		},
+		{
+			name:    "png is resized",
+			path:    func(t *testing.T) string { return pngImage(t, "a.png", colored(40, 20, red)) },
+			options: Options{MaxSize: 10},
+			format:  FormatPng,
+			size:    image.Pt(10, 5),
+		},
+		{
+			name:   "wrong extension is fixed",
+			path:   func(t *testing.T) string { return pngImage(t, "a.jpg", colored(8, 4, red)) },
+			format: FormatPng,
+			size:   image.Pt(8, 4),
+		},
+		{
+			name:   "opaque gif becomes jpeg",
+			path:   func(t *testing.T) string { return gifImage(t, "a.gif", false) },
+			format: FormatJpeg,
+			size:   image.Pt(4, 4),
+		},
+		{
+			name:   "transparent gif becomes png",
+			path:   func(t *testing.T) string { return gifImage(t, "a.gif", true) },
+			format: FormatPng,
+			size:   image.Pt(4, 4),
+		},
+		{
+			name:   "opaque avif becomes jpeg",
+			path:   func(t *testing.T) string { return avifImage(t, "a.jpg", colored(8, 4, red)) },
+			format: FormatJpeg,
+			size:   image.Pt(8, 4),
+		},
+		{
+			name:   "transparent avif becomes png",
+			path:   func(t *testing.T) string { return avifImage(t, "a.avif", colored(8, 4, color.NRGBA{255, 0, 0, 128})) },
+			format: FormatPng,
+			size:   image.Pt(8, 4),
+		},
+		{
+			name:    "jpeg is recompressed",
+			path:    func(t *testing.T) string { return jpegImage(t, "a.jpg", colored(16, 16, red)) },
+			options: Options{Quality: 50},
+			format:  FormatJpeg,
+			size:    image.Pt(16, 16),
+		},
+		{
+			name:    "grayscale",
+			path:    func(t *testing.T) string { return jpegImage(t, "a.jpg", colored(32, 16, red)) },
+			options: Options{Grayscale: true, MaxSize: 16},
+			format:  FormatJpeg,
+			size:    image.Pt(16, 8),
+			gray:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, err := NewProcessor(tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer processor.Close()
+
+			path := tt.path(t)
+			output, err := processor.Process(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (output == path) != tt.unchanged {
+				t.Errorf("Process() = %q; unchanged %v, want %v", output, output == path, tt.unchanged)
+			}
+			if filepath.Ext(output) != tt.format.Extension() {
+				t.Errorf("Process() = %q; want %s extension", output, tt.format.Extension())
+			}
+			decoded, format := decode(t, output)
+
+			if format != tt.format {
+				t.Errorf("format = %v; want %v", format, tt.format)
+			}
+			if size := decoded.Bounds().Size(); size != tt.size {
+				t.Errorf("size = %v; want %v", size, tt.size)
+			}
+			if gray := decoded.ColorModel() == color.GrayModel; gray != tt.gray {
+				t.Errorf("gray = %v; want %v", gray, tt.gray)
+			}
+			if again, err := processor.Process(path); err != nil || again != output {
+				t.Errorf("second Process() = %q, %v; want %q", again, err, output)
+			}
+		})
+	}
+}
+func TestProcessUndecodable(t *testing.T) {
+	dir := t.TempDir()
+	avif := filepath.Join(dir, "cover.jpg")
+	unknown := filepath.Join(dir, "page.bin")
+
+	if err := os.WriteFile(avif, []byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00avifmif1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(unknown, []byte("<html></html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	processor, err := NewProcessor(Options{MaxSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output, err := processor.Process(avif); err != nil {
+		t.Fatal(err)
+	} else if filepath.Ext(output) != ".avif" {
+		t.Errorf("Process() = %q; want .avif extension", output)
+	}
+	if output, err := processor.Process(unknown); err != nil {
+		t.Fatal(err)
+	} else if output != unknown {
+		t.Errorf("Process() = %q; want %q", output, unknown)
+	}
+	directory := processor.directory
+
+	if err := processor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(directory); !os.IsNotExist(err) {
+		t.Errorf("Close() did not remove %s", directory)
+	}
+	if _, err := processor.Process(filepath.Join(dir, "missing.png")); err == nil {
+		t.Errorf("Process() of a missing file expected error")
+	}
+}
+func TestNewProcessor(t *testing.T) {
+	for _, options := range []Options{{Quality: -1}, {Quality: 101}, {MaxSize: -1}} {
+		if _, err := NewProcessor(options); err == nil {
+			t.Errorf("NewProcessor(%+v) expected error", options)
+		}
+	}
+}
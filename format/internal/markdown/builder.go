@@ -0,0 +1,420 @@
+package markdown
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/markdown/internal/noderenderer"
+	"regexp"
+	"strings"
+)
+
+const assetsDir = "assets"
+const maxHeadingLevel = 6
+
+type container struct {
+	First  string
+	Rest   string
+	Blocks int
+}
+type list struct {
+	Ordered bool
+	Index   int
+}
+type asset struct {
+	Name string
+	Path string
+}
+type builder struct {
+	metadata   base.Metadata
+	output     strings.Builder
+	containers []container
+	lists      []list
+	assets     []asset
+	assetNames map[string]string
+	hasChapter bool
+	inVolume   bool
+	tight      bool
+	table      [][]string
+	verses     []string
+}
+
+func NewBuilder() *builder {
+	return &builder{
+		assetNames: map[string]string{},
+	}
+}
+func (self *builder) SetMetadata(metadata base.Metadata) {
+	self.metadata = metadata
+}
+
+var blockStart = regexp.MustCompile(`^(\d*)([#+\-=.)])`)
+
+func escapeLineStart(line string) string {
+	return blockStart.ReplaceAllString(strings.TrimLeft(line, " \t"), `$1\$2`)
+}
+func (self *builder) prefix(line string, blank bool) string {
+	for index := len(self.containers) - 1; index >= 0; index-- {
+		current := &self.containers[index]
+
+		if current.Blocks == 0 && blank {
+			continue
+		} else if current.Blocks == 0 {
+			line = current.First + line
+		} else {
+			line = current.Rest + line
+		}
+	}
+	return line
+}
+func (self *builder) writeBlock(text string) {
+	if self.output.Len() != 0 && !self.tight {
+		self.output.WriteString(strings.TrimRight(self.prefix("", true), " ") + "\n")
+	}
+	self.tight = false
+
+	for index, line := range strings.Split(text, "\n") {
+		prefixed := self.prefix(line, index != 0)
+		if strings.TrimSpace(line) == "" {
+			prefixed = strings.TrimRight(prefixed, " ")
+		}
+		self.output.WriteString(prefixed + "\n")
+
+		if index == 0 {
+			for containerIndex := range self.containers {
+				self.containers[containerIndex].Blocks++
+			}
+		}
+	}
+}
+func (self *builder) pushBlock(text string) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	self.writeBlock(text)
+	return nil
+}
+func (self *builder) heading(level int, text string) string {
+	return strings.Repeat("#", min(level, maxHeadingLevel)) + " " + strings.ReplaceAll(text, "\\\n", " ")
+}
+func (self *builder) chapterLevel() int {
+	if self.inVolume {
+		return 3
+	}
+	return 2
+}
+func (self *builder) PushVolume(volumeTitle string) error {
+	self.inVolume = true
+	self.hasChapter = false
+	self.writeBlock(self.heading(2, noderenderer.Escape(volumeTitle)))
+	return nil
+}
+func (self *builder) PushChapter(chapterTitle string) error {
+	self.hasChapter = true
+	self.writeBlock(self.heading(self.chapterLevel(), noderenderer.Escape(chapterTitle)))
+	return nil
+}
+func (self *builder) PushParagraph(text string) error {
+	text, err := self.inline(text)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(text, "\n")
+
+	for index := range lines {
+		lines[index] = escapeLineStart(lines[index])
+	}
+	return self.pushBlock(strings.Join(lines, "\n"))
+}
+func (self *builder) PushHeading(level int, text string) error {
+	if text, err := self.inline(text); err != nil {
+		return err
+	} else {
+		return self.pushBlock(self.heading(self.chapterLevel()+level, text))
+	}
+}
+func (self *builder) PushCode(code string) error {
+	fence := noderenderer.Fence(code, "`", 3)
+	return self.pushBlock(fence + "\n" + strings.TrimRight(code, "\n") + "\n" + fence)
+}
+func (self *builder) PushHorizontalRule() error {
+	return self.pushBlock("---")
+}
+func (self *builder) BeginList(ordered bool) error {
+	if len(self.containers) != 0 && self.containers[len(self.containers)-1].Blocks == 1 && len(self.lists) != 0 {
+		self.tight = true
+	}
+	self.lists = append(self.lists, list{Ordered: ordered})
+	return nil
+}
+func (self *builder) BeginListItem() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	current := &self.lists[len(self.lists)-1]
+	current.Index++
+
+	marker := "- "
+	if current.Ordered {
+		marker = fmt.Sprintf("%d. ", current.Index)
+	}
+	self.containers = append(self.containers, container{
+		First: marker,
+		Rest:  strings.Repeat(" ", len(marker)),
+	})
+	return nil
+}
+func (self *builder) EndListItem() error {
+	if len(self.containers) == 0 {
+		return errors.New("List item is not created")
+	}
+	item := self.containers[len(self.containers)-1]
+	self.containers = self.containers[:len(self.containers)-1]
+	self.tight = item.Blocks <= 1
+	return nil
+}
+func (self *builder) EndList() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	self.lists = self.lists[:len(self.lists)-1]
+	self.tight = false
+	return nil
+}
+func (self *builder) BeginBlockquote() error {
+	self.containers = append(self.containers, container{First: "> ", Rest: "> "})
+	return nil
+}
+func (self *builder) EndBlockquote() error {
+	if len(self.containers) == 0 {
+		return errors.New("Blockquote is not created")
+	}
+	self.containers = self.containers[:len(self.containers)-1]
+	return nil
+}
+func (self *builder) BeginTable() error {
+	self.table = [][]string{}
+	return nil
+}
+func (self *builder) BeginTableRow() error {
+	self.table = append(self.table, []string{})
+	return nil
+}
+func (self *builder) PushTableCell(_ bool, text string) error {
+	if len(self.table) == 0 {
+		return errors.New("Table row is not created")
+	}
+	text, err := self.inline(text)
+	if err != nil {
+		return err
+	}
+	row := &self.table[len(self.table)-1]
+	*row = append(*row, strings.ReplaceAll(text, "\\\n", "<br>"))
+	return nil
+}
+func (self *builder) EndTableRow() error {
+	return nil
+}
+func renderTableRow(cells []string, columns int) string {
+	for len(cells) < columns {
+		cells = append(cells, "")
+	}
+	return "| " + strings.Join(cells, " | ") + " |"
+}
+func (self *builder) EndTable() error {
+	columns := 0
+	for _, row := range self.table {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return nil
+	}
+	lines := []string{}
+
+	for index, row := range self.table {
+		lines = append(lines, renderTableRow(row, columns))
+		if index == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	self.table = nil
+	return self.pushBlock(strings.Join(lines, "\n"))
+}
+func (self *builder) BeginPoem() error {
+	return nil
+}
+func (self *builder) BeginStanza() error {
+	self.verses = []string{}
+	return nil
+}
+func (self *builder) PushVerse(text string) error {
+	text, err := self.inline(text)
+	if err != nil {
+		return err
+	}
+	self.verses = append(self.verses, escapeLineStart(strings.ReplaceAll(text, "\\\n", " ")))
+	return nil
+}
+func (self *builder) EndStanza() error {
+	if len(self.verses) == 0 {
+		return nil
+	}
+	verses := self.verses
+	self.verses = nil
+	return self.pushBlock(strings.Join(verses, "\\\n"))
+}
+func (self *builder) EndPoem() error {
+	return nil
+}
+func (self *builder) addAsset(imagePath string) (string, error) {
+	if _, err := os.Stat(imagePath); err != nil {
+		return "", err
+	}
+	if name, found := self.assetNames[imagePath]; found {
+		return name, nil
+	}
+	name := filepath.Base(imagePath)
+
+	for _, asset := range self.assets {
+		if asset.Name == name {
+			name = fmt.Sprintf("%d-%s", len(self.assets)+1, filepath.Base(imagePath))
+			break
+		}
+	}
+	self.assetNames[imagePath] = name
+	self.assets = append(self.assets, asset{Name: name, Path: imagePath})
+
+	return name, nil
+}
+func (self *builder) inline(text string) (string, error) {
+	return noderenderer.ReplaceImages(text, func(src string) (string, error) {
+		if name, err := self.addAsset(src); err != nil {
+			return "", err
+		} else {
+			return assetsDir + "/" + name, nil
+		}
+	})
+}
+func (self *builder) PushImage(imagePath string) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	if name, err := self.addAsset(imagePath); err != nil {
+		return err
+	} else {
+		return self.pushBlock(fmt.Sprintf("![](%s)", noderenderer.Destination(assetsDir+"/"+name)))
+	}
+}
+func (self *builder) writeField(output *strings.Builder, name string, value any) {
+	switch value := value.(type) {
+	case string:
+		if value == "" {
+			return
+		}
+	case []string:
+		if len(value) == 0 {
+			return
+		}
+	case int:
+		if value == 0 {
+			return
+		}
+	}
+	encoded, _ := json.Marshal(value)
+	fmt.Fprintf(output, "%s: %s\n", name, encoded)
+}
+func (self *builder) renderFrontMatter() (string, error) {
+	var output strings.Builder
+	metadata := self.metadata
+
+	output.WriteString("---\n")
+	self.writeField(&output, "title", metadata.Title)
+	self.writeField(&output, "original_title", metadata.OriginalTitle)
+	self.writeField(&output, "alt_titles", metadata.AltTitles)
+	self.writeField(&output, "authors", metadata.Authors)
+	self.writeField(&output, "genres", metadata.Genres)
+	self.writeField(&output, "tags", metadata.Tags)
+	self.writeField(&output, "status", metadata.Status)
+	self.writeField(&output, "age_rating", metadata.AgeRating)
+	self.writeField(&output, "language", metadata.Language)
+	self.writeField(&output, "source_language", metadata.SourceLanguage)
+	self.writeField(&output, "publisher", metadata.Publisher)
+	self.writeField(&output, "year", metadata.Year)
+	self.writeField(&output, "source", metadata.Source)
+	self.writeField(&output, "series", metadata.Series)
+	self.writeField(&output, "series_number", metadata.SeriesNumber)
+
+	if metadata.CoverPath != "" {
+		if name, err := self.addAsset(metadata.CoverPath); err != nil {
+			return "", err
+		} else {
+			self.writeField(&output, "cover", assetsDir+"/"+name)
+		}
+	}
+	output.WriteString("---\n\n")
+	return output.String(), nil
+}
+func (self *builder) renderHeader() string {
+	var output strings.Builder
+
+	output.WriteString(self.heading(1, noderenderer.Escape(self.metadata.Title)) + "\n")
+
+	for _, line := range strings.Split(self.metadata.Annotation, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			output.WriteString("\n" + escapeLineStart(noderenderer.Escape(line)) + "\n")
+		}
+	}
+	return output.String()
+}
+func (self *builder) copyAsset(directory string, asset asset) error {
+	source, err := os.Open(asset.Path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := os.Create(filepath.Join(directory, asset.Name))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(destination, source); err != nil {
+		destination.Close()
+		return err
+	}
+	return destination.Close()
+}
+func (self *builder) copyAssets(filename string) error {
+	if len(self.assets) == 0 {
+		return nil
+	}
+	directory := filepath.Join(filepath.Dir(filename), assetsDir)
+
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+	for _, asset := range self.assets {
+		if err := self.copyAsset(directory, asset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (self *builder) Build(filename string) error {
+	frontMatter, err := self.renderFrontMatter()
+	if err != nil {
+		return err
+	}
+	if err := self.copyAssets(filename); err != nil {
+		return err
+	}
+	content := frontMatter + self.renderHeader()
+
+	if self.output.Len() != 0 {
+		content += "\n" + self.output.String()
+	}
+	return os.WriteFile(filename, []byte(content), 0644)
+}
@@ -0,0 +1,172 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/nodehandler"
+	"ranobedl/format/internal/testutil"
+	"ranobedl/schema"
+	"strings"
+	"testing"
+)
+
+func TestRenderInline(t *testing.T) {
+	link := testutil.Text("сайт")
+	link.Marks = []schema.Mark{{Type: schema.MarkTypeLink, Attrs: map[string]any{"href": "https://example.com/a b"}}}
+
+	tests := []struct {
+		name     string
+		nodes    []schema.Node
+		expected string
+	}{
+		{"escape", []schema.Node{testutil.Text("2*3 = [x]_y")}, `2\*3 = \[x\]\_y`},
+		{"bold", []schema.Node{testutil.Text("жирный ", schema.MarkTypeBold), testutil.Text("текст")}, "**жирный** текст"},
+		{"nested", []schema.Node{testutil.Text("оба", schema.MarkTypeBold, schema.MarkTypeItalic)}, "***оба***"},
+		{"strike", []schema.Node{testutil.Text("нет", schema.MarkTypeStrike)}, "~~нет~~"},
+		{"code", []schema.Node{testutil.Text("a*`b`", schema.MarkTypeCode)}, "`` a*`b` ``"},
+		{"link", []schema.Node{link}, "[сайт](<https://example.com/a b>)"},
+		{"hardbreak", []schema.Node{testutil.Text("a"), {Type: schema.NodeTypeHardBreak}, testutil.Text("b")}, "a\\\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result, err := RenderInline(tt.nodes); err != nil {
+				t.Fatal(err)
+			} else if result != tt.expected {
+				t.Errorf("RenderInline() = %q; want %q", result, tt.expected)
+			}
+		})
+	}
+}
+func TestBuild(t *testing.T) {
+	directory := t.TempDir()
+	imagePath := filepath.Join(directory, "cache", "11image1.png")
+
+	if err := os.MkdirAll(filepath.Dir(imagePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(imagePath, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inlinePath := filepath.Join(directory, "cache", "inline image.png")
+
+	if err := os.WriteFile(inlinePath, []byte("inline"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc := schema.Node{
+		Type: schema.NodeTypeDoc,
+		Content: []schema.Node{
+			{Type: schema.NodeTypeHeading, Attrs: map[string]any{"level": float64(1)}, Content: []schema.Node{testutil.Text("Пролог")}},
+			testutil.Paragraph(testutil.Text("1. не список")),
+			testutil.Paragraph(testutil.Text("Текст "), testutil.Image(inlinePath)),
+			{Type: schema.NodeTypeBulletList, Content: []schema.Node{
+				testutil.Item(testutil.Paragraph(testutil.Text("первый"))),
+				testutil.Item(
+					testutil.Paragraph(testutil.Text("второй")),
+					schema.Node{Type: schema.NodeTypeOrderedList, Content: []schema.Node{
+						testutil.Item(testutil.Paragraph(testutil.Text("вложенный"))),
+					}},
+				),
+			}},
+			{Type: schema.NodeTypeBlockquote, Content: []schema.Node{testutil.Text("цитата"), {Type: schema.NodeTypeHardBreak}, testutil.Text("вторая строка")}},
+			{Type: schema.NodeTypeCodeBlock, Content: []schema.Node{testutil.Text("fmt.Println(\"```\")")}},
+			{Type: schema.NodeTypeHorizontalRule},
+			testutil.Image(imagePath),
+			{Type: schema.NodeTypeTable, Content: []schema.Node{
+				{Type: schema.NodeTypeTableRow, Content: []schema.Node{
+					{Type: schema.NodeTypeTableHeader, Content: []schema.Node{testutil.Text("Имя")}},
+					{Type: schema.NodeTypeTableHeader, Content: []schema.Node{testutil.Text("a|b")}},
+				}},
+				{Type: schema.NodeTypeTableRow, Content: []schema.Node{
+					{Type: schema.NodeTypeTableCell, Content: []schema.Node{testutil.Paragraph(testutil.Text("x")), testutil.Paragraph(testutil.Text("y"))}},
+					{Type: schema.NodeTypeTableCell, Content: []schema.Node{testutil.Image(imagePath)}},
+				}},
+			}},
+			{Type: schema.NodeTypePoem, Content: []schema.Node{
+				{Type: schema.NodeTypeStanza, Content: []schema.Node{testutil.Paragraph(testutil.Text("строка"), schema.Node{Type: schema.NodeTypeHardBreak}, testutil.Text("- вторая"))}},
+				{Type: schema.NodeTypeStanza, Content: []schema.Node{testutil.Paragraph(testutil.Text("третья"))}},
+			}},
+		},
+	}
+	markdown := NewBuilder()
+	markdown.SetMetadata(base.Metadata{
+		Title:      "Повелитель тайн",
+		Authors:    []string{"Каракатица"},
+		Annotation: "Аннотация",
+	})
+	if err := markdown.PushVolume("Том 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := markdown.PushChapter("Глава 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodehandler.PushBlock(markdown, RenderInline, doc); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(directory, "out", "book.md")
+
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := markdown.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"---",
+		`title: "Повелитель тайн"`,
+		`authors: ["Каракатица"]`,
+		"---",
+		"",
+		"# Повелитель тайн",
+		"",
+		"Аннотация",
+		"",
+		"## Том 1",
+		"",
+		"### Глава 1",
+		"",
+		"#### Пролог",
+		"",
+		`1\. не список`,
+		"",
+		"Текст ![](<assets/inline image.png>)",
+		"",
+		"- первый",
+		"- второй",
+		"  1. вложенный",
+		"",
+		"> цитата\\",
+		"> вторая строка",
+		"",
+		"````",
+		"fmt.Println(\"```\")",
+		"````",
+		"",
+		"---",
+		"",
+		"![](assets/11image1.png)",
+		"",
+		`| Имя | a\|b |`,
+		"| --- | --- |",
+		"| x<br>y | ![](assets/11image1.png) |",
+		"",
+		"строка\\",
+		`\- вторая`,
+		"",
+		"третья",
+		"",
+	}, "\n")
+	if string(data) != expected {
+		t.Errorf("Build() =\n%s\nwant\n%s", data, expected)
+	}
+	if asset, err := os.ReadFile(filepath.Join(directory, "out", "assets", "11image1.png")); err != nil || string(asset) != "png" {
+		t.Errorf("asset = %q, %v", asset, err)
+	}
+	if asset, err := os.ReadFile(filepath.Join(directory, "out", "assets", "inline image.png")); err != nil || string(asset) != "inline" {
+		t.Errorf("inline asset = %q, %v", asset, err)
+	}
+}
@@ -0,0 +1,42 @@
+package noderenderer
+
+import (
+	"strings"
+)
+
+var textEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"`", "\\`",
+	"*", "\\*",
+	"_", "\\_",
+	"[", "\\[",
+	"]", "\\]",
+	"<", "\\<",
+	">", "\\>",
+	"~", "\\~",
+	"|", "\\|",
+	"&", "&amp;",
+)
+
+func Escape(text string) string {
+	return textEscaper.Replace(text)
+}
+func Destination(url string) string {
+	if strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
+func Fence(text string, char string, minimum int) string {
+	longest, current := 0, 0
+
+	for _, r := range text {
+		if string(r) == char {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+	return strings.Repeat(char, max(longest+1, minimum))
+}
@@ -0,0 +1,14 @@
+package noderenderer
+
+import (
+	"fmt"
+	"ranobedl/schema"
+)
+
+func renderImage(node schema.Node) (string, error) {
+	if src, err := node.ImageSrc(); err != nil {
+		return "", err
+	} else {
+		return fmt.Sprintf("![](%s)", Destination(src)), nil
+	}
+}
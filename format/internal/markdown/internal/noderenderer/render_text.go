@@ -0,0 +1,100 @@
+package noderenderer
+
+import (
+	"fmt"
+	"ranobedl/schema"
+	"strings"
+)
+
+type textRenderer struct {
+	schema.Node
+}
+
+func newTextRenderer(node schema.Node) *textRenderer {
+	return &textRenderer{node}
+}
+
+func (tr *textRenderer) wrap(text string, open string, close string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + open + trimmed + close + text[start+len(trimmed):]
+}
+func (tr *textRenderer) handleBold(text string) (string, error) {
+	return tr.wrap(text, "**", "**"), nil
+}
+func (tr *textRenderer) handleItalic(text string) (string, error) {
+	return tr.wrap(text, "*", "*"), nil
+}
+func (tr *textRenderer) handleUnderline(text string) (string, error) {
+	return tr.wrap(text, "<u>", "</u>"), nil
+}
+func (tr *textRenderer) handleStrike(text string) (string, error) {
+	return tr.wrap(text, "~~", "~~"), nil
+}
+func (tr *textRenderer) handleCode(text string) (string, error) {
+	fence := Fence(tr.Node.Text, "`", 1)
+
+	if strings.HasPrefix(tr.Node.Text, "`") || strings.HasSuffix(tr.Node.Text, "`") {
+		return fence + " " + tr.Node.Text + " " + fence, nil
+	}
+	return fence + tr.Node.Text + fence, nil
+}
+func (tr *textRenderer) handleLink(text string, mark schema.Mark) (string, error) {
+	if href, err := mark.LinkHref(); err != nil {
+		return "", err
+	} else {
+		return fmt.Sprintf("[%s](%s)", text, Destination(href)), nil
+	}
+}
+func (tr *textRenderer) renderMark(text string, mark schema.Mark) (string, error) {
+	switch mark.Type {
+	case schema.MarkTypeBold:
+		return tr.handleBold(text)
+	case schema.MarkTypeItalic:
+		return tr.handleItalic(text)
+	case schema.MarkTypeUnderline:
+		return tr.handleUnderline(text)
+	case schema.MarkTypeStrike:
+		return tr.handleStrike(text)
+	case schema.MarkTypeCode:
+		return tr.handleCode(text)
+	case schema.MarkTypeLink:
+		return tr.handleLink(text, mark)
+
+	default:
+		panic(fmt.Sprintf("Undefined MarkType: %d", mark.Type))
+	}
+}
+func (tr *textRenderer) orderedMarks() []schema.Mark {
+	marks := []schema.Mark{}
+
+	for _, mark := range tr.Node.Marks {
+		if mark.Type == schema.MarkTypeCode {
+			marks = append([]schema.Mark{mark}, marks...)
+		} else {
+			marks = append(marks, mark)
+		}
+	}
+	return marks
+}
+func (tr *textRenderer) Render() (string, error) {
+	if tr.Node.Type != schema.NodeTypeText {
+		return "", fmt.Errorf("Expected text node, but got %v", tr.Node.Type)
+	}
+	output := Escape(tr.Node.Text)
+
+	for _, mark := range tr.orderedMarks() {
+		if rendered, err := tr.renderMark(output, mark); err != nil {
+			return "", err
+		} else {
+			output = rendered
+		}
+	}
+	return output, nil
+}
+func renderText(node schema.Node) (string, error) {
+	return newTextRenderer(node).Render()
+}
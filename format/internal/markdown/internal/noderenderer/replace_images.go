@@ -0,0 +1,30 @@
+package noderenderer
+
+import (
+	"regexp"
+	"strings"
+)
+
+var inlineImage = regexp.MustCompile(`!\[\]\((<[^<>]*>|[^()<>\s]*)\)`)
+
+func source(destination string) string {
+	if strings.HasPrefix(destination, "<") {
+		return strings.NewReplacer("%3C", "<", "%3E", ">").Replace(destination[1 : len(destination)-1])
+	}
+	return destination
+}
+func ReplaceImages(text string, replace func(src string) (string, error)) (string, error) {
+	var err error
+
+	output := inlineImage.ReplaceAllStringFunc(text, func(match string) string {
+		src := source(inlineImage.FindStringSubmatch(match)[1])
+
+		if replaced, replaceErr := replace(src); replaceErr != nil {
+			err = replaceErr
+			return match
+		} else {
+			return "![](" + Destination(replaced) + ")"
+		}
+	})
+	return output, err
+}
@@ -0,0 +1,10 @@
+package markdown
+
+import (
+	"ranobedl/format/internal/markdown/internal/noderenderer"
+	"ranobedl/schema"
+)
+
+func RenderInline(node []schema.Node) (string, error) {
+	return noderenderer.RenderInlineChildren(node)
+}
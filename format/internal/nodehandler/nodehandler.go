@@ -1,14 +1,22 @@
 package nodehandler
 
 import (
-	"fmt"
+	"errors"
 	"ranobedl/format/internal/builder"
 	"ranobedl/schema"
 )
 
 type RenderInline = func(node []schema.Node) (string, error)
 
-func pushDoc(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+const (
+	minHeadingLevel = 1
+	maxHeadingLevel = 6
+)
+const unsupportedImageText = builder.UnsupportedImageText
+
+var errUnsupportedImage = builder.ErrUnsupportedImage
+
+func pushChildren(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
 	for _, child := range node.Content {
 		if err := PushBlock(builder, renderInline, child); err != nil {
 			return err
@@ -16,6 +24,9 @@ func pushDoc(builder builder.Builder, renderInline RenderInline, node schema.Nod
 	}
 	return nil
 }
+func pushDoc(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	return pushChildren(builder, renderInline, node)
+}
 func pushParagraph(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
 	if rendered, err := renderInline(node.Content); err != nil {
 		return err
@@ -23,67 +34,190 @@ func pushParagraph(builder builder.Builder, renderInline RenderInline, node sche
 		return builder.PushParagraph(rendered)
 	}
 }
+func headingLevel(node schema.Node) int {
+	level, err := node.HeadingLevel()
+	if err != nil {
+		return minHeadingLevel
+	}
+	return min(max(level, minHeadingLevel), maxHeadingLevel)
+}
 func pushHeading(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
-	return pushParagraph(
-		builder,
-		renderInline,
-		node,
-	)
+	if rendered, err := renderInline(node.Content); err != nil {
+		return err
+	} else {
+		return builder.PushHeading(headingLevel(node), rendered)
+	}
 }
-func pushBulletList(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+func pushList(builder builder.Builder, renderInline RenderInline, node schema.Node, ordered bool) error {
+	if err := builder.BeginList(ordered); err != nil {
+		return err
+	}
 	for _, child := range node.Content {
-		if err := pushBulletListItem(builder, renderInline, child); err != nil {
+		if err := pushListItem(builder, renderInline, child); err != nil {
 			return err
 		}
 	}
-	return nil
+	return builder.EndList()
+}
+func pushBulletList(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	return pushList(builder, renderInline, node, false)
 }
 func pushOrderedList(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
-	for index, child := range node.Content {
-		if err := pushOrderedListItem(builder, renderInline, child, index+1); err != nil {
-			return err
+	return pushList(builder, renderInline, node, true)
+}
+func pushListItem(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	if err := builder.BeginListItem(); err != nil {
+		return err
+	}
+	if err := pushContent(builder, renderInline, node); err != nil {
+		return err
+	}
+	return builder.EndListItem()
+}
+func isInline(nodes []schema.Node) bool {
+	for _, node := range nodes {
+		if !node.Type.IsInline() || node.Type == schema.NodeTypeImage {
+			return false
 		}
 	}
-	return nil
+	return true
 }
-func pushBulletListItem(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
-	if rendered, err := renderInline(node.Content[0].Content); err != nil {
+func pushContent(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	if len(node.Content) != 0 && isInline(node.Content) {
+		return pushParagraph(builder, renderInline, node)
+	}
+	return pushChildren(builder, renderInline, node)
+}
+func pushBlockquote(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	if err := builder.BeginBlockquote(); err != nil {
 		return err
-	} else {
-		return builder.PushParagraph(fmt.Sprintf("	* %s", rendered))
 	}
+	if err := pushContent(builder, renderInline, node); err != nil {
+		return err
+	}
+	return builder.EndBlockquote()
+}
+func cellContent(node schema.Node) []schema.Node {
+	if isInline(node.Content) {
+		return node.Content
+	}
+	output := []schema.Node{}
+
+	for _, child := range node.Content {
+		if len(output) != 0 {
+			output = append(output, schema.Node{Type: schema.NodeTypeHardBreak})
+		}
+		switch child.Type {
+		case schema.NodeTypeParagraph, schema.NodeTypeHeading:
+			output = append(output, child.Content...)
+		case schema.NodeTypeImage:
+			output = append(output, child)
+		default:
+			output = append(output, schema.Node{Type: schema.NodeTypeText, Text: child.PlainText()})
+		}
+	}
+	return output
 }
-func pushOrderedListItem(builder builder.Builder, renderInline RenderInline, node schema.Node, index int) error {
-	if rendered, err := renderInline(node.Content[0].Content); err != nil {
+func pushTableCell(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	if rendered, err := renderInline(cellContent(node)); err != nil {
 		return err
 	} else {
-		return builder.PushParagraph(fmt.Sprintf("	%d. %s", index, rendered))
+		return builder.PushTableCell(node.Type == schema.NodeTypeTableHeader, rendered)
 	}
 }
-func pushBlockquote(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+func pushTableRow(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	if err := builder.BeginTableRow(); err != nil {
+		return err
+	}
 	for _, child := range node.Content {
-		if err := PushBlock(builder, renderInline, child); err != nil {
+		if err := pushTableCell(builder, renderInline, child); err != nil {
 			return err
 		}
 	}
-	return nil
+	return builder.EndTableRow()
+}
+func pushTable(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	if err := builder.BeginTable(); err != nil {
+		return err
+	}
+	for _, child := range node.Content {
+		if err := pushTableRow(builder, renderInline, child); err != nil {
+			return err
+		}
+	}
+	return builder.EndTable()
 }
-func pushCodeBlock(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
-	return pushParagraph(
-		builder,
-		renderInline,
-		node,
-	)
+func splitVerses(nodes []schema.Node) [][]schema.Node {
+	output := [][]schema.Node{}
+	current := []schema.Node{}
+
+	for _, node := range nodes {
+		if node.Type != schema.NodeTypeHardBreak {
+			current = append(current, node)
+		} else if len(current) != 0 {
+			output = append(output, current)
+			current = []schema.Node{}
+		}
+	}
+	if len(current) != 0 {
+		output = append(output, current)
+	}
+	return output
+}
+func pushStanza(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	if err := builder.BeginStanza(); err != nil {
+		return err
+	}
+	for _, verse := range splitVerses(cellContent(node)) {
+		if rendered, err := renderInline(verse); err != nil {
+			return err
+		} else if err := builder.PushVerse(rendered); err != nil {
+			return err
+		}
+	}
+	return builder.EndStanza()
+}
+func hasStanzas(node schema.Node) bool {
+	for _, child := range node.Content {
+		if child.Type == schema.NodeTypeStanza {
+			return true
+		}
+	}
+	return false
+}
+func pushPoem(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
+	if err := builder.BeginPoem(); err != nil {
+		return err
+	}
+	if !hasStanzas(node) {
+		if err := pushStanza(builder, renderInline, node); err != nil {
+			return err
+		}
+	} else {
+		for _, child := range node.Content {
+			if err := pushStanza(builder, renderInline, child); err != nil {
+				return err
+			}
+		}
+	}
+	return builder.EndPoem()
+}
+func pushCodeBlock(builder builder.Builder, _ RenderInline, node schema.Node) error {
+	return builder.PushCode(node.PlainText())
 }
 func pushHorizontalRule(builder builder.Builder, _ RenderInline, _ schema.Node) error {
-	return builder.PushParagraph("***")
+	return builder.PushHorizontalRule()
 }
-func pushImage(builder builder.Builder, _ RenderInline, node schema.Node) error {
+func pushImage(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
 	if src, err := node.ImageSrc(); err != nil {
 		return err
-	} else {
-		return builder.PushImage(src)
+	} else if err := builder.PushImage(src); !errors.Is(err, errUnsupportedImage) {
+		return err
 	}
+	return pushParagraph(builder, renderInline, schema.Node{
+		Type:    schema.NodeTypeParagraph,
+		Content: []schema.Node{{Type: schema.NodeTypeText, Text: unsupportedImageText, Marks: []schema.Mark{}}},
+	})
 }
 
 func PushBlock(builder builder.Builder, renderInline RenderInline, node schema.Node) error {
@@ -106,6 +240,16 @@ func PushBlock(builder builder.Builder, renderInline RenderInline, node schema.N
 		return pushHorizontalRule(builder, renderInline, node)
 	case schema.NodeTypeImage:
 		return pushImage(builder, renderInline, node)
+	case schema.NodeTypeTable:
+		return pushTable(builder, renderInline, node)
+	case schema.NodeTypeTableRow:
+		return pushTable(builder, renderInline, schema.Node{Type: schema.NodeTypeTable, Content: []schema.Node{node}})
+	case schema.NodeTypeTableHeader, schema.NodeTypeTableCell:
+		return pushContent(builder, renderInline, node)
+	case schema.NodeTypePoem:
+		return pushPoem(builder, renderInline, node)
+	case schema.NodeTypeStanza:
+		return pushPoem(builder, renderInline, schema.Node{Type: schema.NodeTypePoem, Content: []schema.Node{node}})
 	default:
 		panic("Unreachable")
 	}
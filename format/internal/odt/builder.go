@@ -0,0 +1,333 @@
+package odt
+
+import (
+	"errors"
+	"fmt"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/office"
+	"strings"
+)
+
+const maxHeadingLevel = 6
+const maxImageWidth = 170
+const maxImageHeight = 230
+
+type tableCell struct {
+	Header  bool
+	Content string
+}
+type builder struct {
+	metadata   base.Metadata
+	body       strings.Builder
+	images     []office.Image
+	imageIndex map[string]int
+	frames     int
+	lists      int
+	quotes     int
+	tables     int
+	table      [][]tableCell
+	verses     []string
+	inVolume   bool
+	hasChapter bool
+}
+
+func NewBuilder() *builder {
+	return &builder{imageIndex: map[string]int{}}
+}
+func (self *builder) SetMetadata(metadata base.Metadata) {
+	self.metadata = metadata
+}
+func escapeCode(code string) string {
+	var output strings.Builder
+
+	for index, line := range strings.Split(strings.TrimRight(code, "\n"), "\n") {
+		if index > 0 {
+			output.WriteString("<text:line-break/>")
+		}
+		for index, part := range strings.Split(line, " ") {
+			if index > 0 {
+				output.WriteString(`<text:s/>`)
+			}
+			output.WriteString(escapeText(part))
+		}
+	}
+	return output.String()
+}
+func (self *builder) pushBlock(block string) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	self.body.WriteString(block + "\n")
+	return nil
+}
+func (self *builder) pushTitle(level int, title string) {
+	fmt.Fprintf(
+		&self.body, `<text:h text:style-name="%s" text:outline-level="%d">%s</text:h>`+"\n",
+		pageBreakStyle(level), level, escapeText(title),
+	)
+}
+func (self *builder) PushVolume(volumeTitle string) error {
+	self.inVolume = true
+	self.hasChapter = false
+	self.pushTitle(1, volumeTitle)
+	return nil
+}
+func (self *builder) chapterLevel() int {
+	if self.inVolume {
+		return 2
+	}
+	return 1
+}
+func (self *builder) PushChapter(chapterTitle string) error {
+	self.hasChapter = true
+	self.pushTitle(self.chapterLevel(), chapterTitle)
+	return nil
+}
+func (self *builder) paragraphStyle() string {
+	switch {
+	case self.quotes != 0:
+		return "Quotations"
+	case self.lists != 0:
+		return "List_20_Paragraph"
+	default:
+		return "Text_20_body"
+	}
+}
+func (self *builder) PushParagraph(text string) error {
+	return self.pushBlock(fmt.Sprintf(`<text:p text:style-name="%s">%s</text:p>`, self.paragraphStyle(), text))
+}
+func (self *builder) PushHeading(level int, text string) error {
+	level = min(self.chapterLevel()+level, maxHeadingLevel)
+
+	return self.pushBlock(fmt.Sprintf(
+		`<text:h text:style-name="Heading_20_%d" text:outline-level="%d">%s</text:h>`,
+		level, level, text,
+	))
+}
+func (self *builder) PushCode(code string) error {
+	return self.pushBlock(fmt.Sprintf(`<text:p text:style-name="Preformatted_20_Text">%s</text:p>`, escapeCode(code)))
+}
+func (self *builder) PushHorizontalRule() error {
+	return self.pushBlock(`<text:p text:style-name="Horizontal_20_Line"/>`)
+}
+func (self *builder) BeginList(ordered bool) error {
+	style := "List_20_1"
+	if ordered {
+		style = "Numbering_20_123"
+	}
+	self.lists++
+	return self.pushBlock(fmt.Sprintf(`<text:list text:style-name="%s">`, style))
+}
+func (self *builder) BeginListItem() error {
+	if self.lists == 0 {
+		return errors.New("List is not created")
+	}
+	return self.pushBlock("<text:list-item>")
+}
+func (self *builder) EndListItem() error {
+	if self.lists == 0 {
+		return errors.New("List item is not created")
+	}
+	return self.pushBlock("</text:list-item>")
+}
+func (self *builder) EndList() error {
+	if self.lists == 0 {
+		return errors.New("List is not created")
+	}
+	self.lists--
+	return self.pushBlock("</text:list>")
+}
+func (self *builder) BeginBlockquote() error {
+	self.quotes++
+	return nil
+}
+func (self *builder) EndBlockquote() error {
+	if self.quotes == 0 {
+		return errors.New("Blockquote is not created")
+	}
+	self.quotes--
+	return nil
+}
+func (self *builder) BeginTable() error {
+	self.table = [][]tableCell{}
+	return nil
+}
+func (self *builder) BeginTableRow() error {
+	self.table = append(self.table, []tableCell{})
+	return nil
+}
+func (self *builder) PushTableCell(header bool, text string) error {
+	if len(self.table) == 0 {
+		return errors.New("Table row is not created")
+	}
+	row := &self.table[len(self.table)-1]
+	*row = append(*row, tableCell{Header: header, Content: text})
+	return nil
+}
+func (self *builder) EndTableRow() error {
+	return nil
+}
+func isHeaderRow(row []tableCell) bool {
+	for _, cell := range row {
+		if !cell.Header {
+			return false
+		}
+	}
+	return len(row) != 0
+}
+func renderTableRow(row []tableCell, columns int) string {
+	var output strings.Builder
+
+	output.WriteString("<table:table-row>")
+	for index := range columns {
+		cell := tableCell{}
+		if index < len(row) {
+			cell = row[index]
+		}
+		style := "Table_20_Contents"
+		if cell.Header {
+			style = "Table_20_Heading"
+		}
+		fmt.Fprintf(
+			&output, `<table:table-cell table:style-name="Table_Cell" office:value-type="string"><text:p text:style-name="%s">%s</text:p></table:table-cell>`,
+			style, cell.Content,
+		)
+	}
+	output.WriteString("</table:table-row>\n")
+	return output.String()
+}
+func (self *builder) EndTable() error {
+	rows := self.table
+	self.table = nil
+	columns := 0
+
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return nil
+	}
+	self.tables++
+	var output strings.Builder
+
+	fmt.Fprintf(&output, `<table:table table:name="Table%d">`+"\n", self.tables)
+	fmt.Fprintf(&output, `<table:table-column table:number-columns-repeated="%d"/>`+"\n", columns)
+
+	headers := 0
+	for headers < len(rows) && isHeaderRow(rows[headers]) {
+		headers++
+	}
+	if headers != 0 {
+		output.WriteString("<table:table-header-rows>\n")
+		for _, row := range rows[:headers] {
+			output.WriteString(renderTableRow(row, columns))
+		}
+		output.WriteString("</table:table-header-rows>\n")
+	}
+	for _, row := range rows[headers:] {
+		output.WriteString(renderTableRow(row, columns))
+	}
+	output.WriteString("</table:table>")
+	return self.pushBlock(output.String())
+}
+func (self *builder) BeginPoem() error {
+	return nil
+}
+func (self *builder) BeginStanza() error {
+	self.verses = []string{}
+	return nil
+}
+func (self *builder) PushVerse(text string) error {
+	self.verses = append(self.verses, text)
+	return nil
+}
+func (self *builder) EndStanza() error {
+	if len(self.verses) == 0 {
+		return nil
+	}
+	verses := self.verses
+	self.verses = nil
+	return self.pushBlock(fmt.Sprintf(`<text:p text:style-name="Verse">%s</text:p>`, strings.Join(verses, "<text:line-break/>")))
+}
+func (self *builder) EndPoem() error {
+	return nil
+}
+func (self *builder) addImage(imagePath string) (int, office.Image, error) {
+	if index, found := self.imageIndex[imagePath]; found {
+		return index, self.images[index-1], nil
+	}
+	image, err := office.NewImage(imagePath)
+	if err != nil {
+		return 0, office.Image{}, err
+	}
+	self.images = append(self.images, image)
+	self.imageIndex[imagePath] = len(self.images)
+	return len(self.images), image, nil
+}
+func (self *builder) frame(imagePath string) (string, error) {
+	index, image, err := self.addImage(imagePath)
+	if err != nil {
+		return "", err
+	}
+	self.frames++
+	width, height := image.Size(maxImageWidth, maxImageHeight)
+
+	return fmt.Sprintf(
+		`<draw:frame draw:name="Image%d" text:anchor-type="as-char" svg:width="%.2fmm" svg:height="%.2fmm" draw:z-index="0">`+
+			`<draw:image xlink:href="%s" xlink:type="simple" xlink:show="embed" xlink:actuate="onLoad"/></draw:frame>`,
+		self.frames, width, height, imageHref(index, image),
+	), nil
+}
+func (self *builder) PushImage(imagePath string) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	if frame, err := self.frame(imagePath); err != nil {
+		return err
+	} else {
+		return self.pushBlock(fmt.Sprintf(`<text:p text:style-name="Picture">%s</text:p>`, frame))
+	}
+}
+func (self *builder) renderTitlePage() (string, error) {
+	var output strings.Builder
+	metadata := self.metadata
+	titleStyle := "Title"
+
+	if metadata.CoverPath != "" {
+		if frame, err := self.frame(metadata.CoverPath); err == nil {
+			fmt.Fprintf(&output, `<text:p text:style-name="Picture">%s</text:p>`+"\n", frame)
+			titleStyle = "Title_Break"
+		} else if !errors.Is(err, base.ErrUnsupportedImage) {
+			return "", err
+		}
+	}
+	fmt.Fprintf(&output, `<text:p text:style-name="%s">%s</text:p>`+"\n", titleStyle, escapeText(metadata.Title))
+
+	if len(metadata.Authors) != 0 {
+		fmt.Fprintf(
+			&output, `<text:p text:style-name="Subtitle">%s</text:p>`+"\n",
+			escapeText(strings.Join(metadata.Authors, ", ")),
+		)
+	}
+	for _, line := range strings.Split(metadata.Annotation, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(&output, `<text:p text:style-name="Text_20_body">%s</text:p>`+"\n", escapeText(line))
+		}
+	}
+	return output.String(), nil
+}
+func (self *builder) Build(filename string) error {
+	titlePage, err := self.renderTitlePage()
+	if err != nil {
+		return err
+	}
+	archive, err := office.CreateArchive(filename)
+	if err != nil {
+		return err
+	}
+	if err := self.writeContent(archive, titlePage); err != nil {
+		archive.Close()
+		return err
+	}
+	return archive.Close()
+}
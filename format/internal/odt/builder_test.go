@@ -0,0 +1,121 @@
+package odt
+
+import (
+	"archive/zip"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/nodehandler"
+	"ranobedl/format/internal/testutil"
+	"ranobedl/schema"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "image.png")
+
+	if file, err := os.Create(imagePath); err != nil {
+		t.Fatal(err)
+	} else if err := png.Encode(file, image.NewGray(image.Rect(0, 0, 96, 48))); err != nil {
+		t.Fatal(err)
+	} else {
+		file.Close()
+	}
+	doc := schema.Node{
+		Type: schema.NodeTypeDoc,
+		Content: []schema.Node{
+			testutil.Paragraph(testutil.Text("Первый  "), testutil.Text("жирный", schema.MarkTypeBold, schema.MarkTypeItalic), testutil.Text(" & конец")),
+			{Type: schema.NodeTypeHeading, Attrs: map[string]any{"level": 1}, Content: []schema.Node{testutil.Text("Заголовок")}},
+			{Type: schema.NodeTypeBulletList, Content: []schema.Node{
+				testutil.Item(testutil.Paragraph(testutil.Text("первый"))),
+				testutil.Item(
+					testutil.Paragraph(testutil.Text("второй")),
+					schema.Node{Type: schema.NodeTypeOrderedList, Content: []schema.Node{
+						testutil.Item(testutil.Paragraph(testutil.Text("вложенный"))),
+					}},
+				),
+			}},
+			{Type: schema.NodeTypeBlockquote, Content: []schema.Node{testutil.Text("цитата")}},
+			{Type: schema.NodeTypeCodeBlock, Content: []schema.Node{testutil.Text("a\n  b")}},
+			testutil.Image(imagePath),
+			{Type: schema.NodeTypeHorizontalRule},
+			{Type: schema.NodeTypeTable, Content: []schema.Node{
+				{Type: schema.NodeTypeTableRow, Content: []schema.Node{
+					{Type: schema.NodeTypeTableHeader, Content: []schema.Node{testutil.Text("Имя")}},
+					{Type: schema.NodeTypeTableHeader, Content: []schema.Node{testutil.Text("Значение")}},
+				}},
+				{Type: schema.NodeTypeTableRow, Content: []schema.Node{
+					{Type: schema.NodeTypeTableCell, Content: []schema.Node{testutil.Text("ячейка")}},
+				}},
+			}},
+			{Type: schema.NodeTypePoem, Content: []schema.Node{
+				testutil.Paragraph(testutil.Text("строка"), schema.Node{Type: schema.NodeTypeHardBreak}, testutil.Text("вторая")),
+			}},
+		},
+	}
+	book := NewBuilder()
+	book.SetMetadata(base.Metadata{Title: "Книга", Authors: []string{"Автор"}, Genres: []string{"Фэнтези"}})
+
+	if err := book.PushParagraph("text"); err == nil {
+		t.Errorf("PushParagraph() without chapter expected error")
+	}
+	for _, chapter := range []string{"Глава 1", "Глава 2"} {
+		if err := book.PushChapter(chapter); err != nil {
+			t.Fatal(err)
+		}
+		if err := nodehandler.PushBlock(book, RenderInline, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(dir, "book.odt")
+	if err := book.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := reader.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("Build() first entry = %s (method %d); want stored mimetype", first.Name, first.Method)
+	}
+	reader.Close()
+
+	files := testutil.ReadArchive(t, output)
+	content := files["content.xml"]
+
+	expected := []string{
+		`<table:table-header-rows>`,
+		`<text:p text:style-name="Table_20_Heading">Имя</text:p>`,
+		`<table:table-cell table:style-name="Table_Cell" office:value-type="string"><text:p text:style-name="Table_20_Contents"></text:p></table:table-cell>`,
+		`<text:p text:style-name="Verse">строка<text:line-break/>вторая</text:p>`,
+		`<text:h text:style-name="Heading_20_1_Break" text:outline-level="1">Глава 2</text:h>`,
+		`<text:h text:style-name="Heading_20_2" text:outline-level="2">Заголовок</text:h>`,
+		`<text:p text:style-name="Text_20_body">Первый <text:s text:c="1"/>` +
+			`<text:span text:style-name="Strong_20_Emphasis"><text:span text:style-name="Emphasis">жирный</text:span></text:span> &amp; конец</text:p>`,
+		`<text:list text:style-name="List_20_1">`,
+		`<text:list-item>` + "\n" + `<text:p text:style-name="List_20_Paragraph">второй</text:p>` + "\n" + `<text:list text:style-name="Numbering_20_123">`,
+		`<text:p text:style-name="Quotations">цитата</text:p>`,
+		`<text:p text:style-name="Preformatted_20_Text">a<text:line-break/><text:s/><text:s/>b</text:p>`,
+		`<text:p text:style-name="Horizontal_20_Line"/>`,
+		`<draw:frame draw:name="Image2"`,
+		`xlink:href="Pictures/image0001.png"`,
+	}
+	for _, fragment := range expected {
+		if !strings.Contains(content, fragment) {
+			t.Errorf("content.xml does not contain %q", fragment)
+		}
+	}
+	if !strings.Contains(files["META-INF/manifest.xml"], `manifest:full-path="Pictures/image0001.png" manifest:media-type="image/png"`) {
+		t.Errorf("manifest.xml does not list images")
+	}
+	if _, found := files["Pictures/image0002.png"]; found {
+		t.Errorf("Build() embedded the same image twice")
+	}
+	if !strings.Contains(files["meta.xml"], "<meta:keyword>Фэнтези</meta:keyword>") {
+		t.Errorf("meta.xml does not contain keywords")
+	}
+}
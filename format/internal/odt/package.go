@@ -0,0 +1,261 @@
+package odt
+
+import (
+	"fmt"
+	"html"
+	"ranobedl/format/internal/office"
+	"strings"
+	"time"
+)
+
+const defaultLanguage = "ru"
+const mimetype = "application/vnd.oasis.opendocument.text"
+const namespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" ` +
+	`xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" ` +
+	`xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0" ` +
+	`xmlns:xlink="http://www.w3.org/1999/xlink" ` +
+	`xmlns:dc="http://purl.org/dc/elements/1.1/" ` +
+	`xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0"`
+const stylesXml = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles ` + namespaces + ` office:version="1.3">
+  <office:styles>
+    <style:default-style style:family="paragraph">
+      <style:paragraph-properties fo:orphans="2" fo:widows="2"/>
+      <style:text-properties fo:font-family="'Liberation Serif', 'Times New Roman', serif" fo:font-size="12pt" fo:language="%s" fo:country="none"/>
+    </style:default-style>
+    <style:style style:name="Standard" style:family="paragraph" style:class="text"/>
+    <style:style style:name="Text_20_body" style:display-name="Text body" style:family="paragraph" style:parent-style-name="Standard" style:class="text">
+      <style:paragraph-properties fo:margin-top="0mm" fo:margin-bottom="0mm" fo:text-indent="7.5mm" fo:text-align="justify" fo:line-height="115%%"/>
+    </style:style>
+    <style:style style:name="Title" style:family="paragraph" style:parent-style-name="Standard" style:next-style-name="Subtitle" style:class="chapter">
+      <style:paragraph-properties fo:margin-top="60mm" fo:margin-bottom="10mm" fo:text-align="center"/>
+      <style:text-properties fo:font-size="24pt" fo:font-weight="bold"/>
+    </style:style>
+    <style:style style:name="Subtitle" style:family="paragraph" style:parent-style-name="Standard" style:next-style-name="Text_20_body" style:class="chapter">
+      <style:paragraph-properties fo:margin-bottom="10mm" fo:text-align="center"/>
+      <style:text-properties fo:font-size="14pt" fo:font-style="italic"/>
+    </style:style>
+    <style:style style:name="Heading" style:family="paragraph" style:parent-style-name="Standard" style:next-style-name="Text_20_body" style:class="text">
+      <style:paragraph-properties fo:margin-top="5mm" fo:margin-bottom="3mm" fo:keep-with-next="always"/>
+      <style:text-properties fo:font-weight="bold"/>
+    </style:style>
+    <style:style style:name="Heading_20_1" style:display-name="Heading 1" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="1" style:class="text">
+      <style:paragraph-properties fo:margin-top="10mm" fo:margin-bottom="8mm" fo:text-align="center"/>
+      <style:text-properties fo:font-size="18pt"/>
+    </style:style>
+    <style:style style:name="Heading_20_2" style:display-name="Heading 2" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="2" style:class="text">
+      <style:paragraph-properties fo:margin-top="8mm" fo:margin-bottom="6mm" fo:text-align="center"/>
+      <style:text-properties fo:font-size="16pt"/>
+    </style:style>
+    <style:style style:name="Heading_20_3" style:display-name="Heading 3" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="3" style:class="text">
+      <style:text-properties fo:font-size="14pt"/>
+    </style:style>
+    <style:style style:name="Heading_20_4" style:display-name="Heading 4" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="4" style:class="text">
+      <style:text-properties fo:font-size="13pt"/>
+    </style:style>
+    <style:style style:name="Heading_20_5" style:display-name="Heading 5" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="5" style:class="text">
+      <style:text-properties fo:font-style="italic"/>
+    </style:style>
+    <style:style style:name="Heading_20_6" style:display-name="Heading 6" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="6" style:class="text">
+      <style:text-properties fo:font-style="italic" fo:font-weight="normal"/>
+    </style:style>
+    <style:style style:name="Quotations" style:family="paragraph" style:parent-style-name="Text_20_body" style:class="html">
+      <style:paragraph-properties fo:margin-left="10mm" fo:margin-right="10mm" fo:margin-top="2mm" fo:margin-bottom="2mm" fo:text-indent="0mm"/>
+      <style:text-properties fo:font-style="italic"/>
+    </style:style>
+    <style:style style:name="List_20_Paragraph" style:display-name="List Paragraph" style:family="paragraph" style:parent-style-name="Text_20_body" style:class="list">
+      <style:paragraph-properties fo:text-indent="0mm"/>
+    </style:style>
+    <style:style style:name="Preformatted_20_Text" style:display-name="Preformatted Text" style:family="paragraph" style:parent-style-name="Standard" style:class="html">
+      <style:paragraph-properties fo:margin-top="2mm" fo:margin-bottom="2mm" fo:background-color="#f2f2f2"/>
+      <style:text-properties fo:font-family="'Liberation Mono', 'Courier New', monospace" style:font-pitch="fixed" fo:font-size="10pt"/>
+    </style:style>
+    <style:style style:name="Horizontal_20_Line" style:display-name="Horizontal Line" style:family="paragraph" style:parent-style-name="Standard" style:next-style-name="Text_20_body" style:class="html">
+      <style:paragraph-properties fo:margin-left="60mm" fo:margin-right="60mm" fo:margin-top="2mm" fo:margin-bottom="5mm" fo:border-bottom="0.5pt solid #000000" fo:padding="0mm"/>
+      <style:text-properties fo:font-size="6pt"/>
+    </style:style>
+    <style:style style:name="Picture" style:family="paragraph" style:parent-style-name="Standard" style:class="extra">
+      <style:paragraph-properties fo:margin-top="2mm" fo:margin-bottom="2mm" fo:text-align="center"/>
+    </style:style>
+    <style:style style:name="Table_20_Contents" style:display-name="Table Contents" style:family="paragraph" style:parent-style-name="Standard" style:class="extra">
+      <style:paragraph-properties fo:text-align="start"/>
+    </style:style>
+    <style:style style:name="Table_20_Heading" style:display-name="Table Heading" style:family="paragraph" style:parent-style-name="Table_20_Contents" style:class="extra">
+      <style:paragraph-properties fo:text-align="center"/>
+      <style:text-properties fo:font-weight="bold"/>
+    </style:style>
+    <style:style style:name="Verse" style:family="paragraph" style:parent-style-name="Standard" style:class="text">
+      <style:paragraph-properties fo:margin-left="20mm" fo:margin-top="2mm" fo:margin-bottom="2mm" fo:text-align="start"/>
+    </style:style>
+    <style:style style:name="Strong_20_Emphasis" style:display-name="Strong Emphasis" style:family="text">
+      <style:text-properties fo:font-weight="bold"/>
+    </style:style>
+    <style:style style:name="Emphasis" style:family="text">
+      <style:text-properties fo:font-style="italic"/>
+    </style:style>
+    <style:style style:name="Underline" style:family="text">
+      <style:text-properties style:text-underline-style="solid" style:text-underline-width="auto" style:text-underline-color="font-color"/>
+    </style:style>
+    <style:style style:name="Strikethrough" style:family="text">
+      <style:text-properties style:text-line-through-style="solid" style:text-line-through-type="single"/>
+    </style:style>
+    <style:style style:name="Source_20_Text" style:display-name="Source Text" style:family="text">
+      <style:text-properties fo:font-family="'Liberation Mono', 'Courier New', monospace" style:font-pitch="fixed"/>
+    </style:style>
+    <text:list-style style:name="List_20_1" style:display-name="List 1">%s
+    </text:list-style>
+    <text:list-style style:name="Numbering_20_123" style:display-name="Numbering 123">%s
+    </text:list-style>
+  </office:styles>
+  <office:automatic-styles>
+    <style:page-layout style:name="Page">
+      <style:page-layout-properties fo:page-width="210mm" fo:page-height="297mm" style:print-orientation="portrait" fo:margin-top="20mm" fo:margin-bottom="20mm" fo:margin-left="20mm" fo:margin-right="20mm"/>
+    </style:page-layout>
+  </office:automatic-styles>
+  <office:master-styles>
+    <style:master-page style:name="Standard" style:page-layout-name="Page"/>
+  </office:master-styles>
+</office:document-styles>
+`
+
+var bullets = []string{"•", "◦", "▪"}
+
+func imageHref(index int, image office.Image) string {
+	return fmt.Sprintf("Pictures/image%04d%s", index, image.Extension())
+}
+func pageBreakStyle(level int) string {
+	return fmt.Sprintf("Heading_20_%d_Break", level)
+}
+func (self *builder) language() string {
+	if self.metadata.Language != "" {
+		return self.metadata.Language
+	}
+	return defaultLanguage
+}
+func renderListLevels(ordered bool) string {
+	var output strings.Builder
+
+	for level := 1; level <= 10; level++ {
+		indent := fmt.Sprintf(
+			`<style:list-level-properties text:list-level-position-and-space-mode="label-alignment">`+
+				`<style:list-level-label-alignment text:label-followed-by="listtab" text:list-tab-stop-position="%dmm" fo:text-indent="-6mm" fo:margin-left="%dmm"/>`+
+				`</style:list-level-properties>`,
+			level*10, level*10,
+		)
+		if ordered {
+			fmt.Fprintf(
+				&output, "\n      <text:list-level-style-number text:level=\"%d\" style:num-suffix=\".\" style:num-format=\"1\">%s</text:list-level-style-number>",
+				level, indent,
+			)
+		} else {
+			fmt.Fprintf(
+				&output, "\n      <text:list-level-style-bullet text:level=\"%d\" text:bullet-char=\"%s\">%s</text:list-level-style-bullet>",
+				level, bullets[(level-1)%len(bullets)], indent,
+			)
+		}
+	}
+	return output.String()
+}
+func (self *builder) renderStyles() string {
+	return fmt.Sprintf(stylesXml, html.EscapeString(self.language()), renderListLevels(false), renderListLevels(true))
+}
+func (self *builder) renderContent(titlePage string) string {
+	var output strings.Builder
+
+	output.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	output.WriteString("<office:document-content " + namespaces + ` office:version="1.3">` + "\n")
+	output.WriteString("<office:automatic-styles>\n")
+	output.WriteString(`<style:style style:name="Title_Break" style:family="paragraph" style:parent-style-name="Title">` +
+		`<style:paragraph-properties fo:break-before="page"/></style:style>` + "\n")
+
+	for level := 1; level <= 2; level++ {
+		fmt.Fprintf(
+			&output, `<style:style style:name="%s" style:family="paragraph" style:parent-style-name="Heading_20_%d">`+
+				`<style:paragraph-properties fo:break-before="page"/></style:style>`+"\n",
+			pageBreakStyle(level), level,
+		)
+	}
+	output.WriteString(`<style:style style:name="Table_Cell" style:family="table-cell">` +
+		`<style:table-cell-properties fo:padding="1mm" fo:border="0.5pt solid #000000"/></style:style>` + "\n")
+	output.WriteString("</office:automatic-styles>\n")
+	output.WriteString("<office:body><office:text>\n")
+	output.WriteString(titlePage)
+	output.WriteString(self.body.String())
+	output.WriteString("</office:text></office:body>\n</office:document-content>\n")
+	return output.String()
+}
+func (self *builder) renderMeta() string {
+	var output strings.Builder
+	metadata := self.metadata
+
+	output.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	output.WriteString("<office:document-meta " + namespaces + ` office:version="1.3">` + "\n<office:meta>\n")
+	output.WriteString("  <meta:generator>ranobedl</meta:generator>\n")
+	fmt.Fprintf(&output, "  <dc:title>%s</dc:title>\n", html.EscapeString(metadata.Title))
+
+	if metadata.Annotation != "" {
+		fmt.Fprintf(&output, "  <dc:description>%s</dc:description>\n", html.EscapeString(metadata.Annotation))
+	}
+	if metadata.Series != "" {
+		fmt.Fprintf(&output, "  <dc:subject>%s</dc:subject>\n", html.EscapeString(metadata.Series))
+	}
+	for _, keyword := range append(append([]string{}, metadata.Genres...), metadata.Tags...) {
+		fmt.Fprintf(&output, "  <meta:keyword>%s</meta:keyword>\n", html.EscapeString(keyword))
+	}
+	if len(metadata.Authors) != 0 {
+		authors := html.EscapeString(strings.Join(metadata.Authors, ", "))
+		fmt.Fprintf(&output, "  <meta:initial-creator>%s</meta:initial-creator>\n  <dc:creator>%s</dc:creator>\n", authors, authors)
+	}
+	fmt.Fprintf(&output, "  <meta:creation-date>%s</meta:creation-date>\n", time.Now().UTC().Format("2006-01-02T15:04:05"))
+	fmt.Fprintf(&output, "  <dc:language>%s</dc:language>\n", html.EscapeString(self.language()))
+	output.WriteString("</office:meta>\n</office:document-meta>\n")
+	return output.String()
+}
+func (self *builder) renderManifest() string {
+	var output strings.Builder
+
+	output.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	output.WriteString(`<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.3">` + "\n")
+	fmt.Fprintf(&output, "  <manifest:file-entry manifest:full-path=\"/\" manifest:version=\"1.3\" manifest:media-type=\"%s\"/>\n", mimetype)
+
+	for _, name := range []string{"content.xml", "styles.xml", "meta.xml"} {
+		fmt.Fprintf(&output, "  <manifest:file-entry manifest:full-path=\"%s\" manifest:media-type=\"text/xml\"/>\n", name)
+	}
+	for index, image := range self.images {
+		fmt.Fprintf(
+			&output, "  <manifest:file-entry manifest:full-path=\"%s\" manifest:media-type=\"%s\"/>\n",
+			imageHref(index+1, image), image.MediaType(),
+		)
+	}
+	output.WriteString("</manifest:manifest>\n")
+	return output.String()
+}
+func (self *builder) writeContent(archive *office.Archive, titlePage string) error {
+	if err := archive.Store("mimetype", mimetype); err != nil {
+		return err
+	}
+	files := []struct {
+		Name    string
+		Content string
+	}{
+		{"content.xml", self.renderContent(titlePage)},
+		{"styles.xml", self.renderStyles()},
+		{"meta.xml", self.renderMeta()},
+		{"META-INF/manifest.xml", self.renderManifest()},
+	}
+	for _, file := range files {
+		if err := archive.WriteString(file.Name, file.Content); err != nil {
+			return err
+		}
+	}
+	for index, image := range self.images {
+		if err := archive.WriteImage(imageHref(index+1, image), image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
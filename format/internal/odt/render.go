@@ -0,0 +1,95 @@
+package odt
+
+import (
+	"fmt"
+	"html"
+	"ranobedl/schema"
+	"strings"
+)
+
+const imagePlaceholder = "[Иллюстрация]"
+
+func escapeText(text string) string {
+	var output strings.Builder
+	spaces := 0
+
+	flush := func() {
+		if spaces > 0 {
+			output.WriteString(" ")
+		}
+		if spaces > 1 {
+			fmt.Fprintf(&output, `<text:s text:c="%d"/>`, spaces-1)
+		}
+		spaces = 0
+	}
+	for _, char := range text {
+		switch char {
+		case ' ', '\n', '\r':
+			spaces++
+		case '\t':
+			flush()
+			output.WriteString("<text:tab/>")
+		default:
+			flush()
+			output.WriteString(html.EscapeString(string(char)))
+		}
+	}
+	flush()
+	return output.String()
+}
+func markStyle(mark schema.Mark) string {
+	switch mark.Type {
+	case schema.MarkTypeBold:
+		return "Strong_20_Emphasis"
+	case schema.MarkTypeItalic:
+		return "Emphasis"
+	case schema.MarkTypeUnderline:
+		return "Underline"
+	case schema.MarkTypeStrike:
+		return "Strikethrough"
+	case schema.MarkTypeCode:
+		return "Source_20_Text"
+	default:
+		panic(fmt.Sprintf("Undefined MarkType: %d", mark.Type))
+	}
+}
+func renderText(node schema.Node) (string, error) {
+	output := escapeText(node.Text)
+
+	for index := len(node.Marks) - 1; index >= 0; index-- {
+		mark := node.Marks[index]
+
+		if mark.Type != schema.MarkTypeLink {
+			output = fmt.Sprintf(`<text:span text:style-name="%s">%s</text:span>`, markStyle(mark), output)
+		} else if href, err := mark.LinkHref(); err != nil {
+			return "", err
+		} else {
+			output = fmt.Sprintf(`<text:a xlink:type="simple" xlink:href="%s">%s</text:a>`, html.EscapeString(href), output)
+		}
+	}
+	return output, nil
+}
+func renderInline(node schema.Node) (string, error) {
+	switch node.Type {
+	case schema.NodeTypeText:
+		return renderText(node)
+	case schema.NodeTypeHardBreak:
+		return "<text:line-break/>", nil
+	case schema.NodeTypeImage:
+		return imagePlaceholder, nil
+	default:
+		panic("Unreachable code")
+	}
+}
+func RenderInline(node []schema.Node) (string, error) {
+	output := ""
+
+	for _, child := range node {
+		if rendered, err := renderInline(child); err != nil {
+			return "", err
+		} else {
+			output += rendered
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,53 @@
+package office
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+)
+
+type Archive struct {
+	file   *os.File
+	writer *zip.Writer
+}
+
+func CreateArchive(filename string) (*Archive, error) {
+	if file, err := os.Create(filename); err != nil {
+		return nil, err
+	} else {
+		return &Archive{file: file, writer: zip.NewWriter(file)}, nil
+	}
+}
+func (self *Archive) Store(name string, content string) error {
+	if file, err := self.writer.CreateHeader(&zip.FileHeader{
+		Name:   name,
+		Method: zip.Store,
+	}); err != nil {
+		return err
+	} else {
+		_, err := io.WriteString(file, content)
+		return err
+	}
+}
+func (self *Archive) WriteString(name string, content string) error {
+	if file, err := self.writer.Create(name); err != nil {
+		return err
+	} else {
+		_, err := io.WriteString(file, content)
+		return err
+	}
+}
+func (self *Archive) WriteImage(name string, image Image) error {
+	if file, err := self.writer.Create(name); err != nil {
+		return err
+	} else {
+		return image.Write(file)
+	}
+}
+func (self *Archive) Close() error {
+	if err := self.writer.Close(); err != nil {
+		self.file.Close()
+		return err
+	}
+	return self.file.Close()
+}
@@ -0,0 +1,90 @@
+package office
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	base "ranobedl/format/internal/builder"
+
+	_ "github.com/gen2brain/avif"
+	_ "golang.org/x/image/webp"
+)
+
+const pixelsPerMillimeter = 96 / 25.4
+
+type Image struct {
+	Path   string
+	Format string
+	Width  int
+	Height int
+}
+
+func NewImage(path string) (Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Image{}, err
+	}
+	defer file.Close()
+
+	if config, format, err := image.DecodeConfig(file); err != nil {
+		return Image{}, fmt.Errorf("%w %s: %w", base.ErrUnsupportedImage, path, err)
+	} else {
+		return Image{
+			Path:   path,
+			Format: format,
+			Width:  config.Width,
+			Height: config.Height,
+		}, nil
+	}
+}
+func (self Image) native() bool {
+	return self.Format == "jpeg" || self.Format == "png" || self.Format == "gif"
+}
+func (self Image) Extension() string {
+	switch {
+	case self.Format == "jpeg":
+		return ".jpg"
+	case self.native():
+		return "." + self.Format
+	default:
+		return ".png"
+	}
+}
+func (self Image) MediaType() string {
+	return "image/" + map[string]string{".jpg": "jpeg", ".png": "png", ".gif": "gif"}[self.Extension()]
+}
+func (self Image) Size(maxWidth float64, maxHeight float64) (float64, float64) {
+	width := float64(self.Width) / pixelsPerMillimeter
+	height := float64(self.Height) / pixelsPerMillimeter
+
+	if width > maxWidth {
+		height *= maxWidth / width
+		width = maxWidth
+	}
+	if height > maxHeight {
+		width *= maxHeight / height
+		height = maxHeight
+	}
+	return width, height
+}
+func (self Image) Write(writer io.Writer) error {
+	source, err := os.Open(self.Path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	if self.native() {
+		_, err := io.Copy(writer, source)
+		return err
+	}
+	if decoded, _, err := image.Decode(source); err != nil {
+		return fmt.Errorf("%w %s: %w", base.ErrUnsupportedImage, self.Path, err)
+	} else {
+		return png.Encode(writer, decoded)
+	}
+}
@@ -0,0 +1,612 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	base "ranobedl/format/internal/builder"
+	"strings"
+)
+
+const lineSpacing = 1.4
+const pointsPerPixel = 0.75
+
+type alignment int
+
+const (
+	alignJustify alignment = iota
+	alignLeft
+	alignCenter
+)
+
+type Config struct {
+	PageWidth  float64
+	PageHeight float64
+	Margin     float64
+	FontSize   float64
+	FontPath   string
+}
+type textStyle struct {
+	Size   float64
+	Bold   bool
+	Italic bool
+	Mono   bool
+}
+type blockStyle struct {
+	textStyle
+	Align  alignment
+	Indent float64
+	Before float64
+	After  float64
+}
+type container struct {
+	Left   float64
+	Right  float64
+	Italic bool
+	Marker string
+}
+type list struct {
+	Ordered bool
+	Index   int
+}
+type builder struct {
+	config     Config
+	metadata   base.Metadata
+	fonts      map[fontStyle]*pdfFont
+	fontList   []*pdfFont
+	images     map[string]*pdfImage
+	imageList  []*pdfImage
+	pages      []*page
+	y          float64
+	outline    []*outlineItem
+	volume     *outlineItem
+	containers []container
+	lists      []list
+	table      [][]tableCell
+	started    bool
+	hasChapter bool
+}
+
+func NewBuilder(config Config) (*builder, error) {
+	paths, err := findFonts(config.FontPath)
+	if err != nil {
+		return nil, err
+	}
+	self := &builder{
+		config: config,
+		fonts:  map[fontStyle]*pdfFont{},
+		images: map[string]*pdfImage{},
+	}
+	loaded := map[string]*pdfFont{}
+
+	for _, style := range []fontStyle{styleRegular, styleBold, styleItalic, styleBoldItalic, styleMono} {
+		path, found := paths[style]
+		load := loadFont
+
+		if len(paths) == 0 || !found && style == styleMono {
+			path, load = bundledFamily[style], loadBundledFont
+		} else if !found {
+			continue
+		}
+		if _, found := loaded[path]; !found {
+			if font, err := load(path); err != nil {
+				return nil, err
+			} else {
+				font.Name = fmt.Sprintf("F%d", len(self.fontList)+1)
+				loaded[path] = font
+				self.fontList = append(self.fontList, font)
+			}
+		}
+		self.fonts[style] = loaded[path]
+	}
+	return self, nil
+}
+func (self *builder) missingGlyphs() error {
+	for _, font := range self.fontList {
+		if err := font.Missing(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (self *builder) SetMetadata(metadata base.Metadata) {
+	self.metadata = metadata
+}
+func (self *builder) fontFor(bold bool, italic bool, mono bool) *pdfFont {
+	candidates := []fontStyle{styleRegular}
+
+	switch {
+	case mono:
+		candidates = []fontStyle{styleMono, styleRegular}
+	case bold && italic:
+		candidates = []fontStyle{styleBoldItalic, styleBold, styleItalic, styleRegular}
+	case bold:
+		candidates = []fontStyle{styleBold, styleRegular}
+	case italic:
+		candidates = []fontStyle{styleItalic, styleRegular}
+	}
+	for _, style := range candidates {
+		if font, found := self.fonts[style]; found {
+			return font
+		}
+	}
+	panic("Unreachable")
+}
+func (self *builder) contentWidth() float64 {
+	return self.config.PageWidth - 2*self.config.Margin
+}
+func (self *builder) contentHeight() float64 {
+	return self.config.PageHeight - 2*self.config.Margin
+}
+func (self *builder) page() *page {
+	return self.pages[len(self.pages)-1]
+}
+func (self *builder) finishPage() error {
+	if len(self.pages) == 0 {
+		return nil
+	}
+	current := self.page()
+
+	if current.Numbered {
+		label := fmt.Sprint(len(self.pages))
+		font := self.fontFor(false, false, false)
+		size := self.config.FontSize * 0.8
+		x := (self.config.PageWidth - font.Width(label, size)) / 2
+
+		current.Printf(
+			"BT /%s %s Tf 1 0 0 1 %s %s Tm %s Tj ET\n",
+			font.Name, number(size), number(x), number(self.config.Margin/2), font.Encode(label),
+		)
+	}
+	return current.Finish()
+}
+func (self *builder) newPage(numbered bool) error {
+	if err := self.finishPage(); err != nil {
+		return err
+	}
+	self.pages = append(self.pages, &page{Numbered: numbered})
+	self.y = 0
+	return nil
+}
+func (self *builder) startPage() error {
+	if len(self.pages) != 0 && !self.page().HasBody {
+		self.y = 0
+		return nil
+	}
+	return self.newPage(true)
+}
+func (self *builder) ensureSpace(height float64) error {
+	if self.y > 0 && self.y+height > self.contentHeight() {
+		return self.newPage(true)
+	}
+	return nil
+}
+func (self *builder) bounds() (float64, float64, bool) {
+	left, right, italic := 0.0, 0.0, false
+
+	for _, current := range self.containers {
+		left += current.Left
+		right += current.Right
+		italic = italic || current.Italic
+	}
+	return self.config.Margin + left, self.contentWidth() - left - right, italic
+}
+func (self *builder) takeMarker() string {
+	for index := len(self.containers) - 1; index >= 0; index-- {
+		if marker := self.containers[index].Marker; marker != "" {
+			self.containers[index].Marker = ""
+			return marker
+		}
+	}
+	return ""
+}
+func (self *builder) baseline(size float64) float64 {
+	return self.config.PageHeight - self.config.Margin - self.y - size*(lineSpacing+0.6)/2
+}
+func (self *builder) drawLine(current line, x float64, width float64, size float64, align alignment) {
+	spaceWidth := self.fontFor(false, false, false).Width(" ", size)
+	gap := spaceWidth
+	baseline := self.baseline(size)
+
+	switch {
+	case align == alignCenter:
+		x += (width - current.Width) / 2
+	case align == alignJustify && !current.Last && len(current.Words) > 1:
+		if extra := (width - current.Width) / float64(len(current.Words)-1); extra < spaceWidth*3 {
+			gap += extra
+		}
+	}
+	var decorations strings.Builder
+	output := self.page()
+
+	output.Printf("BT\n")
+	for wordIndex, item := range current.Words {
+		for index, part := range item.Fragments {
+			text := part.Text
+
+			if wordIndex < len(current.Words)-1 && index == len(item.Fragments)-1 {
+				text += " "
+			}
+			output.Printf(
+				"/%s %s Tf 1 0 0 1 %s %s Tm %s Tj\n",
+				part.Font.Name, number(part.Size), number(x), number(baseline), part.Font.Encode(text),
+			)
+			if part.Underline {
+				fmt.Fprintf(&decorations, "%s %s %s %s re f\n",
+					number(x), number(baseline-part.Size*0.12), number(part.Width), number(part.Size*0.05))
+			}
+			if part.Strike {
+				fmt.Fprintf(&decorations, "%s %s %s %s re f\n",
+					number(x), number(baseline+part.Size*0.28), number(part.Width), number(part.Size*0.05))
+			}
+			x += part.Width
+		}
+		x += gap
+	}
+	output.Printf("ET\n%s", decorations.String())
+}
+func (self *builder) drawMarker(marker string, x float64, size float64) {
+	font := self.fontFor(false, false, false)
+	x -= font.Width(marker, size) + size*0.4
+
+	self.page().Printf(
+		"BT /%s %s Tf 1 0 0 1 %s %s Tm %s Tj ET\n",
+		font.Name, number(size), number(x), number(self.baseline(size)), font.Encode(marker),
+	)
+}
+func (self *builder) drawText(runs []run, style blockStyle) error {
+	x, width, italic := self.bounds()
+	style.Italic = style.Italic || italic
+
+	spaceWidth := self.fontFor(false, false, false).Width(" ", style.Size)
+	lineHeight := style.Size * lineSpacing
+	lines := breakLines(self.splitWords(runs, style.textStyle), width-style.Indent, width, spaceWidth)
+	marker := self.takeMarker()
+
+	if self.y > 0 {
+		self.y += style.Before
+	}
+	for index, current := range lines {
+		if err := self.ensureSpace(lineHeight); err != nil {
+			return err
+		}
+		if index == 0 {
+			if marker != "" {
+				self.drawMarker(marker, x, style.Size)
+			}
+			self.drawLine(current, x+style.Indent, width-style.Indent, style.Size, style.Align)
+		} else {
+			self.drawLine(current, x, width, style.Size, style.Align)
+		}
+		self.y += lineHeight
+	}
+	self.y += style.After
+	return self.missingGlyphs()
+}
+func (self *builder) drawTitle(text string, size float64) error {
+	return self.drawText([]run{{Text: text}}, blockStyle{
+		textStyle: textStyle{Size: size, Bold: true},
+		Align:     alignCenter,
+		After:     self.config.FontSize * 1.5,
+	})
+}
+func (self *builder) image(path string) (*pdfImage, error) {
+	if cached, found := self.images[path]; found {
+		return cached, nil
+	}
+	current, err := newImage(fmt.Sprintf("Im%d", len(self.imageList)+1), path)
+	if err != nil {
+		return nil, err
+	}
+	self.images[path] = current
+	self.imageList = append(self.imageList, current)
+	return current, nil
+}
+func (self *builder) drawImage(current *pdfImage, x float64, top float64, width float64, height float64) {
+	y := self.config.PageHeight - top - height
+
+	self.page().Printf(
+		"q %s 0 0 %s %s %s cm /%s Do Q\n",
+		number(width), number(height), number(x), number(y), current.Name,
+	)
+}
+func (self *builder) fit(current *pdfImage, maxWidth float64, maxHeight float64, scale float64) (float64, float64) {
+	width := float64(current.Width) * scale
+	height := float64(current.Height) * scale
+
+	if width > maxWidth {
+		height *= maxWidth / width
+		width = maxWidth
+	}
+	if height > maxHeight {
+		width *= maxHeight / height
+		height = maxHeight
+	}
+	return width, height
+}
+func (self *builder) pushCover() error {
+	current, err := self.image(self.metadata.CoverPath)
+	if errors.Is(err, base.ErrUnsupportedImage) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := self.newPage(false); err != nil {
+		return err
+	}
+	width, height := self.fit(current, self.config.PageWidth, self.config.PageHeight, 1000)
+
+	self.drawImage(
+		current,
+		(self.config.PageWidth-width)/2,
+		(self.config.PageHeight-height)/2,
+		width, height,
+	)
+	return nil
+}
+func (self *builder) pushTitlePage() error {
+	if err := self.newPage(true); err != nil {
+		return err
+	}
+	size := self.config.FontSize
+	self.y = self.contentHeight() / 4
+
+	if err := self.drawTitle(self.metadata.Title, size*2); err != nil {
+		return err
+	}
+	if len(self.metadata.Authors) != 0 {
+		if err := self.drawText([]run{{Text: strings.Join(self.metadata.Authors, ", ")}}, blockStyle{
+			textStyle: textStyle{Size: size * 1.2, Italic: true},
+			Align:     alignCenter,
+			After:     size * 2,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, paragraph := range strings.Split(self.metadata.Annotation, "\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph == "" {
+			continue
+		}
+		if err := self.drawText([]run{{Text: paragraph}}, self.paragraphStyle()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (self *builder) start() error {
+	if self.started {
+		return nil
+	}
+	self.started = true
+
+	if self.metadata.CoverPath != "" {
+		if err := self.pushCover(); err != nil {
+			return err
+		}
+	}
+	return self.pushTitlePage()
+}
+func (self *builder) paragraphStyle() blockStyle {
+	style := blockStyle{
+		textStyle: textStyle{Size: self.config.FontSize},
+		Align:     alignJustify,
+		After:     self.config.FontSize * 0.3,
+	}
+	if len(self.containers) == 0 {
+		style.Indent = self.config.FontSize * 1.5
+	}
+	return style
+}
+func (self *builder) pushBlock(text string, style blockStyle) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	if runs, err := decodeRuns(text); err != nil {
+		return err
+	} else {
+		return self.drawText(runs, style)
+	}
+}
+func (self *builder) PushVolume(volumeTitle string) error {
+	if err := self.start(); err != nil {
+		return err
+	}
+	if err := self.newPage(true); err != nil {
+		return err
+	}
+	self.hasChapter = false
+	self.y = self.contentHeight() / 3
+	self.volume = &outlineItem{
+		Title: volumeTitle,
+		Page:  len(self.pages) - 1,
+		Top:   self.config.PageHeight - self.config.Margin,
+	}
+	self.outline = append(self.outline, self.volume)
+	return self.drawTitle(volumeTitle, self.config.FontSize*2)
+}
+func (self *builder) PushChapter(chapterTitle string) error {
+	if err := self.start(); err != nil {
+		return err
+	}
+	if err := self.startPage(); err != nil {
+		return err
+	}
+	self.hasChapter = true
+	self.containers = nil
+	self.lists = nil
+	self.table = nil
+
+	item := &outlineItem{
+		Title: chapterTitle,
+		Page:  len(self.pages) - 1,
+		Top:   self.config.PageHeight - self.config.Margin,
+	}
+	if self.volume != nil {
+		self.volume.Children = append(self.volume.Children, item)
+	} else {
+		self.outline = append(self.outline, item)
+	}
+	return self.drawTitle(chapterTitle, self.config.FontSize*1.5)
+}
+func (self *builder) PushParagraph(text string) error {
+	return self.pushBlock(text, self.paragraphStyle())
+}
+func (self *builder) PushHeading(level int, text string) error {
+	size := self.config.FontSize * max(1.6-0.15*float64(level), 1.05)
+	style := blockStyle{
+		textStyle: textStyle{Size: size, Bold: true},
+		Align:     alignLeft,
+		Before:    size * 0.6,
+		After:     size * 0.4,
+	}
+	if err := self.ensureSpace(size*lineSpacing + self.config.FontSize*lineSpacing*2); err != nil {
+		return err
+	}
+	return self.pushBlock(text, style)
+}
+func (self *builder) PushImage(imagePath string) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	current, err := self.image(imagePath)
+	if err != nil {
+		return err
+	}
+	x, width, _ := self.bounds()
+	spacing := self.config.FontSize * 0.5
+	width, height := self.fit(current, width, self.contentHeight()-2*spacing, pointsPerPixel)
+
+	if err := self.ensureSpace(height + 2*spacing); err != nil {
+		return err
+	}
+	if self.y > 0 {
+		self.y += spacing
+	}
+	_, available, _ := self.bounds()
+	self.takeMarker()
+	self.drawImage(current, x+(available-width)/2, self.config.Margin+self.y, width, height)
+	self.y += height + spacing
+	return nil
+}
+func (self *builder) PushCode(code string) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	size := self.config.FontSize * 0.9
+	runs := []run{}
+
+	for index, codeLine := range strings.Split(strings.TrimRight(code, "\n"), "\n") {
+		if index > 0 {
+			runs = append(runs, run{Break: true})
+		}
+		runs = append(runs, run{Text: strings.ReplaceAll(codeLine, " ", "\u00a0"), Mono: true})
+	}
+	self.containers = append(self.containers, container{Left: self.config.FontSize})
+	defer func() {
+		self.containers = self.containers[:len(self.containers)-1]
+	}()
+	return self.drawText(runs, blockStyle{
+		textStyle: textStyle{Size: size, Mono: true},
+		Align:     alignLeft,
+		Before:    size * 0.3,
+		After:     size * 0.6,
+	})
+}
+func (self *builder) PushHorizontalRule() error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	height := self.config.FontSize * lineSpacing * 2
+
+	if err := self.ensureSpace(height); err != nil {
+		return err
+	}
+	x, width, _ := self.bounds()
+	y := self.config.PageHeight - self.config.Margin - self.y - height/2
+
+	self.page().Printf(
+		"0.5 w %s %s m %s %s l S\n",
+		number(x+width*3/8), number(y), number(x+width*5/8), number(y),
+	)
+	self.y += height
+	return nil
+}
+func (self *builder) BeginList(ordered bool) error {
+	self.lists = append(self.lists, list{Ordered: ordered})
+	return nil
+}
+func (self *builder) BeginListItem() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	current := &self.lists[len(self.lists)-1]
+	current.Index++
+
+	marker := "•"
+	if current.Ordered {
+		marker = fmt.Sprintf("%d.", current.Index)
+	}
+	self.containers = append(self.containers, container{
+		Left:   self.config.FontSize * 1.8,
+		Marker: marker,
+	})
+	return nil
+}
+func (self *builder) EndListItem() error {
+	if len(self.containers) == 0 {
+		return errors.New("List item is not created")
+	}
+	self.containers = self.containers[:len(self.containers)-1]
+	return nil
+}
+func (self *builder) EndList() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	self.lists = self.lists[:len(self.lists)-1]
+	return nil
+}
+func (self *builder) BeginBlockquote() error {
+	self.containers = append(self.containers, container{
+		Left:   self.config.FontSize * 2,
+		Right:  self.config.FontSize * 2,
+		Italic: true,
+	})
+	return nil
+}
+func (self *builder) EndBlockquote() error {
+	if len(self.containers) == 0 {
+		return errors.New("Blockquote is not created")
+	}
+	self.containers = self.containers[:len(self.containers)-1]
+	return nil
+}
+func (self *builder) BeginPoem() error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	if self.y > 0 {
+		self.y += self.config.FontSize * 0.6
+	}
+	self.containers = append(self.containers, container{Left: self.config.FontSize * 3})
+	return nil
+}
+func (self *builder) BeginStanza() error {
+	return nil
+}
+func (self *builder) PushVerse(text string) error {
+	return self.pushBlock(text, blockStyle{
+		textStyle: textStyle{Size: self.config.FontSize},
+		Align:     alignLeft,
+	})
+}
+func (self *builder) EndStanza() error {
+	self.y += self.config.FontSize * 0.6
+	return nil
+}
+func (self *builder) EndPoem() error {
+	if len(self.containers) == 0 {
+		return errors.New("Poem is not created")
+	}
+	self.containers = self.containers[:len(self.containers)-1]
+	return nil
+}
@@ -0,0 +1,243 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/nodehandler"
+	"ranobedl/format/internal/testutil"
+	"ranobedl/schema"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func words(widths ...float64) []word {
+	output := []word{}
+
+	for _, width := range widths {
+		output = append(output, word{Width: width})
+	}
+	return output
+}
+func lineWidths(lines []line) [][]float64 {
+	output := [][]float64{}
+
+	for _, current := range lines {
+		widths := []float64{}
+		for _, item := range current.Words {
+			widths = append(widths, item.Width)
+		}
+		output = append(output, append(widths, current.Width))
+	}
+	return output
+}
+
+func TestBreakLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments [][]word
+		first    float64
+		expected [][]float64
+	}{
+		{"single line", [][]word{words(10, 20)}, 100, [][]float64{{10, 20, 32}}},
+		{"wrap", [][]word{words(40, 40, 40)}, 100, [][]float64{{40, 40, 82}, {40, 40}}},
+		{"first line indent", [][]word{words(40, 40, 40)}, 70, [][]float64{{40, 40}, {40, 40, 82}}},
+		{"hard break", [][]word{words(10), words(10)}, 100, [][]float64{{10, 10}, {10, 10}}},
+		{"empty segment", [][]word{words(10), {}}, 100, [][]float64{{10, 10}, {0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := breakLines(tt.segments, tt.first, 100, 2)
+
+			if result := lineWidths(lines); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("breakLines() = %v; want %v", result, tt.expected)
+			}
+			if !lines[len(lines)-1].Last {
+				t.Errorf("breakLines() last line is not marked as last")
+			}
+		})
+	}
+}
+func newTestBuilder(t *testing.T) *builder {
+	book, err := NewBuilder(Config{
+		PageWidth:  420,
+		PageHeight: 595,
+		Margin:     40,
+		FontSize:   11,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return book
+}
+func writeImage(t *testing.T, path string) {
+	picture := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+
+	for x := range 40 {
+		picture.Set(x, x%30, color.NRGBA{R: 200, A: 128})
+	}
+	var output bytes.Buffer
+	if err := png.Encode(&output, picture); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, output.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+func checkXref(t *testing.T, data []byte) {
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if match == nil {
+		t.Fatalf("Build() output has no trailer")
+	}
+	start, _ := strconv.Atoi(string(match[1]))
+
+	if !bytes.HasPrefix(data[start:], []byte("xref\n")) {
+		t.Fatalf("Build() startxref points to %q", data[start:min(start+10, len(data))])
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[start:], -1)
+
+	for index, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		header := fmt.Sprintf("%d 0 obj\n", index+1)
+
+		if !bytes.HasPrefix(data[offset:], []byte(header)) {
+			t.Errorf("xref entry %d points to %q", index+1, data[offset:min(offset+10, len(data))])
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "image.png")
+	writeImage(t, imagePath)
+
+	long := "Очень длинный абзац, который точно не поместится в одну строку и будет перенесён. "
+	doc := schema.Node{
+		Type: schema.NodeTypeDoc,
+		Content: []schema.Node{
+			testutil.Paragraph(testutil.Text("Первый "), testutil.Text("жирный", schema.MarkTypeBold), testutil.Text(" и "), testutil.Text("зачёркнутый", schema.MarkTypeStrike)),
+			testutil.Paragraph(testutil.Text(long + long + long)),
+			{Type: schema.NodeTypeHeading, Attrs: map[string]any{"level": 2}, Content: []schema.Node{testutil.Text("Заголовок")}},
+			{Type: schema.NodeTypeOrderedList, Content: []schema.Node{
+				testutil.Item(testutil.Paragraph(testutil.Text("первый"))),
+				testutil.Item(testutil.Paragraph(testutil.Text("второй"))),
+			}},
+			{Type: schema.NodeTypeBlockquote, Content: []schema.Node{testutil.Text("цитата")}},
+			{Type: schema.NodeTypeCodeBlock, Content: []schema.Node{testutil.Text("func main() {\n    return\n}")}},
+			testutil.Image(imagePath),
+			{Type: schema.NodeTypeHorizontalRule},
+			{Type: schema.NodeTypeTable, Content: []schema.Node{
+				{Type: schema.NodeTypeTableRow, Content: []schema.Node{
+					{Type: schema.NodeTypeTableHeader, Content: []schema.Node{testutil.Text("Имя")}},
+					{Type: schema.NodeTypeTableHeader, Content: []schema.Node{testutil.Text("Значение")}},
+				}},
+				{Type: schema.NodeTypeTableRow, Content: []schema.Node{
+					{Type: schema.NodeTypeTableCell, Content: []schema.Node{testutil.Text("ячейка")}},
+				}},
+			}},
+			{Type: schema.NodeTypePoem, Content: []schema.Node{
+				testutil.Paragraph(testutil.Text("строка"), schema.Node{Type: schema.NodeTypeHardBreak}, testutil.Text("вторая")),
+			}},
+		},
+	}
+	book := newTestBuilder(t)
+	book.SetMetadata(base.Metadata{
+		Title:      "Книга",
+		Authors:    []string{"Автор"},
+		Annotation: "Аннотация",
+		CoverPath:  imagePath,
+	})
+	if err := book.PushParagraph(`[{"text":"без главы"}]`); err == nil {
+		t.Errorf("PushParagraph() without chapter expected error")
+	}
+	for _, volume := range []string{"Том 1", "Том 2"} {
+		if err := book.PushVolume(volume); err != nil {
+			t.Fatal(err)
+		}
+		for _, chapter := range []string{"Глава 1", "Глава 2"} {
+			if err := book.PushChapter(chapter); err != nil {
+				t.Fatal(err)
+			}
+			if err := nodehandler.PushBlock(book, RenderInline, doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	output := filepath.Join(dir, "book.pdf")
+	if err := book.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-1.7\n")) {
+		t.Errorf("Build() output has no PDF header")
+	}
+	checkXref(t, data)
+
+	pages := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindSubmatch(data)
+	if pages == nil {
+		t.Fatalf("Build() output has no page tree")
+	}
+	if count, _ := strconv.Atoi(string(pages[1])); count != len(book.pages) || count < 8 {
+		t.Errorf("Build() page count = %d; builder has %d pages", count, len(book.pages))
+	}
+	if !bytes.Contains(data, []byte("/Type /Outlines")) || !bytes.Contains(data, []byte("/Count 6 >>")) {
+		t.Errorf("Build() output has no outline with 2 volumes and 4 chapters")
+	}
+	for _, expected := range []string{"/FontFile2", "/ToUnicode", "/SMask", "/Title " + textString("Книга")} {
+		if !bytes.Contains(data, []byte(expected)) {
+			t.Errorf("Build() output does not contain %q", expected)
+		}
+	}
+}
+func TestFonts(t *testing.T) {
+	book := newTestBuilder(t)
+
+	for style, font := range book.fonts {
+		if current := font.glyph('Ж'); current.Index == 0 || current.Width == 0 {
+			t.Errorf("bundled font %d has no Cyrillic glyphs", style)
+		}
+	}
+	if err := book.missingGlyphs(); err != nil {
+		t.Errorf("missingGlyphs() = %v", err)
+	}
+	if err := book.PushChapter("Глава"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.PushParagraph(`[{"text":"漢字"}]`); err == nil {
+		t.Errorf("PushParagraph() with missing glyphs expected error")
+	}
+	if _, err := findFonts("No Such Font"); err == nil {
+		t.Errorf("findFonts() of a missing font expected error")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Custom-Regular.ttf")
+
+	if err := os.WriteFile(path, bundledFonts[bundledFamily[styleRegular]], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if fonts, err := findFonts(path); err != nil {
+		t.Fatal(err)
+	} else if len(fonts) != 1 || fonts[styleRegular] != path {
+		t.Errorf("findFonts() = %v; want only the regular style", fonts)
+	}
+	custom, err := NewBuilder(Config{PageWidth: 420, PageHeight: 595, Margin: 40, FontSize: 11, FontPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.fontFor(true, false, false) != custom.fonts[styleRegular] {
+		t.Errorf("bold text does not fall back to the custom regular font")
+	}
+	if _, found := custom.fonts[styleMono]; !found {
+		t.Errorf("custom font builder has no bundled monospace font")
+	}
+}
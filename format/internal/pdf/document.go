@@ -0,0 +1,151 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func (self *builder) resources(ids map[string]int) string {
+	var output strings.Builder
+
+	output.WriteString("<< /ProcSet [/PDF /Text /ImageB /ImageC] /Font <<")
+	for _, font := range self.fontList {
+		if font.Used() {
+			fmt.Fprintf(&output, " /%s %d 0 R", font.Name, ids[font.Name])
+		}
+	}
+	output.WriteString(" >> /XObject <<")
+	for _, current := range self.imageList {
+		fmt.Fprintf(&output, " /%s %d 0 R", current.Name, ids[current.Name])
+	}
+	output.WriteString(" >> >>")
+	return output.String()
+}
+func (self *builder) info() string {
+	metadata := self.metadata
+	fields := []string{
+		"/Title " + textString(metadata.Title),
+		"/Creator " + textString("ranobedl"),
+		"/Producer " + textString("ranobedl"),
+		"/CreationDate " + textString(time.Now().UTC().Format("D:20060102150405Z")),
+	}
+	if len(metadata.Authors) != 0 {
+		fields = append(fields, "/Author "+textString(strings.Join(metadata.Authors, ", ")))
+	}
+	if metadata.Series != "" {
+		fields = append(fields, "/Subject "+textString(fmt.Sprintf("%s #%d", metadata.Series, metadata.SeriesNumber)))
+	}
+	if keywords := append(append([]string{}, metadata.Genres...), metadata.Tags...); len(keywords) != 0 {
+		fields = append(fields, "/Keywords "+textString(strings.Join(keywords, ", ")))
+	}
+	return "<< " + strings.Join(fields, " ") + " >>"
+}
+func (self *builder) language() string {
+	if self.metadata.Language != "" {
+		return self.metadata.Language
+	}
+	return "ru"
+}
+func (self *builder) write(writer *objectWriter) error {
+	catalogId := writer.Allocate()
+	pagesId := writer.Allocate()
+	resourcesId := writer.Allocate()
+	infoId := writer.Allocate()
+
+	if err := writer.WriteHeader(); err != nil {
+		return err
+	}
+	ids := map[string]int{}
+
+	for _, font := range self.fontList {
+		if font.Used() {
+			ids[font.Name] = writer.Allocate()
+		}
+	}
+	for _, current := range self.imageList {
+		ids[current.Name] = writer.Allocate()
+	}
+	pageIds := make([]int, len(self.pages))
+	kids := make([]string, len(self.pages))
+
+	for index := range self.pages {
+		pageIds[index] = writer.Allocate()
+		kids[index] = fmt.Sprintf("%d 0 R", pageIds[index])
+	}
+	for index, current := range self.pages {
+		contentId := writer.Allocate()
+
+		if err := writer.WriteObject(pageIds[index], fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %d 0 R /Contents %d 0 R >>",
+			pagesId, number(self.config.PageWidth), number(self.config.PageHeight), resourcesId, contentId,
+		)); err != nil {
+			return err
+		}
+		if err := writer.WriteStream(contentId, "/Filter /FlateDecode", current.data); err != nil {
+			return err
+		}
+		current.data = nil
+	}
+	if err := writer.WriteObject(pagesId, fmt.Sprintf(
+		"<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(self.pages),
+	)); err != nil {
+		return err
+	}
+	if err := writer.WriteObject(resourcesId, self.resources(ids)); err != nil {
+		return err
+	}
+	for _, font := range self.fontList {
+		if font.Used() {
+			if err := font.Write(writer, ids[font.Name]); err != nil {
+				return err
+			}
+		}
+	}
+	for _, current := range self.imageList {
+		if err := current.Write(writer, ids[current.Name]); err != nil {
+			return err
+		}
+	}
+	catalog := fmt.Sprintf(
+		"<< /Type /Catalog /Pages %d 0 R /Lang %s /ViewerPreferences << /DisplayDocTitle true >>",
+		pagesId, textString(self.language()),
+	)
+	if len(self.outline) != 0 {
+		outlinesId := writer.Allocate()
+
+		if err := writeOutlines(writer, outlinesId, self.outline, pageIds); err != nil {
+			return err
+		}
+		catalog += fmt.Sprintf(" /Outlines %d 0 R /PageMode /UseOutlines", outlinesId)
+	}
+	if err := writer.WriteObject(catalogId, catalog+" >>"); err != nil {
+		return err
+	}
+	if err := writer.WriteObject(infoId, self.info()); err != nil {
+		return err
+	}
+	return writer.Finish(catalogId, infoId)
+}
+func (self *builder) Build(filename string) error {
+	if err := self.start(); err != nil {
+		return err
+	}
+	if err := self.finishPage(); err != nil {
+		return err
+	}
+	if err := self.missingGlyphs(); err != nil {
+		return err
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := self.write(newObjectWriter(file)); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,235 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+type glyph struct {
+	Index sfnt.GlyphIndex
+	Width float64
+}
+type pdfFont struct {
+	Name       string
+	BaseFont   string
+	data       []byte
+	font       *sfnt.Font
+	buffer     sfnt.Buffer
+	ppem       fixed.Int26_6
+	unitsPerEm float64
+	glyphs     map[rune]glyph
+	used       map[sfnt.GlyphIndex]rune
+	missing    []rune
+}
+
+func loadFont(path string) (*pdfFont, error) {
+	if data, err := os.ReadFile(path); err != nil {
+		return nil, err
+	} else {
+		return parseFont(path, data)
+	}
+}
+func parseFont(path string, data []byte) (*pdfFont, error) {
+	if bytes.HasPrefix(data, []byte("OTTO")) {
+		return nil, fmt.Errorf("Unsupported font %s: only TrueType outlines are supported", path)
+	}
+	parsed, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("Unsupported font %s: %w", path, err)
+	}
+	unitsPerEm := parsed.UnitsPerEm()
+
+	self := &pdfFont{
+		data:       data,
+		font:       parsed,
+		ppem:       fixed.I(int(unitsPerEm)),
+		unitsPerEm: float64(unitsPerEm),
+		glyphs:     map[rune]glyph{},
+		used:       map[sfnt.GlyphIndex]rune{},
+	}
+	self.BaseFont = self.postScriptName()
+	return self, nil
+}
+func (self *pdfFont) postScriptName() string {
+	name, err := self.font.Name(&self.buffer, sfnt.NameIDPostScript)
+
+	if err != nil || strings.TrimSpace(name) == "" {
+		return "Font"
+	}
+	return strings.ReplaceAll(name, " ", "")
+}
+func invisible(char rune) bool {
+	return unicode.IsControl(char) || unicode.Is(unicode.Cf, char)
+}
+func (self *pdfFont) glyph(char rune) glyph {
+	if cached, found := self.glyphs[char]; found {
+		return cached
+	}
+	var result glyph
+
+	if index, err := self.font.GlyphIndex(&self.buffer, char); err == nil {
+		result.Index = index
+	}
+	if result.Index == 0 {
+		self.missing = append(self.missing, char)
+	}
+	if advance, err := self.font.GlyphAdvance(&self.buffer, result.Index, self.ppem, font.HintingNone); err == nil {
+		result.Width = float64(advance) / 64 * 1000 / self.unitsPerEm
+	}
+	self.glyphs[char] = result
+	return result
+}
+func (self *pdfFont) Width(text string, size float64) float64 {
+	width := 0.0
+
+	for _, char := range text {
+		if !invisible(char) {
+			width += self.glyph(char).Width
+		}
+	}
+	return width * size / 1000
+}
+func (self *pdfFont) Encode(text string) string {
+	var output strings.Builder
+
+	output.WriteString("<")
+	for _, char := range text {
+		if invisible(char) {
+			continue
+		}
+		current := self.glyph(char)
+
+		if _, found := self.used[current.Index]; !found {
+			self.used[current.Index] = char
+		}
+		fmt.Fprintf(&output, "%04X", uint16(current.Index))
+	}
+	output.WriteString(">")
+	return output.String()
+}
+func (self *pdfFont) Missing() error {
+	if len(self.missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Font %s has no glyphs for %q, choose another one with --pdf-font", self.BaseFont, string(self.missing))
+}
+func (self *pdfFont) Used() bool {
+	return len(self.used) != 0
+}
+func (self *pdfFont) usedGlyphs() []sfnt.GlyphIndex {
+	indices := make([]sfnt.GlyphIndex, 0, len(self.used))
+
+	for index := range self.used {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i] < indices[j]
+	})
+	return indices
+}
+func (self *pdfFont) widths() string {
+	var output strings.Builder
+	widths := map[sfnt.GlyphIndex]float64{}
+
+	for _, current := range self.glyphs {
+		widths[current.Index] = current.Width
+	}
+	output.WriteString("[")
+	for _, index := range self.usedGlyphs() {
+		fmt.Fprintf(&output, " %d [%s]", index, number(widths[index]))
+	}
+	output.WriteString(" ]")
+	return output.String()
+}
+func (self *pdfFont) toUnicode() []byte {
+	var output bytes.Buffer
+
+	output.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	output.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	output.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	output.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	indices := self.usedGlyphs()
+
+	for start := 0; start < len(indices); start += 100 {
+		end := min(start+100, len(indices))
+
+		fmt.Fprintf(&output, "%d beginbfchar\n", end-start)
+		for _, index := range indices[start:end] {
+			target := strings.TrimPrefix(strings.Trim(textString(string(self.used[index])), "<>"), "FEFF")
+			fmt.Fprintf(&output, "<%04X> <%s>\n", uint16(index), target)
+		}
+		output.WriteString("endbfchar\n")
+	}
+	output.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return output.Bytes()
+}
+func (self *pdfFont) descriptor(fileId int) (string, error) {
+	metrics, err := self.font.Metrics(&self.buffer, self.ppem, font.HintingNone)
+	if err != nil {
+		return "", err
+	}
+	bounds, err := self.font.Bounds(&self.buffer, self.ppem, font.HintingNone)
+	if err != nil {
+		return "", err
+	}
+	scale := func(value fixed.Int26_6) string {
+		return number(float64(value) / 64 * 1000 / self.unitsPerEm)
+	}
+	italicAngle := 0.0
+	if post := self.font.PostTable(); post != nil {
+		italicAngle = post.ItalicAngle
+	}
+	return fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%s %s %s %s] "+
+			"/ItalicAngle %s /Ascent %s /Descent -%s /CapHeight %s /StemV 80 /FontFile2 %d 0 R >>",
+		nameString(self.BaseFont),
+		scale(bounds.Min.X), scale(-bounds.Max.Y), scale(bounds.Max.X), scale(-bounds.Min.Y),
+		number(italicAngle), scale(metrics.Ascent), scale(metrics.Descent), scale(metrics.CapHeight),
+		fileId,
+	), nil
+}
+func (self *pdfFont) Write(writer *objectWriter, id int) error {
+	if !self.Used() {
+		return errors.New("Font is not used")
+	}
+	cidId := writer.Allocate()
+	descriptorId := writer.Allocate()
+	fileId := writer.Allocate()
+	toUnicodeId := writer.Allocate()
+
+	baseFont := nameString(self.BaseFont)
+
+	if err := writer.WriteObject(id, fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		baseFont, cidId, toUnicodeId,
+	)); err != nil {
+		return err
+	}
+	if err := writer.WriteObject(cidId, fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /W %s >>",
+		baseFont, descriptorId, self.widths(),
+	)); err != nil {
+		return err
+	}
+	if descriptor, err := self.descriptor(fileId); err != nil {
+		return err
+	} else if err := writer.WriteObject(descriptorId, descriptor); err != nil {
+		return err
+	}
+	if err := writer.WriteCompressedStream(fileId, fmt.Sprintf("/Length1 %d", len(self.data)), self.data); err != nil {
+		return err
+	}
+	return writer.WriteCompressedStream(toUnicodeId, "", self.toUnicode())
+}
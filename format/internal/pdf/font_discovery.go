@@ -0,0 +1,152 @@
+package pdf
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+type fontStyle int
+
+const (
+	styleRegular fontStyle = iota
+	styleBold
+	styleItalic
+	styleBoldItalic
+	styleMono
+)
+
+type fontFamily [4]string
+
+var fontFamilies = []fontFamily{
+	{"DejaVuSerif.ttf", "DejaVuSerif-Bold.ttf", "DejaVuSerif-Italic.ttf", "DejaVuSerif-BoldItalic.ttf"},
+	{"LiberationSerif-Regular.ttf", "LiberationSerif-Bold.ttf", "LiberationSerif-Italic.ttf", "LiberationSerif-BoldItalic.ttf"},
+	{"NotoSerif-Regular.ttf", "NotoSerif-Bold.ttf", "NotoSerif-Italic.ttf", "NotoSerif-BoldItalic.ttf"},
+	{"PTSerif-Regular.ttf", "PTSerif-Bold.ttf", "PTSerif-Italic.ttf", "PTSerif-BoldItalic.ttf"},
+	{"times.ttf", "timesbd.ttf", "timesi.ttf", "timesbi.ttf"},
+	{"Times New Roman.ttf", "Times New Roman Bold.ttf", "Times New Roman Italic.ttf", "Times New Roman Bold Italic.ttf"},
+	{"DejaVuSans.ttf", "DejaVuSans-Bold.ttf", "DejaVuSans-Oblique.ttf", "DejaVuSans-BoldOblique.ttf"},
+	{"LiberationSans-Regular.ttf", "LiberationSans-Bold.ttf", "LiberationSans-Italic.ttf", "LiberationSans-BoldItalic.ttf"},
+	{"NotoSans-Regular.ttf", "NotoSans-Bold.ttf", "NotoSans-Italic.ttf", "NotoSans-BoldItalic.ttf"},
+	{"arial.ttf", "arialbd.ttf", "ariali.ttf", "arialbi.ttf"},
+	{"Arial.ttf", "Arial Bold.ttf", "Arial Italic.ttf", "Arial Bold Italic.ttf"},
+}
+
+func fontDirs() []string {
+	home, _ := os.UserHomeDir()
+
+	switch runtime.GOOS {
+	case "windows":
+		return []string{
+			filepath.Join(os.Getenv("WINDIR"), "Fonts"),
+			filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "Fonts"),
+		}
+	case "darwin":
+		return []string{
+			filepath.Join(home, "Library", "Fonts"),
+			"/Library/Fonts",
+			"/System/Library/Fonts",
+			"/System/Library/Fonts/Supplemental",
+		}
+	default:
+		return []string{
+			filepath.Join(home, ".local", "share", "fonts"),
+			filepath.Join(home, ".fonts"),
+			"/usr/local/share/fonts",
+			"/usr/share/fonts",
+		}
+	}
+}
+func indexFonts() map[string]string {
+	index := map[string]string{}
+
+	for _, dir := range fontDirs() {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			name := strings.ToLower(entry.Name())
+
+			if _, found := index[name]; !found && strings.HasSuffix(name, ".ttf") {
+				index[name] = path
+			}
+			return nil
+		})
+	}
+	return index
+}
+func fontVariants(path string) fontFamily {
+	dir := filepath.Dir(path)
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	stem = strings.TrimSuffix(stem, "-Regular")
+
+	find := func(suffixes ...string) string {
+		for _, suffix := range suffixes {
+			candidate := filepath.Join(dir, stem+suffix+".ttf")
+
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+		}
+		return ""
+	}
+	return fontFamily{
+		path,
+		find("-Bold"),
+		find("-Italic", "-Oblique"),
+		find("-BoldItalic", "-BoldOblique"),
+	}
+}
+func findInstalled(name string) (fontFamily, bool) {
+	index := indexFonts()
+	key := strings.ToLower(strings.ReplaceAll(name, " ", ""))
+
+	for _, candidate := range fontFamilies {
+		stem := strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(candidate[0], ".ttf"), "-Regular"))
+
+		if strings.ReplaceAll(stem, " ", "") != key {
+			continue
+		}
+		if path, found := index[strings.ToLower(candidate[0])]; found {
+			family := fontVariants(path)
+
+			for style, name := range candidate {
+				if path, found := index[strings.ToLower(name)]; found && style != 0 {
+					family[style] = path
+				}
+			}
+			return family, true
+		}
+	}
+	for _, filename := range []string{key + ".ttf", key + "-regular.ttf"} {
+		if path, found := index[filename]; found {
+			return fontVariants(path), true
+		}
+	}
+	return fontFamily{}, false
+}
+func findFonts(fontPath string) (map[fontStyle]string, error) {
+	var family fontFamily
+
+	if fontPath == "" {
+		return map[fontStyle]string{}, nil
+	}
+	if _, err := os.Stat(fontPath); err == nil {
+		family = fontVariants(fontPath)
+	} else if installed, found := findInstalled(fontPath); found {
+		family = installed
+	} else {
+		return nil, fmt.Errorf("Font not found: %s", fontPath)
+	}
+	fonts := map[fontStyle]string{}
+
+	for style, path := range family {
+		if path != "" {
+			fonts[fontStyle(style)] = path
+		}
+	}
+	return fonts, nil
+}
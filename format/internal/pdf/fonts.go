@@ -0,0 +1,34 @@
+package pdf
+
+import (
+	"fmt"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+var bundledFonts = map[string][]byte{
+	"Go-Regular":     goregular.TTF,
+	"Go-Bold":        gobold.TTF,
+	"Go-Italic":      goitalic.TTF,
+	"Go-Bold-Italic": gobolditalic.TTF,
+	"Go-Mono":        gomono.TTF,
+}
+var bundledFamily = map[fontStyle]string{
+	styleRegular:    "Go-Regular",
+	styleBold:       "Go-Bold",
+	styleItalic:     "Go-Italic",
+	styleBoldItalic: "Go-Bold-Italic",
+	styleMono:       "Go-Mono",
+}
+
+func loadBundledFont(name string) (*pdfFont, error) {
+	if data, found := bundledFonts[name]; !found {
+		return nil, fmt.Errorf("Font not found: %s", name)
+	} else {
+		return parseFont(name, data)
+	}
+}
@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	base "ranobedl/format/internal/builder"
+
+	_ "github.com/gen2brain/avif"
+	_ "golang.org/x/image/webp"
+)
+
+type pdfImage struct {
+	Name   string
+	Path   string
+	Format string
+	Width  int
+	Height int
+	Model  color.Model
+}
+
+func newImage(name string, path string) (*pdfImage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if config, format, err := image.DecodeConfig(file); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", base.ErrUnsupportedImage, path, err)
+	} else {
+		return &pdfImage{
+			Name:   name,
+			Path:   path,
+			Format: format,
+			Width:  config.Width,
+			Height: config.Height,
+			Model:  config.ColorModel,
+		}, nil
+	}
+}
+func (self *pdfImage) writeJpeg(writer *objectWriter, id int) error {
+	data, err := os.ReadFile(self.Path)
+	if err != nil {
+		return err
+	}
+	colorSpace := "/DeviceRGB"
+
+	switch self.Model {
+	case color.GrayModel:
+		colorSpace = "/DeviceGray"
+	case color.CMYKModel:
+		colorSpace = "/DeviceCMYK /Decode [1 0 1 0 1 0 1 0]"
+	}
+	return writer.WriteStream(id, fmt.Sprintf(
+		"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode",
+		self.Width, self.Height, colorSpace,
+	), data)
+}
+func (self *pdfImage) writeRaster(writer *objectWriter, id int) error {
+	data, err := os.ReadFile(self.Path)
+	if err != nil {
+		return err
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("Unsupported image %s: %w", self.Path, err)
+	}
+	bounds := decoded.Bounds()
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+
+			rgb = append(rgb, pixel.R, pixel.G, pixel.B)
+			alpha = append(alpha, pixel.A)
+			opaque = opaque && pixel.A == 0xff
+		}
+	}
+	dict := fmt.Sprintf(
+		"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+		bounds.Dx(), bounds.Dy(),
+	)
+	if !opaque {
+		maskId := writer.Allocate()
+
+		if err := writer.WriteCompressedStream(maskId, fmt.Sprintf(
+			"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8",
+			bounds.Dx(), bounds.Dy(),
+		), alpha); err != nil {
+			return err
+		}
+		dict += fmt.Sprintf(" /SMask %d 0 R", maskId)
+	}
+	return writer.WriteCompressedStream(id, dict, rgb)
+}
+func (self *pdfImage) Write(writer *objectWriter, id int) error {
+	if self.Format == "jpeg" {
+		return self.writeJpeg(writer, id)
+	}
+	return self.writeRaster(writer, id)
+}
@@ -0,0 +1,140 @@
+package pdf
+
+import (
+	"strings"
+)
+
+type fragment struct {
+	Text      string
+	Font      *pdfFont
+	Size      float64
+	Width     float64
+	Underline bool
+	Strike    bool
+}
+type word struct {
+	Fragments []fragment
+	Width     float64
+}
+type line struct {
+	Words []word
+	Width float64
+	Last  bool
+}
+
+func (self *word) append(text string, font *pdfFont, size float64, source run) {
+	width := font.Width(text, size)
+	self.Width += width
+
+	if last := len(self.Fragments) - 1; last >= 0 {
+		previous := &self.Fragments[last]
+
+		if previous.Font == font && previous.Size == size &&
+			previous.Underline == source.Underline && previous.Strike == source.Strike {
+			previous.Text += text
+			previous.Width += width
+			return
+		}
+	}
+	self.Fragments = append(self.Fragments, fragment{
+		Text:      text,
+		Font:      font,
+		Size:      size,
+		Width:     width,
+		Underline: source.Underline,
+		Strike:    source.Strike,
+	})
+}
+func isSpace(char rune) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r'
+}
+func (self *builder) splitWords(runs []run, style textStyle) [][]word {
+	segments := [][]word{{}}
+	var current word
+
+	flush := func() {
+		if len(current.Fragments) != 0 {
+			segments[len(segments)-1] = append(segments[len(segments)-1], current)
+			current = word{}
+		}
+	}
+	for _, item := range runs {
+		if item.Break {
+			flush()
+			segments = append(segments, []word{})
+			continue
+		}
+		font := self.fontFor(style.Bold || item.Bold, style.Italic || item.Italic, style.Mono || item.Mono)
+		var buffer strings.Builder
+
+		for _, char := range item.Text {
+			if !isSpace(char) {
+				buffer.WriteRune(char)
+				continue
+			}
+			if buffer.Len() != 0 {
+				current.append(buffer.String(), font, style.Size, item)
+				buffer.Reset()
+			}
+			flush()
+		}
+		if buffer.Len() != 0 {
+			current.append(buffer.String(), font, style.Size, item)
+		}
+	}
+	flush()
+	return segments
+}
+func splitWord(source word, width float64) []word {
+	pieces := []word{}
+	var current word
+
+	for _, item := range source.Fragments {
+		for _, char := range item.Text {
+			charWidth := item.Font.Width(string(char), item.Size)
+
+			if len(current.Fragments) != 0 && current.Width+charWidth > width {
+				pieces = append(pieces, current)
+				current = word{}
+			}
+			current.append(string(char), item.Font, item.Size, run{Underline: item.Underline, Strike: item.Strike})
+		}
+	}
+	return append(pieces, current)
+}
+func breakLines(segments [][]word, firstWidth float64, width float64, spaceWidth float64) []line {
+	lines := []line{}
+	available := firstWidth
+	var current line
+
+	push := func(last bool) {
+		current.Last = last
+		lines = append(lines, current)
+		current = line{}
+		available = width
+	}
+	for _, segment := range segments {
+		for _, item := range segment {
+			if len(current.Words) != 0 && current.Width+spaceWidth+item.Width > available {
+				push(false)
+			}
+			if len(current.Words) == 0 && item.Width > available {
+				pieces := splitWord(item, available)
+
+				for _, piece := range pieces[:len(pieces)-1] {
+					current.Words = []word{piece}
+					current.Width = piece.Width
+					push(false)
+				}
+				item = pieces[len(pieces)-1]
+			}
+			if len(current.Words) != 0 {
+				current.Width += spaceWidth
+			}
+			current.Words = append(current.Words, item)
+			current.Width += item.Width
+		}
+		push(true)
+	}
+	return lines
+}
@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+type outlineItem struct {
+	Title    string
+	Page     int
+	Top      float64
+	Children []*outlineItem
+}
+
+func allocateOutline(writer *objectWriter, items []*outlineItem, ids map[*outlineItem]int) {
+	for _, item := range items {
+		ids[item] = writer.Allocate()
+		allocateOutline(writer, item.Children, ids)
+	}
+}
+func countOutline(items []*outlineItem) int {
+	count := len(items)
+
+	for _, item := range items {
+		count += countOutline(item.Children)
+	}
+	return count
+}
+func writeOutline(writer *objectWriter, items []*outlineItem, parentId int, ids map[*outlineItem]int, pageIds []int) error {
+	for index, item := range items {
+		var dict strings.Builder
+
+		fmt.Fprintf(
+			&dict, "<< /Title %s /Parent %d 0 R /Dest [%d 0 R /XYZ 0 %s null]",
+			textString(item.Title), parentId, pageIds[item.Page], number(item.Top),
+		)
+		if index > 0 {
+			fmt.Fprintf(&dict, " /Prev %d 0 R", ids[items[index-1]])
+		}
+		if index < len(items)-1 {
+			fmt.Fprintf(&dict, " /Next %d 0 R", ids[items[index+1]])
+		}
+		if len(item.Children) != 0 {
+			fmt.Fprintf(
+				&dict, " /First %d 0 R /Last %d 0 R /Count %d",
+				ids[item.Children[0]], ids[item.Children[len(item.Children)-1]], countOutline(item.Children),
+			)
+		}
+		dict.WriteString(" >>")
+
+		if err := writer.WriteObject(ids[item], dict.String()); err != nil {
+			return err
+		}
+		if err := writeOutline(writer, item.Children, ids[item], ids, pageIds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func writeOutlines(writer *objectWriter, id int, items []*outlineItem, pageIds []int) error {
+	ids := map[*outlineItem]int{}
+	allocateOutline(writer, items, ids)
+
+	if err := writer.WriteObject(id, fmt.Sprintf(
+		"<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>",
+		ids[items[0]], ids[items[len(items)-1]], countOutline(items),
+	)); err != nil {
+		return err
+	}
+	return writeOutline(writer, items, id, ids, pageIds)
+}
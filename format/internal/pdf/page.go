@@ -0,0 +1,27 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type page struct {
+	content  bytes.Buffer
+	data     []byte
+	HasBody  bool
+	Numbered bool
+}
+
+func (self *page) Printf(format string, args ...any) {
+	self.HasBody = true
+	fmt.Fprintf(&self.content, format, args...)
+}
+func (self *page) Finish() error {
+	if compressed, err := compress(self.content.Bytes()); err != nil {
+		return err
+	} else {
+		self.data = compressed
+		self.content = bytes.Buffer{}
+		return nil
+	}
+}
@@ -0,0 +1,77 @@
+package pdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"ranobedl/schema"
+)
+
+const imagePlaceholder = "[Иллюстрация]"
+
+type run struct {
+	Text      string `json:"text,omitempty"`
+	Break     bool   `json:"break,omitempty"`
+	Bold      bool   `json:"bold,omitempty"`
+	Italic    bool   `json:"italic,omitempty"`
+	Mono      bool   `json:"mono,omitempty"`
+	Underline bool   `json:"underline,omitempty"`
+	Strike    bool   `json:"strike,omitempty"`
+}
+
+func renderText(node schema.Node) run {
+	output := run{Text: node.Text}
+
+	for _, mark := range node.Marks {
+		switch mark.Type {
+		case schema.MarkTypeBold:
+			output.Bold = true
+		case schema.MarkTypeItalic:
+			output.Italic = true
+		case schema.MarkTypeUnderline, schema.MarkTypeLink:
+			output.Underline = true
+		case schema.MarkTypeStrike:
+			output.Strike = true
+		case schema.MarkTypeCode:
+			output.Mono = true
+		default:
+			panic(fmt.Sprintf("Undefined MarkType: %d", mark.Type))
+		}
+	}
+	return output
+}
+func renderInline(node schema.Node) (run, error) {
+	switch node.Type {
+	case schema.NodeTypeText:
+		return renderText(node), nil
+	case schema.NodeTypeHardBreak:
+		return run{Break: true}, nil
+	case schema.NodeTypeImage:
+		return run{Text: imagePlaceholder}, nil
+	default:
+		return run{}, fmt.Errorf("Expected inline node, but got %v", node.Type)
+	}
+}
+func RenderInline(node []schema.Node) (string, error) {
+	runs := make([]run, 0, len(node))
+
+	for _, child := range node {
+		if rendered, err := renderInline(child); err != nil {
+			return "", err
+		} else {
+			runs = append(runs, rendered)
+		}
+	}
+	if data, err := json.Marshal(runs); err != nil {
+		return "", err
+	} else {
+		return string(data), nil
+	}
+}
+func decodeRuns(text string) ([]run, error) {
+	var runs []run
+
+	if err := json.Unmarshal([]byte(text), &runs); err != nil {
+		return nil, fmt.Errorf("Invalid inline content: %w", err)
+	}
+	return runs, nil
+}
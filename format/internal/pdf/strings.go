@@ -0,0 +1,39 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+func textString(text string) string {
+	var output strings.Builder
+
+	output.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&output, "%04X", unit)
+	}
+	output.WriteString(">")
+	return output.String()
+}
+func nameString(name string) string {
+	var output strings.Builder
+
+	for _, char := range []byte(name) {
+		if char > ' ' && char < 0x7f && !strings.ContainsRune("/()<>[]{}%#", rune(char)) {
+			output.WriteByte(char)
+		} else {
+			fmt.Fprintf(&output, "#%02X", char)
+		}
+	}
+	return output.String()
+}
+func number(value float64) string {
+	output := fmt.Sprintf("%.2f", value)
+	output = strings.TrimRight(strings.TrimRight(output, "0"), ".")
+
+	if output == "-0" || output == "" {
+		return "0"
+	}
+	return output
+}
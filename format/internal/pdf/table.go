@@ -0,0 +1,104 @@
+package pdf
+
+import (
+	"errors"
+)
+
+type tableCell struct {
+	Header bool
+	Runs   []run
+}
+
+func (self *builder) BeginTable() error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	self.table = [][]tableCell{}
+	return nil
+}
+func (self *builder) BeginTableRow() error {
+	self.table = append(self.table, []tableCell{})
+	return nil
+}
+func (self *builder) PushTableCell(header bool, text string) error {
+	if len(self.table) == 0 {
+		return errors.New("Table row is not created")
+	}
+	if runs, err := decodeRuns(text); err != nil {
+		return err
+	} else {
+		row := &self.table[len(self.table)-1]
+		*row = append(*row, tableCell{Header: header, Runs: runs})
+		return nil
+	}
+}
+func (self *builder) EndTableRow() error {
+	return nil
+}
+func (self *builder) drawTableRow(cells []tableCell, x float64, columnWidth float64, size float64) error {
+	padding := size * 0.4
+	lineHeight := size * lineSpacing
+	spaceWidth := self.fontFor(false, false, false).Width(" ", size)
+	_, _, italic := self.bounds()
+
+	cellLines := make([][]line, len(cells))
+	rowHeight := lineHeight
+
+	for index, cell := range cells {
+		style := textStyle{Size: size, Bold: cell.Header, Italic: italic}
+		width := columnWidth - 2*padding
+		cellLines[index] = breakLines(self.splitWords(cell.Runs, style), width, width, spaceWidth)
+		rowHeight = max(rowHeight, float64(len(cellLines[index]))*lineHeight)
+	}
+	rowHeight += 2 * padding
+
+	if rowHeight <= self.contentHeight() {
+		if err := self.ensureSpace(rowHeight); err != nil {
+			return err
+		}
+	}
+	top := self.y
+
+	for index, lines := range cellLines {
+		left := x + float64(index)*columnWidth
+
+		for lineIndex, current := range lines {
+			self.y = top + padding + float64(lineIndex)*lineHeight
+			self.drawLine(current, left+padding, columnWidth-2*padding, size, alignLeft)
+		}
+		self.page().Printf(
+			"0.5 w %s %s %s %s re S\n",
+			number(left), number(self.config.PageHeight-self.config.Margin-top-rowHeight),
+			number(columnWidth), number(rowHeight),
+		)
+	}
+	self.y = top + rowHeight
+	return self.missingGlyphs()
+}
+func (self *builder) EndTable() error {
+	rows := self.table
+	self.table = nil
+	columns := 0
+
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return nil
+	}
+	x, width, _ := self.bounds()
+	size := self.config.FontSize * 0.9
+	spacing := self.config.FontSize * 0.5
+	self.takeMarker()
+
+	if self.y > 0 {
+		self.y += spacing
+	}
+	for _, row := range rows {
+		if err := self.drawTableRow(row, x, width/float64(columns), size); err != nil {
+			return err
+		}
+	}
+	self.y += spacing
+	return nil
+}
@@ -0,0 +1,92 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+type countingWriter struct {
+	writer *bufio.Writer
+	count  int64
+}
+
+func (self *countingWriter) Write(data []byte) (int, error) {
+	written, err := self.writer.Write(data)
+	self.count += int64(written)
+	return written, err
+}
+
+type objectWriter struct {
+	output  *countingWriter
+	offsets []int64
+}
+
+func newObjectWriter(writer io.Writer) *objectWriter {
+	return &objectWriter{
+		output:  &countingWriter{writer: bufio.NewWriter(writer)},
+		offsets: []int64{0},
+	}
+}
+func (self *objectWriter) Allocate() int {
+	self.offsets = append(self.offsets, 0)
+	return len(self.offsets) - 1
+}
+func (self *objectWriter) WriteHeader() error {
+	_, err := io.WriteString(self.output, "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	return err
+}
+func (self *objectWriter) WriteObject(id int, body string) error {
+	self.offsets[id] = self.output.count
+	_, err := fmt.Fprintf(self.output, "%d 0 obj\n%s\nendobj\n", id, body)
+	return err
+}
+func compress(data []byte) ([]byte, error) {
+	var output bytes.Buffer
+
+	writer := zlib.NewWriter(&output)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+func (self *objectWriter) WriteStream(id int, dict string, data []byte) error {
+	self.offsets[id] = self.output.count
+
+	if _, err := fmt.Fprintf(self.output, "%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data)); err != nil {
+		return err
+	}
+	if _, err := self.output.Write(data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(self.output, "\nendstream\nendobj\n")
+	return err
+}
+func (self *objectWriter) WriteCompressedStream(id int, dict string, data []byte) error {
+	if compressed, err := compress(data); err != nil {
+		return err
+	} else {
+		return self.WriteStream(id, "/Filter /FlateDecode "+dict, compressed)
+	}
+}
+func (self *objectWriter) Finish(rootId int, infoId int) error {
+	start := self.output.count
+
+	fmt.Fprintf(self.output, "xref\n0 %d\n", len(self.offsets))
+	io.WriteString(self.output, "0000000000 65535 f \n")
+
+	for _, offset := range self.offsets[1:] {
+		fmt.Fprintf(self.output, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(
+		self.output,
+		"trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(self.offsets), rootId, infoId, start,
+	)
+	return self.output.writer.Flush()
+}
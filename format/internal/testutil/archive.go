@@ -0,0 +1,50 @@
+package testutil
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var xmlExtensions = map[string]bool{".xml": true, ".rels": true, ".opf": true, ".xhtml": true}
+
+func checkXml(t *testing.T, name string, content string) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("%s is not well-formed: %v", name, err)
+		}
+	}
+}
+func ReadArchive(t *testing.T, path string) map[string]string {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	files := map[string]string{}
+	for _, file := range reader.File {
+		source, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(source)
+		source.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(data)
+
+		if xmlExtensions[filepath.Ext(file.Name)] {
+			checkXml(t, file.Name, files[file.Name])
+		}
+	}
+	return files
+}
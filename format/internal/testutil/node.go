@@ -0,0 +1,24 @@
+package testutil
+
+import "ranobedl/schema"
+
+func Text(value string, marks ...schema.MarkType) schema.Node {
+	node := schema.Node{Type: schema.NodeTypeText, Text: value}
+
+	for _, mark := range marks {
+		node.Marks = append(node.Marks, schema.Mark{Type: mark})
+	}
+	return node
+}
+func Block(nodeType schema.NodeType, content ...schema.Node) schema.Node {
+	return schema.Node{Type: nodeType, Content: content}
+}
+func Paragraph(content ...schema.Node) schema.Node {
+	return Block(schema.NodeTypeParagraph, content...)
+}
+func Item(content ...schema.Node) schema.Node {
+	return Block(schema.NodeTypeListItem, content...)
+}
+func Image(src string) schema.Node {
+	return schema.Node{Type: schema.NodeTypeImage, Attrs: map[string]any{"src": src}}
+}
@@ -0,0 +1,253 @@
+package txt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	base "ranobedl/format/internal/builder"
+	"strings"
+	"unicode/utf8"
+)
+
+const quoteIndent = "    "
+const codeIndent = "    "
+const horizontalRule = "* * *"
+const cellSeparator = " | "
+
+type container struct {
+	First  string
+	Rest   string
+	Blocks int
+}
+type list struct {
+	Ordered bool
+	Index   int
+}
+type builder struct {
+	metadata   base.Metadata
+	width      int
+	output     strings.Builder
+	containers []container
+	lists      []list
+	hasChapter bool
+	lastInList bool
+	rows       []string
+	cells      []string
+	verses     []string
+}
+
+func NewBuilder(width int) *builder {
+	return &builder{width: width}
+}
+func (self *builder) SetMetadata(metadata base.Metadata) {
+	self.metadata = metadata
+}
+func (self *builder) prefixes() (string, string) {
+	first, rest := "", ""
+
+	for index := range self.containers {
+		current := &self.containers[index]
+
+		if current.Blocks == 0 {
+			first += current.First
+		} else {
+			first += current.Rest
+		}
+		rest += current.Rest
+	}
+	return first, rest
+}
+func (self *builder) writeLines(lines []string, separator string) {
+	if self.output.Len() != 0 {
+		self.output.WriteString(separator)
+	}
+	for _, line := range lines {
+		self.output.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+}
+func (self *builder) writeBlock(text string, wrapped bool) {
+	first, rest := self.prefixes()
+	width := 0
+
+	if wrapped && self.width > 0 {
+		width = max(self.width-utf8.RuneCountInString(rest), 1)
+	}
+	lines := []string{}
+	for index, line := range wrap(text, width) {
+		if index == 0 {
+			lines = append(lines, first+line)
+		} else {
+			lines = append(lines, rest+line)
+		}
+	}
+	inList := len(self.lists) != 0
+
+	if inList && self.lastInList {
+		self.writeLines(lines, "")
+	} else {
+		self.writeLines(lines, "\n")
+	}
+	self.lastInList = inList
+
+	for index := range self.containers {
+		self.containers[index].Blocks++
+	}
+}
+func (self *builder) pushBlock(text string, wrapped bool) error {
+	if !self.hasChapter {
+		return errors.New("Chapter is not created")
+	}
+	self.writeBlock(text, wrapped)
+	return nil
+}
+func (self *builder) writeTitle(title string) {
+	self.lastInList = false
+	self.writeLines(wrap(title, self.width), "\n\n")
+}
+func (self *builder) PushVolume(volumeTitle string) error {
+	self.hasChapter = false
+	self.writeTitle(volumeTitle)
+	return nil
+}
+func (self *builder) PushChapter(chapterTitle string) error {
+	self.hasChapter = true
+	self.writeTitle(chapterTitle)
+	return nil
+}
+func (self *builder) PushParagraph(text string) error {
+	return self.pushBlock(text, true)
+}
+func (self *builder) PushHeading(level int, text string) error {
+	return self.pushBlock(text, true)
+}
+func (self *builder) PushImage(imagePath string) error {
+	return self.pushBlock(imagePlaceholder, false)
+}
+func (self *builder) PushCode(code string) error {
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+
+	for index := range lines {
+		lines[index] = codeIndent + lines[index]
+	}
+	return self.pushBlock(strings.Join(lines, "\n"), false)
+}
+func (self *builder) PushHorizontalRule() error {
+	return self.pushBlock(horizontalRule, false)
+}
+func (self *builder) BeginList(ordered bool) error {
+	self.lists = append(self.lists, list{Ordered: ordered})
+	return nil
+}
+func (self *builder) BeginListItem() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	current := &self.lists[len(self.lists)-1]
+	current.Index++
+
+	marker := "- "
+	if current.Ordered {
+		marker = fmt.Sprintf("%d. ", current.Index)
+	}
+	self.containers = append(self.containers, container{
+		First: marker,
+		Rest:  strings.Repeat(" ", utf8.RuneCountInString(marker)),
+	})
+	return nil
+}
+func (self *builder) EndListItem() error {
+	if len(self.containers) == 0 {
+		return errors.New("List item is not created")
+	}
+	self.containers = self.containers[:len(self.containers)-1]
+	return nil
+}
+func (self *builder) EndList() error {
+	if len(self.lists) == 0 {
+		return errors.New("List is not created")
+	}
+	self.lists = self.lists[:len(self.lists)-1]
+	return nil
+}
+func (self *builder) BeginBlockquote() error {
+	self.containers = append(self.containers, container{First: quoteIndent, Rest: quoteIndent})
+	return nil
+}
+func (self *builder) EndBlockquote() error {
+	if len(self.containers) == 0 {
+		return errors.New("Blockquote is not created")
+	}
+	self.containers = self.containers[:len(self.containers)-1]
+	return nil
+}
+func (self *builder) BeginTable() error {
+	self.rows = []string{}
+	return nil
+}
+func (self *builder) BeginTableRow() error {
+	self.cells = []string{}
+	return nil
+}
+func (self *builder) PushTableCell(_ bool, text string) error {
+	self.cells = append(self.cells, strings.ReplaceAll(text, "\n", " "))
+	return nil
+}
+func (self *builder) EndTableRow() error {
+	self.rows = append(self.rows, strings.Join(self.cells, cellSeparator))
+	self.cells = nil
+	return nil
+}
+func (self *builder) EndTable() error {
+	if len(self.rows) == 0 {
+		return nil
+	}
+	rows := self.rows
+	self.rows = nil
+	return self.pushBlock(strings.Join(rows, "\n"), false)
+}
+func (self *builder) BeginPoem() error {
+	return nil
+}
+func (self *builder) BeginStanza() error {
+	self.verses = []string{}
+	return nil
+}
+func (self *builder) PushVerse(text string) error {
+	self.verses = append(self.verses, strings.ReplaceAll(text, "\n", " "))
+	return nil
+}
+func (self *builder) EndStanza() error {
+	if len(self.verses) == 0 {
+		return nil
+	}
+	verses := self.verses
+	self.verses = nil
+	return self.pushBlock(strings.Join(verses, "\n"), true)
+}
+func (self *builder) EndPoem() error {
+	return nil
+}
+func (self *builder) renderHeader() string {
+	var output strings.Builder
+	metadata := self.metadata
+
+	output.WriteString(strings.Join(wrap(metadata.Title, self.width), "\n") + "\n")
+
+	if len(metadata.Authors) != 0 {
+		output.WriteString(strings.Join(metadata.Authors, ", ") + "\n")
+	}
+	for _, line := range strings.Split(metadata.Annotation, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			output.WriteString("\n" + strings.Join(wrap(line, self.width), "\n") + "\n")
+		}
+	}
+	return output.String()
+}
+func (self *builder) Build(filename string) error {
+	content := self.renderHeader()
+
+	if self.output.Len() != 0 {
+		content += "\n\n" + self.output.String()
+	}
+	return os.WriteFile(filename, []byte(content), 0644)
+}
@@ -0,0 +1,103 @@
+package txt
+
+import (
+	"os"
+	"path/filepath"
+	base "ranobedl/format/internal/builder"
+	"ranobedl/format/internal/nodehandler"
+	"ranobedl/format/internal/testutil"
+	"ranobedl/schema"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		width    int
+		expected []string
+	}{
+		{"disabled", "один два три", 0, []string{"один два три"}},
+		{"words", "один два три четыре", 9, []string{"один два", "три", "четыре"}},
+		{"long word", "сверхдлинноеслово да", 5, []string{"сверхдлинноеслово", "да"}},
+		{"hard break", "раз\nдва три", 7, []string{"раз", "два три"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := wrap(tt.text, tt.width); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("wrap(%q, %d) = %q; want %q", tt.text, tt.width, result, tt.expected)
+			}
+		})
+	}
+}
+func TestBuild(t *testing.T) {
+	doc := schema.Node{
+		Type: schema.NodeTypeDoc,
+		Content: []schema.Node{
+			testutil.Paragraph(testutil.Text("Первый "), testutil.Text("абзац", schema.MarkTypeBold), testutil.Text(" с довольно длинным текстом.")),
+			{Type: schema.NodeTypeBulletList, Content: []schema.Node{
+				testutil.Item(testutil.Paragraph(testutil.Text("первый"))),
+				testutil.Item(
+					testutil.Paragraph(testutil.Text("второй")),
+					schema.Node{Type: schema.NodeTypeOrderedList, Content: []schema.Node{
+						testutil.Item(testutil.Paragraph(testutil.Text("вложенный"))),
+					}},
+				),
+			}},
+			{Type: schema.NodeTypeBlockquote, Content: []schema.Node{testutil.Text("цитата")}},
+			testutil.Image("image.png"),
+			{Type: schema.NodeTypeHorizontalRule},
+		},
+	}
+	book := NewBuilder(20)
+	book.SetMetadata(base.Metadata{Title: "Книга", Authors: []string{"Автор"}})
+
+	if err := book.PushVolume("Том 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.PushChapter("Глава 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodehandler.PushBlock(book, RenderInline, doc); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "book.txt")
+
+	if err := book.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"Книга",
+		"Автор",
+		"",
+		"",
+		"Том 1",
+		"",
+		"",
+		"Глава 1",
+		"",
+		"Первый абзац с",
+		"довольно длинным",
+		"текстом.",
+		"",
+		"- первый",
+		"- второй",
+		"  1. вложенный",
+		"",
+		"    цитата",
+		"",
+		"[Иллюстрация]",
+		"",
+		"* * *",
+		"",
+	}, "\n")
+	if string(data) != expected {
+		t.Errorf("Build() =\n%s\nwant\n%s", data, expected)
+	}
+}
@@ -0,0 +1,32 @@
+package txt
+
+import (
+	"ranobedl/schema"
+)
+
+const imagePlaceholder = "[Иллюстрация]"
+
+func renderInline(node schema.Node) (string, error) {
+	switch node.Type {
+	case schema.NodeTypeText:
+		return node.Text, nil
+	case schema.NodeTypeHardBreak:
+		return "\n", nil
+	case schema.NodeTypeImage:
+		return imagePlaceholder, nil
+	default:
+		panic("Unreachable code")
+	}
+}
+func RenderInline(node []schema.Node) (string, error) {
+	output := ""
+
+	for _, child := range node {
+		if rendered, err := renderInline(child); err != nil {
+			return "", err
+		} else {
+			output += rendered
+		}
+	}
+	return output, nil
+}
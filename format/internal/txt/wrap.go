@@ -0,0 +1,33 @@
+package txt
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+func wrapLine(line string, width int) []string {
+	words := strings.Fields(line)
+	if width <= 0 || len(words) == 0 {
+		return []string{line}
+	}
+	output := []string{}
+	current := words[0]
+
+	for _, word := range words[1:] {
+		if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			output = append(output, current)
+			current = word
+		} else {
+			current += " " + word
+		}
+	}
+	return append(output, current)
+}
+func wrap(text string, width int) []string {
+	output := []string{}
+
+	for _, line := range strings.Split(text, "\n") {
+		output = append(output, wrapLine(line, width)...)
+	}
+	return output
+}
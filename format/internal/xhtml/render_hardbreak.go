@@ -0,0 +1,13 @@
+package xhtml
+
+import (
+	"fmt"
+	"ranobedl/schema"
+)
+
+func renderHardBreak(node schema.Node) (string, error) {
+	if node.Type != schema.NodeTypeHardBreak {
+		return "", fmt.Errorf("Node is not hardbreak")
+	}
+	return "<br/>", nil
+}
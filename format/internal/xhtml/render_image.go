@@ -0,0 +1,15 @@
+package xhtml
+
+import (
+	"fmt"
+	"html"
+	"ranobedl/schema"
+)
+
+func renderImage(node schema.Node) (string, error) {
+	if src, err := node.ImageSrc(); err != nil {
+		return "", err
+	} else {
+		return fmt.Sprintf(`<img src="%s" alt=""/>`, html.EscapeString(src)), nil
+	}
+}
@@ -0,0 +1,25 @@
+package xhtml
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+)
+
+var inlineImage = regexp.MustCompile(`<img src="([^"]*)" alt=""/>`)
+
+func ReplaceImages(text string, replace func(src string) (string, error)) (string, error) {
+	var err error
+
+	output := inlineImage.ReplaceAllStringFunc(text, func(match string) string {
+		src := html.UnescapeString(inlineImage.FindStringSubmatch(match)[1])
+
+		if replaced, replaceErr := replace(src); replaceErr != nil {
+			err = replaceErr
+			return match
+		} else {
+			return fmt.Sprintf(`<img src="%s" alt=""/>`, html.EscapeString(replaced))
+		}
+	})
+	return output, err
+}
@@ -0,0 +1,25 @@
+package format
+
+import (
+	"ranobedl/cachemgr"
+	"ranobedl/format/internal/builder"
+)
+
+func newMetadata(ranobeInfo cachemgr.RanobeInfo) builder.Metadata {
+	return builder.Metadata{
+		Title:          ranobeInfo.Name,
+		OriginalTitle:  ranobeInfo.OriginalName,
+		AltTitles:      ranobeInfo.AltNames,
+		Authors:        ranobeInfo.Authors,
+		Annotation:     ranobeInfo.Description,
+		Genres:         ranobeInfo.Genres,
+		Tags:           ranobeInfo.Tags,
+		Status:         ranobeInfo.Status,
+		AgeRating:      ranobeInfo.AgeRating,
+		Language:       ranobeInfo.Language,
+		SourceLanguage: ranobeInfo.SourceLanguage,
+		Publisher:      ranobeInfo.Publisher,
+		Year:           ranobeInfo.Year,
+		Source:         ranobeInfo.Url,
+	}
+}
@@ -0,0 +1,25 @@
+package format
+
+import (
+	"ranobedl/selector"
+)
+
+type Options struct {
+	Format         Format
+	Output         string
+	Selector       selector.Selector
+	TitleTemplate  string
+	VolumeTemplate string
+	Cover          string
+	Split          Split
+	NameTemplate   string
+	ExternalImages bool
+	Wrap           int
+	PageSize       PageSize
+	Margin         float64
+	FontSize       float64
+	Font           string
+	ImageMaxSize   int
+	JpegQuality    int
+	Grayscale      bool
+}
@@ -0,0 +1,62 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const pointsPerMillimeter = 72 / 25.4
+
+type PageSize struct {
+	Width  float64
+	Height float64
+}
+
+var pageSizes = []struct {
+	Name string
+	Size PageSize
+}{
+	{"a4", PageSize{595.28, 841.89}},
+	{"a5", PageSize{419.53, 595.28}},
+	{"a6", PageSize{297.64, 419.53}},
+	{"letter", PageSize{612, 792}},
+}
+
+const DefaultPageSize = "a4"
+const DefaultMargin = 20
+const DefaultFontSize = 11
+
+func millimeters(str string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("Invalid page dimension: %s", str)
+	}
+	return value * pointsPerMillimeter, nil
+}
+func PageSizeFromString(str string) (PageSize, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+
+	for _, known := range pageSizes {
+		if str == known.Name {
+			return known.Size, nil
+		}
+	}
+	if width, height, found := strings.Cut(strings.TrimSuffix(str, "mm"), "x"); found && strings.HasSuffix(str, "mm") {
+		output := PageSize{}
+		var err error
+
+		if output.Width, err = millimeters(width); err != nil {
+			return PageSize{}, err
+		}
+		if output.Height, err = millimeters(height); err != nil {
+			return PageSize{}, err
+		}
+		return output, nil
+	}
+	return PageSize{}, fmt.Errorf("Unsupported page size: %s (supported: a4, a5, a6, letter, <width>x<height>mm)", str)
+}
+func (self PageSize) Empty() bool {
+	return self.Width == 0 || self.Height == 0
+}
@@ -0,0 +1,33 @@
+package format
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPageSizeFromString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected PageSize
+		wantErr  bool
+	}{
+		{"a4", PageSize{595.28, 841.89}, false},
+		{"Letter", PageSize{612, 792}, false},
+		{"100x200mm", PageSize{283.46, 566.93}, false},
+		{"100x200", PageSize{}, true},
+		{"0x200mm", PageSize{}, true},
+		{"b5", PageSize{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := PageSizeFromString(tt.input)
+
+			if math.Abs(result.Width-tt.expected.Width) > 0.01 || math.Abs(result.Height-tt.expected.Height) > 0.01 {
+				t.Errorf("PageSizeFromString(%q) = %+v; want %+v", tt.input, result, tt.expected)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PageSizeFromString(%q) error = %v; wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
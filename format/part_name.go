@@ -0,0 +1,80 @@
+package format
+
+import (
+	"fmt"
+	"ranobedl/cachemgr"
+	"strings"
+	"text/template"
+)
+
+const (
+	DefaultVolumeNameTemplate   = "{{.Name}} - Том {{.Volume}}"
+	DefaultChaptersNameTemplate = "{{.Name}} - Главы {{.FirstVolume}}.{{.FirstNumber}}-{{.LastVolume}}.{{.LastNumber}}"
+)
+
+type partData struct {
+	Name        string
+	Index       int
+	Volume      string
+	FirstVolume string
+	FirstNumber string
+	LastVolume  string
+	LastNumber  string
+}
+
+var unsafeFilenameChars = strings.NewReplacer(
+	"/", "_", "\\", "_", ":", "_", "*", "_", "?", "_",
+	"\"", "_", "<", "_", ">", "_", "|", "_",
+)
+
+type partNamer struct {
+	template *template.Template
+	used     map[string]bool
+}
+
+func newPartNamer(text string, split Split) (*partNamer, error) {
+	fallback := DefaultVolumeNameTemplate
+	if split.Mode == SplitChapters {
+		fallback = DefaultChaptersNameTemplate
+	}
+	if parsed, err := parseTitleTemplate("name", text, fallback); err != nil {
+		return nil, err
+	} else {
+		return &partNamer{parsed, map[string]bool{}}, nil
+	}
+}
+func newPartData(name string, index int, chapters []cachemgr.Chapter) partData {
+	first := chapters[0]
+	last := chapters[len(chapters)-1]
+
+	data := partData{
+		Name:        name,
+		Index:       index,
+		FirstVolume: first.Volume,
+		FirstNumber: first.Number,
+		LastVolume:  last.Volume,
+		LastNumber:  last.Number,
+	}
+	if first.Volume == last.Volume {
+		data.Volume = first.Volume
+	}
+	return data
+}
+func (self *partNamer) Name(name string, index int, chapters []cachemgr.Chapter) (string, error) {
+	var output strings.Builder
+
+	if err := self.template.Execute(&output, newPartData(name, index, chapters)); err != nil {
+		return "", err
+	}
+	partName := strings.TrimSpace(output.String())
+
+	if partName == "" || self.used[partName] {
+		partName = strings.TrimSpace(fmt.Sprintf("%s (%d)", partName, index))
+	}
+	self.used[partName] = true
+
+	return partName, nil
+}
+func filename(name string) string {
+	return unsafeFilenameChars.Replace(name)
+}
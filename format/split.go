@@ -0,0 +1,76 @@
+package format
+
+import (
+	"fmt"
+	"ranobedl/cachemgr"
+	"strconv"
+	"strings"
+)
+
+type SplitMode int
+
+const (
+	SplitNone SplitMode = iota
+	SplitVolume
+	SplitChapters
+)
+
+type Split struct {
+	Mode SplitMode
+	Size int
+}
+
+const splitChaptersPrefix = "chapters="
+
+func SplitFromString(str string) (Split, error) {
+	switch str = strings.ToLower(strings.TrimSpace(str)); {
+	case str == "" || str == "none":
+		return Split{Mode: SplitNone}, nil
+	case str == "volume":
+		return Split{Mode: SplitVolume}, nil
+	case strings.HasPrefix(str, splitChaptersPrefix):
+		if size, err := strconv.Atoi(strings.TrimPrefix(str, splitChaptersPrefix)); err != nil || size <= 0 {
+			return Split{}, fmt.Errorf("Invalid chapter count in split: %s", str)
+		} else {
+			return Split{Mode: SplitChapters, Size: size}, nil
+		}
+	default:
+		return Split{}, fmt.Errorf("Unsupported split: %s (supported: none, volume, chapters=N)", str)
+	}
+}
+func (self Split) String() string {
+	switch self.Mode {
+	case SplitNone:
+		return "none"
+	case SplitVolume:
+		return "volume"
+	case SplitChapters:
+		return splitChaptersPrefix + strconv.Itoa(self.Size)
+	default:
+		panic(fmt.Sprintf("Undefined SplitMode: %d", self.Mode))
+	}
+}
+func (self Split) Empty() bool {
+	return self.Mode == SplitNone
+}
+func (self Split) Parts(chapters []cachemgr.Chapter) [][]cachemgr.Chapter {
+	parts := [][]cachemgr.Chapter{}
+
+	for index, chapter := range chapters {
+		var next bool
+
+		switch self.Mode {
+		case SplitVolume:
+			next = index == 0 || chapter.Volume != chapters[index-1].Volume
+		case SplitChapters:
+			next = index%self.Size == 0
+		default:
+			next = index == 0
+		}
+		if next {
+			parts = append(parts, []cachemgr.Chapter{})
+		}
+		parts[len(parts)-1] = append(parts[len(parts)-1], chapter)
+	}
+	return parts
+}
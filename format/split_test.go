@@ -0,0 +1,86 @@
+package format
+
+import (
+	"ranobedl/cachemgr"
+	"reflect"
+	"testing"
+)
+
+func TestSplitFromString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Split
+		wantErr  bool
+	}{
+		{"", Split{Mode: SplitNone}, false},
+		{"none", Split{Mode: SplitNone}, false},
+		{"Volume", Split{Mode: SplitVolume}, false},
+		{"chapters=50", Split{Mode: SplitChapters, Size: 50}, false},
+		{"chapters=0", Split{}, true},
+		{"chapters=x", Split{}, true},
+		{"arc", Split{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := SplitFromString(tt.input)
+
+			if result != tt.expected {
+				t.Errorf("SplitFromString(%q) = %+v; want %+v", tt.input, result, tt.expected)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SplitFromString(%q) error = %v; wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+func TestSplitParts(t *testing.T) {
+	chapters := []cachemgr.Chapter{
+		{Volume: "1", Number: "1"},
+		{Volume: "1", Number: "2"},
+		{Volume: "1", Number: "3"},
+		{Volume: "2", Number: "4"},
+		{Volume: "2", Number: "5"},
+	}
+	tests := []struct {
+		split    Split
+		expected []int
+	}{
+		{Split{Mode: SplitNone}, []int{5}},
+		{Split{Mode: SplitVolume}, []int{3, 2}},
+		{Split{Mode: SplitChapters, Size: 2}, []int{2, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.split.String(), func(t *testing.T) {
+			sizes := []int{}
+			for _, part := range tt.split.Parts(chapters) {
+				sizes = append(sizes, len(part))
+			}
+			if !reflect.DeepEqual(sizes, tt.expected) {
+				t.Errorf("Parts() sizes = %v; want %v", sizes, tt.expected)
+			}
+		})
+	}
+}
+func TestPartNamer(t *testing.T) {
+	chapters := []cachemgr.Chapter{
+		{Volume: "1", Number: "1"},
+		{Volume: "2", Number: "12.5"},
+	}
+	namer, err := newPartNamer("", Split{Mode: SplitChapters, Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := namer.Name("Novel", 1, chapters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Novel - Главы 1.1-2.12.5" {
+		t.Errorf("Name() = %q", name)
+	}
+	if name, _ := namer.Name("Novel", 2, chapters); name != "Novel - Главы 1.1-2.12.5 (2)" {
+		t.Errorf("Name() for duplicate = %q", name)
+	}
+	if filename("a/b:c") != "a_b_c" {
+		t.Errorf("filename() = %q", filename("a/b:c"))
+	}
+}
@@ -0,0 +1,64 @@
+package format
+
+import (
+	"fmt"
+	"ranobedl/cachemgr"
+	"strings"
+	"text/template"
+)
+
+const (
+	DefaultTitleTemplate  = "Том {{.Volume}} Глава {{.Number}}{{with .Name}} — {{.}}{{end}}"
+	DefaultVolumeTemplate = "Том {{.Volume}}"
+)
+
+type titleData struct {
+	Volume string
+	Number string
+	Name   string
+}
+
+type titleRenderer struct {
+	chapter *template.Template
+	volume  *template.Template
+}
+
+func parseTitleTemplate(name string, text string, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	if parsed, err := template.New(name).Option("missingkey=error").Parse(text); err != nil {
+		return nil, fmt.Errorf("Invalid %s template: %v", name, err)
+	} else {
+		return parsed, nil
+	}
+}
+func newTitleRenderer(chapterTemplate string, volumeTemplate string) (*titleRenderer, error) {
+	chapter, err := parseTitleTemplate("title", chapterTemplate, DefaultTitleTemplate)
+	if err != nil {
+		return nil, err
+	}
+	volume, err := parseTitleTemplate("volume", volumeTemplate, DefaultVolumeTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &titleRenderer{chapter, volume}, nil
+}
+func (self *titleRenderer) execute(tmpl *template.Template, chapter cachemgr.Chapter) (string, error) {
+	var output strings.Builder
+
+	if err := tmpl.Execute(&output, titleData{
+		Volume: chapter.Volume,
+		Number: chapter.Number,
+		Name:   strings.TrimSpace(chapter.Name),
+	}); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output.String()), nil
+}
+func (self *titleRenderer) Chapter(chapter cachemgr.Chapter) (string, error) {
+	return self.execute(self.chapter, chapter)
+}
+func (self *titleRenderer) Volume(chapter cachemgr.Chapter) (string, error) {
+	return self.execute(self.volume, chapter)
+}
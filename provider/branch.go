@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Branch struct {
+	Id        string
+	Teams     []string
+	CreatedAt string
+	Revision  string
+}
+
+type Fallback int
+
+const (
+	FallbackFirst Fallback = iota
+	FallbackNewest
+	FallbackFail
+)
+
+func FallbackFromString(str string) (Fallback, error) {
+	switch str {
+	case "first":
+		return FallbackFirst, nil
+	case "newest":
+		return FallbackNewest, nil
+	case "fail":
+		return FallbackFail, nil
+	default:
+		return -1, fmt.Errorf("Undefined branch fallback: %s (supported: first, newest, fail)", str)
+	}
+}
+
+type BranchPolicy struct {
+	BranchId string
+	Team     string
+	Fallback Fallback
+}
+
+func (self *BranchPolicy) Empty() bool {
+	return self.BranchId == "" && self.Team == ""
+}
+func (self *BranchPolicy) matches(branch Branch) bool {
+	if self.BranchId != "" && branch.Id != self.BranchId {
+		return false
+	}
+	if self.Team == "" {
+		return true
+	}
+	for _, team := range branch.Teams {
+		if strings.EqualFold(team, self.Team) {
+			return true
+		}
+	}
+	return false
+}
+func (self *BranchPolicy) newest(branches []Branch) Branch {
+	output := branches[0]
+
+	for _, branch := range branches[1:] {
+		if branch.CreatedAt > output.CreatedAt {
+			output = branch
+		}
+	}
+	return output
+}
+func (self *BranchPolicy) Select(branches []Branch) (Branch, error) {
+	if len(branches) == 0 || self.Empty() {
+		return Branch{}, nil
+	}
+	for _, branch := range branches {
+		if self.matches(branch) {
+			return branch, nil
+		}
+	}
+	switch self.Fallback {
+	case FallbackFirst:
+		return branches[0], nil
+	case FallbackNewest:
+		return self.newest(branches), nil
+	default:
+		return Branch{}, fmt.Errorf("No branch matches branch %q team %q", self.BranchId, self.Team)
+	}
+}
@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestBranchPolicySelect(t *testing.T) {
+	branches := []Branch{
+		{Id: "1", Teams: []string{"Alpha"}, CreatedAt: "2024-01-01T00:00:00Z"},
+		{Id: "2", Teams: []string{"Beta", "Gamma"}, CreatedAt: "2024-03-01T00:00:00Z"},
+		{Id: "3", Teams: []string{"Delta"}, CreatedAt: "2024-02-01T00:00:00Z"},
+	}
+	tests := []struct {
+		name     string
+		policy   BranchPolicy
+		branches []Branch
+		expected string
+		wantErr  bool
+	}{
+		{"no preference", BranchPolicy{}, branches, "", false},
+		{"no branches", BranchPolicy{BranchId: "2", Fallback: FallbackFail}, nil, "", false},
+		{"branch id", BranchPolicy{BranchId: "3"}, branches, "3", false},
+		{"team", BranchPolicy{Team: "gamma"}, branches, "2", false},
+		{"branch and team mismatch", BranchPolicy{BranchId: "1", Team: "Beta", Fallback: FallbackFail}, branches, "", true},
+		{"fallback first", BranchPolicy{Team: "Omega", Fallback: FallbackFirst}, branches, "1", false},
+		{"fallback newest", BranchPolicy{Team: "Omega", Fallback: FallbackNewest}, branches, "2", false},
+		{"fallback fail", BranchPolicy{BranchId: "9", Fallback: FallbackFail}, branches, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.policy.Select(tt.branches)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Select() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if result.Id != tt.expected {
+				t.Errorf("Select() = %q; want %q", result.Id, tt.expected)
+			}
+		})
+	}
+}
+func TestFallbackFromString(t *testing.T) {
+	for input, expected := range map[string]Fallback{
+		"first":  FallbackFirst,
+		"newest": FallbackNewest,
+		"fail":   FallbackFail,
+	} {
+		if result, err := FallbackFromString(input); err != nil || result != expected {
+			t.Errorf("FallbackFromString(%q) = %v, %v; want %v", input, result, err, expected)
+		}
+	}
+	if _, err := FallbackFromString("random"); err == nil {
+		t.Errorf("FallbackFromString(\"random\") expected error")
+	}
+}
@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"ranobedl/cachemgr"
+	"ranobedl/schema"
+)
+
+type Chapter struct {
+	Volume   string
+	Number   string
+	Name     string
+	Revision string
+	Branches []Branch
+	Branch   string
+}
+
+type Provider interface {
+	Id() cachemgr.RanobeProvider
+
+	Match(url string) bool
+	UniqueName(url string) (string, error)
+
+	FetchInfo(uniqueName string) (cachemgr.RanobeInfo, error)
+	ListChapters(uniqueName string) ([]Chapter, error)
+	FetchChapter(uniqueName string, chapter Chapter) (schema.Node, error)
+}
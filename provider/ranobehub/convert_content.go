@@ -0,0 +1,76 @@
+package ranobehub
+
+import (
+	"net/url"
+	"path"
+	"ranobedl/cachemgr"
+	"ranobedl/provider"
+	"ranobedl/schema"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const defaultImageExtension = "jpg"
+
+type contentConvertor struct {
+	UniqueName string
+	Chapter    provider.Chapter
+}
+
+func (cc *contentConvertor) imageExtension(src string) string {
+	if url, err := url.Parse(src); err == nil {
+		if extension := strings.TrimPrefix(path.Ext(url.Path), "."); extension != "" {
+			return strings.ToLower(extension)
+		}
+	}
+	return defaultImageExtension
+}
+func (cc *contentConvertor) downloadImage(src string) (string, error) {
+	return cachemgr.StoreImage(
+		providerId,
+		cc.UniqueName,
+		src,
+		"."+cc.imageExtension(src),
+	)
+}
+func (cc *contentConvertor) collectImages(node schema.Node, images []schema.Node) []schema.Node {
+	for _, child := range node.Content {
+		if child.Type == schema.NodeTypeImage {
+			images = append(images, child)
+		} else {
+			images = cc.collectImages(child, images)
+		}
+	}
+	return images
+}
+func (cc *contentConvertor) replaceImgSrc(node schema.Node) error {
+	images := cc.collectImages(node, nil)
+
+	for _, image := range images {
+		if src, err := image.ImageSrc(); err != nil {
+			return err
+		} else {
+
+			if path, err := cc.downloadImage(src); err != nil {
+				return err
+			} else {
+				image.Attrs["src"] = path
+			}
+		}
+	}
+	return nil
+}
+func (cc *contentConvertor) Convert(container *html.Node) (schema.Node, error) {
+	node, err := schema.FromHtmlString(sanitizeHtml(container))
+	if err != nil {
+		return schema.Node{}, err
+	}
+	if err := cc.replaceImgSrc(node); err != nil {
+		return schema.Node{}, err
+	}
+	return node, nil
+}
+func convertContent(uniqueName string, chapter provider.Chapter, container *html.Node) (schema.Node, error) {
+	return (&contentConvertor{UniqueName: uniqueName, Chapter: chapter}).Convert(container)
+}
@@ -0,0 +1,73 @@
+package ranobehub
+
+import (
+	api "ranobedl/api/ranobehub"
+	"ranobedl/cachemgr"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const ranobeUrl = "https://ranobehub.org/ranobe/"
+
+var lineBreakTags = map[string]bool{
+	"p": true, "br": true, "div": true, "li": true,
+}
+
+func collectText(node *html.Node, output *strings.Builder) {
+	if node.Type == html.TextNode {
+		output.WriteString(node.Data)
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		collectText(child, output)
+	}
+	if node.Type == html.ElementNode && lineBreakTags[node.Data] {
+		output.WriteString("\n")
+	}
+}
+func htmlToText(htmlStr string) string {
+	root, err := html.Parse(strings.NewReader(htmlStr))
+	if err != nil {
+		return strings.TrimSpace(htmlStr)
+	}
+	var text strings.Builder
+	collectText(root, &text)
+
+	lines := []string{}
+	for _, line := range strings.Split(text.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+func convertInfo(uniqueName string, data api.RanobeInfoData) cachemgr.RanobeInfo {
+	output := cachemgr.RanobeInfo{
+		Name:        data.Name(),
+		AltNames:    data.Synonyms,
+		Authors:     []string{},
+		Description: htmlToText(data.Description),
+		Genres:      data.GenreNames(),
+		Tags:        data.TagNames(),
+		Status:      data.Status.Title,
+		Language:    "ru",
+		Url:         ranobeUrl + uniqueName,
+		CoverUrl:    data.PosterUrl(),
+	}
+	if data.Names.Original != "" {
+		output.OriginalName = data.Names.Original
+	} else {
+		output.OriginalName = data.Names.Eng
+	}
+	if data.Names.Eng != "" && data.Names.Eng != output.OriginalName {
+		output.AltNames = append([]string{data.Names.Eng}, output.AltNames...)
+	}
+	if data.Year != 0 {
+		output.Year = strconv.Itoa(data.Year)
+	}
+	for _, author := range data.Authors {
+		output.Authors = append(output.Authors, author.Name())
+	}
+	return output
+}
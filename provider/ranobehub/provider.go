@@ -0,0 +1,67 @@
+package ranobehub
+
+import (
+	"net/url"
+	api "ranobedl/api/ranobehub"
+	"ranobedl/cachemgr"
+	"ranobedl/provider"
+	"ranobedl/schema"
+	"strconv"
+	"strings"
+)
+
+const providerId cachemgr.RanobeProvider = cachemgr.RanobeHub
+
+type ranobeHub struct{}
+
+func (self *ranobeHub) Id() cachemgr.RanobeProvider {
+	return providerId
+}
+func (self *ranobeHub) Match(urlStr string) bool {
+	if url, err := url.Parse(urlStr); err != nil {
+		return false
+	} else {
+		host := strings.ToLower(url.Hostname())
+		return host == "ranobehub.org" || strings.HasSuffix(host, ".ranobehub.org")
+	}
+}
+func (self *ranobeHub) UniqueName(url string) (string, error) {
+	return api.GetUniqueName(url)
+}
+func (self *ranobeHub) FetchInfo(uniqueName string) (cachemgr.RanobeInfo, error) {
+	if ranobeInfo, err := api.GetRanobeInfo(uniqueName); err != nil {
+		return cachemgr.RanobeInfo{}, err
+	} else {
+		return convertInfo(uniqueName, ranobeInfo), nil
+	}
+}
+func (self *ranobeHub) ListChapters(uniqueName string) ([]provider.Chapter, error) {
+	volumes, err := api.GetContents(uniqueName)
+	if err != nil {
+		return nil, err
+	}
+	output := []provider.Chapter{}
+
+	for _, volume := range volumes {
+		for _, chapter := range volume.Chapters {
+			output = append(output, provider.Chapter{
+				Volume:   strconv.Itoa(volume.Num),
+				Number:   strconv.Itoa(chapter.Num),
+				Name:     chapter.Name,
+				Revision: strconv.Itoa(chapter.Id),
+			})
+		}
+	}
+	return output, nil
+}
+func (self *ranobeHub) FetchChapter(uniqueName string, chapter provider.Chapter) (schema.Node, error) {
+	if container, err := api.GetChapterContent(uniqueName, chapter.Volume, chapter.Number); err != nil {
+		return schema.Node{}, err
+	} else {
+		return convertContent(uniqueName, chapter, container)
+	}
+}
+
+func init() {
+	provider.Register(&ranobeHub{})
+}
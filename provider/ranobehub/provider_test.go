@@ -0,0 +1,161 @@
+package ranobehub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	api "ranobedl/api/ranobehub"
+	"ranobedl/cachemgr"
+	"ranobedl/provider"
+	"ranobedl/schema"
+	"reflect"
+	"testing"
+)
+
+func newFixtureServer(t *testing.T) *httptest.Server {
+	routes := map[string]string{
+		"/api/ranobe/1":          "testdata/ranobe_info.json",
+		"/api/ranobe/1/contents": "testdata/contents.json",
+		"/ranobe/1/1/1":          "testdata/chapter.html",
+		"/api/media/55":          "testdata/image.png",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fixture, found := routes[r.URL.Path]; found {
+			http.ServeFile(w, r, fixture)
+		} else {
+			http.NotFound(w, r)
+		}
+	}))
+	baseUrl := api.BaseUrl
+	api.BaseUrl = server.URL
+
+	t.Cleanup(func() {
+		api.BaseUrl = baseUrl
+		server.Close()
+	})
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	return server
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"https://ranobehub.org/ranobe/1-lord-of-the-mysteries", true},
+		{"https://www.ranobehub.org/ranobe/1-lord-of-the-mysteries", true},
+		{"https://ranobelib.me/ru/book/1--name", false},
+		{"://", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if result := (&ranobeHub{}).Match(tt.url); result != tt.expected {
+				t.Errorf("Match(%q) = %v; want %v", tt.url, result, tt.expected)
+			}
+		})
+	}
+}
+func TestUniqueName(t *testing.T) {
+	name, err := (&ranobeHub{}).UniqueName("https://ranobehub.org/ranobe/1-lord-of-the-mysteries?tab=chapters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "1-lord-of-the-mysteries" {
+		t.Errorf("UniqueName() = %q; want %q", name, "1-lord-of-the-mysteries")
+	}
+	if _, err := api.GetRanobeId("lord-of-the-mysteries"); err == nil {
+		t.Errorf("GetRanobeId() expected error for name without id")
+	}
+}
+func TestFetchInfo(t *testing.T) {
+	newFixtureServer(t)
+
+	info, err := (&ranobeHub{}).FetchInfo("1-lord-of-the-mysteries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := cachemgr.RanobeInfo{
+		Name:         "Повелитель тайн",
+		OriginalName: "诡秘之主",
+		AltNames:     []string{"Lord of the Mysteries", "Владыка тайн"},
+		Authors:      []string{"Каракатица, Любящая Ныряние"},
+		Description:  "С приходом волны пара и машин...\nКто сможет стать Богом?",
+		Genres:       []string{"Фэнтези", "Мистика"},
+		Tags:         []string{"Тайные общества"},
+		Status:       "Завершено",
+		Language:     "ru",
+		Year:         "2018",
+		Url:          "https://ranobehub.org/ranobe/1-lord-of-the-mysteries",
+		CoverUrl:     "https://ranobehub.org/img/ranobe/posters/1/big.jpg",
+	}
+	if !reflect.DeepEqual(info, expected) {
+		t.Errorf("FetchInfo() = %+v; want %+v", info, expected)
+	}
+}
+func TestListChapters(t *testing.T) {
+	newFixtureServer(t)
+
+	chapters, err := (&ranobeHub{}).ListChapters("1-lord-of-the-mysteries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []provider.Chapter{
+		{Volume: "1", Number: "1", Name: "Багровый", Revision: "100"},
+		{Volume: "1", Number: "2", Name: "Ситуация", Revision: "101"},
+		{Volume: "2", Number: "1", Name: "Раскрытие", Revision: "200"},
+	}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("ListChapters() = %+v; want %+v", chapters, expected)
+	}
+}
+func TestFetchChapter(t *testing.T) {
+	newFixtureServer(t)
+
+	if err := cachemgr.CreateRanobeDir(providerId, "1-lord-of-the-mysteries"); err != nil {
+		t.Fatal(err)
+	}
+	node, err := (&ranobeHub{}).FetchChapter(
+		"1-lord-of-the-mysteries",
+		provider.Chapter{Volume: "1", Number: "1"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := []schema.NodeType{}
+	for _, child := range node.Content {
+		types = append(types, child.Type)
+	}
+	expected := []schema.NodeType{
+		schema.NodeTypeParagraph,
+		schema.NodeTypeParagraph,
+		schema.NodeTypeImage,
+		schema.NodeTypeParagraph,
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Fatalf("FetchChapter() block types = %v; want %v", types, expected)
+	}
+	bold := node.Content[1].Content[1]
+	if bold.Text != "боль" || len(bold.Marks) != 1 || bold.Marks[0].Type != schema.MarkTypeBold {
+		t.Errorf("FetchChapter() bold text = %+v", bold)
+	}
+	src, err := node.Content[2].ImageSrc()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture, err := os.ReadFile("testdata/image.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash := sha256.Sum256(fixture); src != hex.EncodeToString(hash[:])+".jpg" {
+		t.Errorf("FetchChapter() image src = %q", src)
+	}
+	if path, err := cachemgr.ImagePath(providerId, "1-lord-of-the-mysteries", src); err != nil {
+		t.Fatal(err)
+	} else if data, err := os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(data, fixture) {
+		t.Errorf("FetchChapter() downloaded image differs from fixture")
+	}
+}
@@ -0,0 +1,156 @@
+package ranobehub
+
+import (
+	"net/url"
+	api "ranobedl/api/ranobehub"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var renamedTags = map[string]string{
+	"strong": "b",
+	"em":     "i",
+	"del":    "s",
+	"strike": "s",
+}
+var keptTags = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "blockquote": true, "pre": true, "hr": true,
+	"br": true, "img": true, "b": true, "i": true, "u": true, "s": true, "code": true,
+	"a": true,
+}
+var droppedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"iframe":   true,
+	"svg":      true,
+	"button":   true,
+	"form":     true,
+}
+
+type htmlSanitizer struct {
+	root   *html.Node
+	output strings.Builder
+}
+
+func (hs *htmlSanitizer) findAttr(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+func (hs *htmlSanitizer) resolveUrl(reference string) string {
+	if base, err := url.Parse(api.BaseUrl); err != nil {
+		return reference
+	} else if resolved, err := base.Parse(reference); err != nil {
+		return reference
+	} else {
+		return resolved.String()
+	}
+}
+func (hs *htmlSanitizer) imageSrc(node *html.Node) string {
+	if mediaId := hs.findAttr(node, "data-media-id"); mediaId != "" {
+		return api.GetMediaUrl(mediaId)
+	}
+	if src := hs.findAttr(node, "data-src"); src != "" {
+		return hs.resolveUrl(src)
+	}
+	return hs.resolveUrl(hs.findAttr(node, "src"))
+}
+func (hs *htmlSanitizer) paragraphAncestor(node *html.Node, root *html.Node) *html.Node {
+	var paragraph *html.Node
+
+	for parent := node.Parent; parent != nil && parent != root; parent = parent.Parent {
+		if parent.Type == html.ElementNode && parent.Data == "p" {
+			paragraph = parent
+		}
+	}
+	return paragraph
+}
+func (hs *htmlSanitizer) collectImages(node *html.Node, images []*html.Node) []*html.Node {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "img" {
+			images = append(images, child)
+		} else {
+			images = hs.collectImages(child, images)
+		}
+	}
+	return images
+}
+func (hs *htmlSanitizer) isBlank(node *html.Node) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode || strings.TrimSpace(child.Data) != "" {
+			return false
+		}
+	}
+	return true
+}
+func (hs *htmlSanitizer) hoistImages(root *html.Node) {
+	for _, image := range hs.collectImages(root, nil) {
+		if paragraph := hs.paragraphAncestor(image, root); paragraph != nil {
+			image.Parent.RemoveChild(image)
+			paragraph.Parent.InsertBefore(image, paragraph.NextSibling)
+
+			if hs.isBlank(paragraph) {
+				paragraph.Parent.RemoveChild(paragraph)
+			}
+		}
+	}
+}
+func (hs *htmlSanitizer) writeElement(node *html.Node) {
+	tag := node.Data
+
+	if droppedTags[tag] {
+		return
+	}
+	if tag == "br" && node.Parent == hs.root {
+		return
+	}
+	if renamed, found := renamedTags[tag]; found {
+		tag = renamed
+	}
+	if !keptTags[tag] {
+		hs.writeChildren(node)
+		return
+	}
+	hs.output.WriteString("<" + tag)
+
+	switch tag {
+	case "img":
+		hs.output.WriteString(` src="` + html.EscapeString(hs.imageSrc(node)) + `"`)
+	case "a":
+		hs.output.WriteString(` href="` + html.EscapeString(hs.findAttr(node, "href")) + `"`)
+	}
+	hs.output.WriteString(">")
+
+	if tag == "img" || tag == "br" || tag == "hr" {
+		return
+	}
+	hs.writeChildren(node)
+	hs.output.WriteString("</" + tag + ">")
+}
+func (hs *htmlSanitizer) writeChildren(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			hs.output.WriteString(html.EscapeString(child.Data))
+		case html.ElementNode:
+			hs.writeElement(child)
+		}
+	}
+}
+func (hs *htmlSanitizer) Sanitize(root *html.Node) string {
+	hs.root = root
+
+	hs.hoistImages(root)
+	hs.writeChildren(root)
+
+	return hs.output.String()
+}
+func sanitizeHtml(root *html.Node) string {
+	return (&htmlSanitizer{}).Sanitize(root)
+}
@@ -26,17 +26,15 @@ func (cc *contentConvertor) fromHtml() (schema.Node, error) {
 		if err != nil {
 			return schema.Node{}, err
 		}
-		for _, child := range node.Content {
-			if child.Type == schema.NodeTypeImage {
-				src, err := child.ImageSrc()
-				if err != nil {
-					return schema.Node{}, err
-				}
-
-				filename := path.Base(src)
-				part := strings.TrimSuffix(filename, path.Ext(filename))
-				child.Attrs["src"] = part
+		for _, image := range cc.collectImages(node, nil) {
+			src, err := image.ImageSrc()
+			if err != nil {
+				return schema.Node{}, err
 			}
+
+			filename := path.Base(src)
+			part := strings.TrimSuffix(filename, path.Ext(filename))
+			image.Attrs["src"] = part
 		}
 		return node, nil
 	}
@@ -61,43 +59,42 @@ func (cc *contentConvertor) fromSchema() (schema.Node, error) {
 		return cc.convertSchema(schemaContent)
 	}
 }
-func (cc *contentConvertor) downloadImage(index int, source string) (string, error) {
+func (cc *contentConvertor) downloadImage(source string) (string, error) {
 	for _, attachment := range cc.Data.Attachments {
 		if attachment.Name == source {
-
-			filename := fmt.Sprintf(
-				"%s%simage%d.%s",
-				cc.Data.Volume,
-				cc.Data.Number,
-				index,
-				attachment.Extension,
-			)
-			return cachemgr.DownloadImage(
-				provider,
+			return cachemgr.StoreImage(
+				providerId,
 				cc.UniqueName,
 				"https://ranobelib.me"+attachment.Url,
-				filename,
+				"."+attachment.Extension,
 			)
 		}
 	}
 	return "", fmt.Errorf("Image not found")
 }
-func (cc *contentConvertor) replaceImgSrc(node schema.Node) error {
-	index := 0
-
+func (cc *contentConvertor) collectImages(node schema.Node, images []schema.Node) []schema.Node {
 	for _, child := range node.Content {
 		if child.Type == schema.NodeTypeImage {
-			if src, err := child.ImageSrc(); err != nil {
-				return err
-			} else {
+			images = append(images, child)
+		} else {
+			images = cc.collectImages(child, images)
+		}
+	}
+	return images
+}
+func (cc *contentConvertor) replaceImgSrc(node schema.Node) error {
+	images := cc.collectImages(node, nil)
 
-				if path, err := cc.downloadImage(index, src); err != nil {
-					return err
+	for _, image := range images {
+		if src, err := image.ImageSrc(); err != nil {
+			return err
+		} else {
+
+			if path, err := cc.downloadImage(src); err != nil {
+				return err
 
-				} else {
-					child.Attrs["src"] = path
-					index++
-				}
+			} else {
+				image.Attrs["src"] = path
 			}
 		}
 	}
@@ -0,0 +1,36 @@
+package ranobelib
+
+import (
+	"encoding/json"
+	api "ranobedl/api/ranobelib"
+	"ranobedl/schema"
+	"testing"
+)
+
+func TestFromHtmlInlineImages(t *testing.T) {
+	content, err := json.Marshal(`<p>Текст <img src="/uploads/ranobe/1/first.jpg"></p><img src="/uploads/ranobe/1/second.png">`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	convertor := contentConvertor{UniqueName: "1--novel", Data: api.ChapterContentData{Content: content}}
+
+	node, err := convertor.fromHtml()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := []string{}
+
+	for _, image := range convertor.collectImages(node, nil) {
+		if src, err := image.ImageSrc(); err != nil {
+			t.Fatal(err)
+		} else {
+			sources = append(sources, src)
+		}
+	}
+	if len(sources) != 2 || sources[0] != "first" || sources[1] != "second" {
+		t.Errorf("fromHtml() image sources = %v; want [first second]", sources)
+	}
+	if paragraph := node.Content[0]; paragraph.Type != schema.NodeTypeParagraph || paragraph.Content[1].Type != schema.NodeTypeImage {
+		t.Errorf("fromHtml() did not keep the inline image in its paragraph: %+v", paragraph)
+	}
+}
@@ -0,0 +1,57 @@
+package ranobelib
+
+import (
+	api "ranobedl/api/ranobelib"
+	"ranobedl/cachemgr"
+	"strings"
+)
+
+const bookUrl = "https://ranobelib.me/ru/book/"
+
+func sourceLanguage(typeLabel string) string {
+	label := strings.ToLower(typeLabel)
+
+	switch {
+	case strings.Contains(label, "япон"):
+		return "ja"
+	case strings.Contains(label, "коре"):
+		return "ko"
+	case strings.Contains(label, "кита"):
+		return "zh"
+	case strings.Contains(label, "англ"):
+		return "en"
+	default:
+		return ""
+	}
+}
+func convertInfo(uniqueName string, data api.RanobeInfoData) cachemgr.RanobeInfo {
+	output := cachemgr.RanobeInfo{
+		Name:           data.RusName,
+		OriginalName:   data.Name,
+		AltNames:       data.OtherNames,
+		Authors:        []string{},
+		Description:    strings.TrimSpace(data.Summary),
+		Genres:         data.GenreNames(),
+		Tags:           data.TagNames(),
+		Status:         data.Status.Label,
+		AgeRating:      data.AgeRestriction.Label,
+		Language:       "ru",
+		SourceLanguage: sourceLanguage(data.Type.Label),
+		Year:           data.Year(),
+		Url:            bookUrl + uniqueName,
+		CoverUrl:       data.Cover.Default,
+	}
+	if output.Name == "" {
+		output.Name = data.Name
+	}
+	if data.EngName != "" {
+		output.AltNames = append([]string{data.EngName}, output.AltNames...)
+	}
+	for _, author := range data.Authors {
+		output.Authors = append(output.Authors, author.Name)
+	}
+	if len(data.Publisher) != 0 {
+		output.Publisher = data.Publisher[0].Name
+	}
+	return output
+}
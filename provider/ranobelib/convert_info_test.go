@@ -0,0 +1,57 @@
+package ranobelib
+
+import (
+	"encoding/json"
+	api "ranobedl/api/ranobelib"
+	"reflect"
+	"testing"
+)
+
+func TestConvertInfo(t *testing.T) {
+	var data api.RanobeInfoData
+	if err := json.Unmarshal([]byte(`{
+		"name": "Omniscient Reader",
+		"rus_name": "Точка зрения всеведущего читателя",
+		"eng_name": "Omniscient Reader's Viewpoint",
+		"otherNames": ["ORV"],
+		"summary": "  Ким Докча...  ",
+		"authors": [{"name": "Sing Shong"}],
+		"publisher": [{"id": 1, "name": "Munpia"}],
+		"genres": [{"id": 1, "name": "Фэнтези"}],
+		"tags": [{"id": 2, "name": "Апокалипсис"}],
+		"status": {"id": 2, "label": "Завершён"},
+		"ageRestriction": {"id": 3, "label": "16+"},
+		"type": {"id": 11, "label": "Корея"},
+		"releaseDate": "2018",
+		"cover": {"default": "https://cover.imglib.info/orv.jpg"}
+	}`), &data); err != nil {
+		t.Fatal(err)
+	}
+	info := convertInfo("1--orv", data)
+
+	if info.Name != "Точка зрения всеведущего читателя" || info.OriginalName != "Omniscient Reader" {
+		t.Errorf("convertInfo() names = %q, %q", info.Name, info.OriginalName)
+	}
+	if expected := []string{"Omniscient Reader's Viewpoint", "ORV"}; !reflect.DeepEqual(info.AltNames, expected) {
+		t.Errorf("convertInfo().AltNames = %v; want %v", info.AltNames, expected)
+	}
+	if !reflect.DeepEqual(info.Authors, []string{"Sing Shong"}) || info.Publisher != "Munpia" {
+		t.Errorf("convertInfo() authors = %v, publisher = %q", info.Authors, info.Publisher)
+	}
+	if info.Description != "Ким Докча..." || info.SourceLanguage != "ko" || info.Year != "2018" {
+		t.Errorf("convertInfo() = %+v", info)
+	}
+	if info.Status != "Завершён" || info.AgeRating != "16+" || info.CoverUrl != "https://cover.imglib.info/orv.jpg" {
+		t.Errorf("convertInfo() = %+v", info)
+	}
+	if info.Url != "https://ranobelib.me/ru/book/1--orv" {
+		t.Errorf("convertInfo().Url = %q", info.Url)
+	}
+}
+func TestConvertInfoWithoutAuthors(t *testing.T) {
+	info := convertInfo("1--name", api.RanobeInfoData{Name: "Name"})
+
+	if info.Name != "Name" || len(info.Authors) != 0 {
+		t.Errorf("convertInfo() = %+v", info)
+	}
+}
@@ -1,7 +1,82 @@
 package ranobelib
 
 import (
+	"net/url"
+	api "ranobedl/api/ranobelib"
 	"ranobedl/cachemgr"
+	"ranobedl/provider"
+	"ranobedl/schema"
+	"strconv"
+	"strings"
 )
 
-const provider cachemgr.RanobeProvider = cachemgr.RanobeLib
+const providerId cachemgr.RanobeProvider = cachemgr.RanobeLib
+
+type ranobeLib struct{}
+
+func (self *ranobeLib) Id() cachemgr.RanobeProvider {
+	return providerId
+}
+func (self *ranobeLib) Match(urlStr string) bool {
+	if url, err := url.Parse(urlStr); err != nil {
+		return false
+	} else {
+		host := strings.ToLower(url.Hostname())
+		return host == "ranobelib.me" || strings.HasSuffix(host, ".ranobelib.me")
+	}
+}
+func (self *ranobeLib) UniqueName(url string) (string, error) {
+	return api.GetUniqueName(url)
+}
+func (self *ranobeLib) FetchInfo(uniqueName string) (cachemgr.RanobeInfo, error) {
+	if ranobeInfo, err := api.GetRanobeInfo(uniqueName); err != nil {
+		return cachemgr.RanobeInfo{}, err
+	} else {
+		return convertInfo(uniqueName, ranobeInfo), nil
+	}
+}
+func (self *ranobeLib) convertBranches(branches []api.Branch) []provider.Branch {
+	output := make([]provider.Branch, 0, len(branches))
+
+	for _, branch := range branches {
+		converted := provider.Branch{
+			Teams:     branch.TeamNames(),
+			CreatedAt: branch.CreatedAt,
+			Revision:  branch.Revision(),
+		}
+		if branch.BranchId != 0 {
+			converted.Id = strconv.Itoa(branch.BranchId)
+		}
+		output = append(output, converted)
+	}
+	return output
+}
+func (self *ranobeLib) ListChapters(uniqueName string) ([]provider.Chapter, error) {
+	chapterInfo, err := api.GetChapterInfo(uniqueName)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]provider.Chapter, 0, len(chapterInfo))
+
+	for _, chapter := range chapterInfo {
+		output = append(output, provider.Chapter{
+			Volume:   chapter.Volume,
+			Number:   chapter.Number,
+			Name:     chapter.Name,
+			Revision: chapter.Revision(),
+			Branches: self.convertBranches(chapter.Branches),
+		})
+	}
+	return output, nil
+}
+func (self *ranobeLib) FetchChapter(uniqueName string, chapter provider.Chapter) (schema.Node, error) {
+	if chapterContent, err := api.GetChapterContent(uniqueName, chapter.Number, chapter.Volume, chapter.Branch); err != nil {
+		return schema.Node{}, err
+	} else {
+		return convertContent(uniqueName, chapterContent)
+	}
+}
+
+func init() {
+	provider.Register(&ranobeLib{})
+}
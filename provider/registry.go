@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"fmt"
+	"ranobedl/cachemgr"
+	"sync"
+)
+
+var (
+	registryMutex sync.RWMutex
+	registry      []Provider
+)
+
+func Register(provider Provider) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	for _, registered := range registry {
+		if registered.Id() == provider.Id() {
+			panic(fmt.Sprintf("Provider already registered: %s", provider.Id()))
+		}
+	}
+	registry = append(registry, provider)
+}
+func FromUrl(url string) (Provider, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	for _, provider := range registry {
+		if provider.Match(url) {
+			return provider, nil
+		}
+	}
+	return nil, fmt.Errorf("Unsupported url: %s", url)
+}
+func FromId(id cachemgr.RanobeProvider) (Provider, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	for _, provider := range registry {
+		if provider.Id() == id {
+			return provider, nil
+		}
+	}
+	return nil, fmt.Errorf("Undefined provider: %s", id)
+}
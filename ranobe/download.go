@@ -2,11 +2,14 @@ package ranobe
 
 import (
 	"ranobedl/cachemgr"
-	"ranobedl/provider/ranobelib"
+	"ranobedl/provider"
 )
 
-func Download(provider cachemgr.RanobeProvider, uniqueName string, callback func(current, total int)) error {
-	if inCache, err := cachemgr.InCache(provider, uniqueName); err != nil {
+func Download(ranobeProvider provider.Provider, uniqueName string, options Options, callback func(current, total int)) error {
+	if err := cachemgr.Migrate(ranobeProvider.Id(), uniqueName); err != nil {
+		return err
+	}
+	if inCache, err := cachemgr.InCache(ranobeProvider.Id(), uniqueName); err != nil {
 		return err
 	} else {
 
@@ -14,11 +17,20 @@ func Download(provider cachemgr.RanobeProvider, uniqueName string, callback func
 			return nil
 		}
 	}
-	switch provider {
-
-	case cachemgr.RanobeLib:
-		return ranobelib.DownloadRanobe(uniqueName, callback)
-
+	_, err := downloadRanobe(ranobeProvider, uniqueName, options, callback)
+	return err
+}
+func Update(ranobeProvider provider.Provider, uniqueName string, options Options, callback func(current, total int)) (Report, error) {
+	return downloadRanobe(ranobeProvider, uniqueName, options, callback)
+}
+func Verify(ranobeProvider provider.Provider, uniqueName string, options Options, callback func(current, total int)) (cachemgr.VerifyReport, error) {
+	if err := cachemgr.Migrate(ranobeProvider.Id(), uniqueName); err != nil {
+		return cachemgr.VerifyReport{}, err
+	}
+	report, err := cachemgr.Verify(ranobeProvider.Id(), uniqueName)
+	if err != nil || report.Empty() {
+		return report, err
 	}
-	return nil
+	_, err = repairRanobe(ranobeProvider, uniqueName, options, report, callback)
+	return report, err
 }
@@ -0,0 +1,54 @@
+package ranobe
+
+import (
+	"os"
+	"path/filepath"
+	"ranobedl/cachemgr"
+	"ranobedl/provider"
+)
+
+type chapterDownloader struct {
+	Provider   provider.Provider
+	UniqueName string
+}
+
+func (cd *chapterDownloader) chapterPath(number string, volume string) (string, error) {
+	return cachemgr.ChapterPath(cd.Provider.Id(), cd.UniqueName, volume, number)
+}
+func (cd *chapterDownloader) Download(chapter provider.Chapter) (cachemgr.Chapter, error) {
+	schema, err := cd.Provider.FetchChapter(cd.UniqueName, chapter)
+	if err != nil {
+		return cachemgr.Chapter{}, err
+	}
+	chapterPath, err := cd.chapterPath(chapter.Number, chapter.Volume)
+	if err != nil {
+		return cachemgr.Chapter{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(chapterPath), 0777); err != nil {
+		return cachemgr.Chapter{}, err
+	}
+	if err := schema.ToFile(chapterPath); err != nil {
+		return cachemgr.Chapter{}, err
+	}
+	checksum, err := cachemgr.Checksum(chapterPath)
+	if err != nil {
+		return cachemgr.Chapter{}, err
+	}
+
+	return cachemgr.Chapter{
+		Path:     chapterPath,
+		Number:   chapter.Number,
+		Volume:   chapter.Volume,
+		Name:     chapter.Name,
+		Revision: chapter.Revision,
+		Branch:   chapter.Branch,
+		Checksum: checksum,
+	}, nil
+}
+
+func downloadChapter(ranobeProvider provider.Provider, uniqueName string, chapter provider.Chapter) (cachemgr.Chapter, error) {
+	return (&chapterDownloader{
+		Provider:   ranobeProvider,
+		UniqueName: uniqueName,
+	}).Download(chapter)
+}
@@ -0,0 +1,251 @@
+package ranobe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"ranobedl/cachemgr"
+	"ranobedl/provider"
+	"ranobedl/schema"
+	"ranobedl/util"
+	"sync"
+	"time"
+)
+
+const progressSaveInterval = 2 * time.Second
+
+type ranobeDownloader struct {
+	Provider   provider.Provider
+	UniqueName string
+	Options    Options
+
+	mutex     sync.Mutex
+	previous  map[string]cachemgr.Chapter
+	repair    map[string]cachemgr.Chapter
+	entries   []*cachemgr.Chapter
+	completed int
+	total     int
+	lastSave  time.Time
+	report    Report
+}
+
+func chapterKey(volume string, number string) string {
+	return volume + "/" + number
+}
+
+func (rd *ranobeDownloader) downloadCover(ranobeInfo *cachemgr.RanobeInfo) error {
+	coverPath, err := cachemgr.DownloadCover(
+		rd.Provider.Id(),
+		rd.UniqueName,
+		ranobeInfo.CoverUrl,
+		cachemgr.CoverName,
+	)
+	if err != nil {
+		return err
+	}
+	if checksum, err := cachemgr.Checksum(coverPath); err != nil {
+		return err
+	} else {
+		ranobeInfo.CoverPath, ranobeInfo.CoverChecksum = coverPath, checksum
+		return nil
+	}
+}
+func (rd *ranobeDownloader) exportInfo() error {
+	ranobeInfo, err := rd.Provider.FetchInfo(rd.UniqueName)
+	if err != nil {
+		return err
+	}
+	if ranobeInfo.CoverUrl != "" {
+		if err := rd.downloadCover(&ranobeInfo); err != nil {
+			fmt.Fprintf(os.Stderr, "Cover is not downloaded: %v\n", err)
+		}
+	}
+	return ranobeInfo.Save(rd.Provider.Id(), rd.UniqueName)
+}
+func (rd *ranobeDownloader) loadPrevious() {
+	rd.previous = map[string]cachemgr.Chapter{}
+
+	if pathInfo, err := cachemgr.LoadPathInfo(rd.Provider.Id(), rd.UniqueName); err == nil {
+		for _, chapter := range pathInfo.Data {
+			rd.previous[chapterKey(chapter.Volume, chapter.Number)] = chapter
+		}
+	}
+}
+func branchRevision(chapter provider.Chapter, branchId string) string {
+	for _, branch := range chapter.Branches {
+		if branch.Id == branchId && branch.Revision != "" {
+			return branch.Revision
+		}
+	}
+	return chapter.Revision
+}
+func (rd *ranobeDownloader) isDownloaded(chapter provider.Chapter, selected bool) (cachemgr.Chapter, bool) {
+	previous, found := rd.previous[chapterKey(chapter.Volume, chapter.Number)]
+	revision := branchRevision(chapter, previous.Branch)
+
+	if found && previous.Revision != "" && previous.Revision != revision {
+		return cachemgr.Chapter{}, false
+	}
+	if found && selected && !rd.Options.Branch.Empty() && previous.Branch != chapter.Branch {
+		return cachemgr.Chapter{}, false
+	}
+	chapterDownloader := chapterDownloader{
+		Provider:   rd.Provider,
+		UniqueName: rd.UniqueName,
+	}
+	chapterPath, err := chapterDownloader.chapterPath(chapter.Number, chapter.Volume)
+	if err != nil {
+		return cachemgr.Chapter{}, false
+	}
+	if _, err := schema.FromFile(chapterPath); err != nil {
+		return cachemgr.Chapter{}, false
+	}
+	checksum, err := cachemgr.Checksum(chapterPath)
+	if err != nil {
+		return cachemgr.Chapter{}, false
+	}
+	return cachemgr.Chapter{
+		Path:     chapterPath,
+		Number:   chapter.Number,
+		Volume:   chapter.Volume,
+		Name:     chapter.Name,
+		Revision: revision,
+		Branch:   previous.Branch,
+		Checksum: checksum,
+	}, true
+}
+func (rd *ranobeDownloader) selected(chapter provider.Chapter) bool {
+	if rd.repair != nil {
+		_, found := rd.repair[chapterKey(chapter.Volume, chapter.Number)]
+		return found
+	}
+	return rd.Options.Selector.Match(chapter.Volume, chapter.Number)
+}
+func (rd *ranobeDownloader) selectBranch(chapter *provider.Chapter) error {
+	if damaged, found := rd.repair[chapterKey(chapter.Volume, chapter.Number)]; found && damaged.Branch != "" {
+		chapter.Branch = damaged.Branch
+	} else if branch, err := rd.Options.Branch.Select(chapter.Branches); err != nil {
+		return fmt.Errorf("Volume %s chapter %s: %w", chapter.Volume, chapter.Number, err)
+	} else {
+		chapter.Branch = branch.Id
+	}
+	chapter.Revision = branchRevision(*chapter, chapter.Branch)
+	return nil
+}
+func (rd *ranobeDownloader) record(chapter provider.Chapter) {
+	if _, found := rd.previous[chapterKey(chapter.Volume, chapter.Number)]; found {
+		rd.report.Changed = append(rd.report.Changed, chapter)
+	} else {
+		rd.report.Added = append(rd.report.Added, chapter)
+	}
+}
+func (rd *ranobeDownloader) pathInfo() cachemgr.PathInfo {
+	pathInfo := cachemgr.PathInfo{
+		Data:     []cachemgr.Chapter{},
+		Complete: true,
+	}
+	for _, entry := range rd.entries {
+		if entry != nil {
+			pathInfo.Data = append(pathInfo.Data, *entry)
+		} else {
+			pathInfo.Complete = false
+		}
+	}
+	return pathInfo
+}
+func (rd *ranobeDownloader) saveProgress(force bool) error {
+	if !force && time.Since(rd.lastSave) < progressSaveInterval {
+		return nil
+	}
+	rd.lastSave = time.Now()
+
+	pathInfo := rd.pathInfo()
+	return pathInfo.Save(rd.Provider.Id(), rd.UniqueName)
+}
+func (rd *ranobeDownloader) complete(index int, chapter cachemgr.Chapter, callback func(current, total int)) error {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	rd.entries[index] = &chapter
+	rd.completed++
+	callback(rd.completed, rd.total)
+
+	return rd.saveProgress(false)
+}
+func (rd *ranobeDownloader) Download(callback func(current, total int)) (Report, error) {
+	if err := cachemgr.CreateRanobeDir(rd.Provider.Id(), rd.UniqueName); err != nil {
+		return rd.report, err
+	}
+	if err := rd.exportInfo(); err != nil {
+		return rd.report, err
+	}
+	chapters, err := rd.Provider.ListChapters(rd.UniqueName)
+	if err != nil {
+		return rd.report, err
+	}
+	rd.loadPrevious()
+	rd.entries = make([]*cachemgr.Chapter, len(chapters))
+	pending := []int{}
+
+	for index, chapter := range chapters {
+		selected := rd.selected(chapter)
+
+		if selected {
+			rd.total++
+
+			if err := rd.selectBranch(&chapters[index]); err != nil {
+				return rd.report, err
+			}
+			chapter = chapters[index]
+		}
+		if entry, found := rd.isDownloaded(chapter, selected); found {
+			rd.entries[index] = &entry
+
+			if selected {
+				rd.completed++
+			}
+		} else if selected {
+			pending = append(pending, index)
+			rd.record(chapter)
+		}
+	}
+	callback(rd.completed, rd.total)
+
+	err = util.RunParallel(len(pending), rd.Options.Jobs, func(pendingIndex int) error {
+		index := pending[pendingIndex]
+
+		if chapter, err := downloadChapter(rd.Provider, rd.UniqueName, chapters[index]); err != nil {
+			return err
+		} else {
+			return rd.complete(index, chapter, callback)
+		}
+	})
+	if err != nil {
+		return rd.report, errors.Join(err, rd.saveProgress(true))
+	}
+	pathInfo := rd.pathInfo()
+	return rd.report, pathInfo.Save(rd.Provider.Id(), rd.UniqueName)
+}
+
+func repairRanobe(ranobeProvider provider.Provider, uniqueName string, options Options, report cachemgr.VerifyReport, callback func(current, total int)) (Report, error) {
+	downloader := &ranobeDownloader{
+		Provider:   ranobeProvider,
+		UniqueName: uniqueName,
+		Options:    options,
+	}
+	if !report.Rebuild {
+		downloader.repair = map[string]cachemgr.Chapter{}
+
+		for _, chapter := range report.Damaged {
+			downloader.repair[chapterKey(chapter.Volume, chapter.Number)] = chapter
+		}
+	}
+	return downloader.Download(callback)
+}
+func downloadRanobe(ranobeProvider provider.Provider, uniqueName string, options Options, callback func(current, total int)) (Report, error) {
+	return (&ranobeDownloader{
+		Provider:   ranobeProvider,
+		UniqueName: uniqueName,
+		Options:    options,
+	}).Download(callback)
+}
@@ -0,0 +1,477 @@
+package ranobe
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"ranobedl/cachemgr"
+	"ranobedl/provider"
+	"ranobedl/schema"
+	"ranobedl/selector"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeProvider struct {
+	mutex    sync.Mutex
+	chapters []provider.Chapter
+	fetched  []string
+	branches []string
+	failOn   string
+	onFail   func()
+	coverUrl string
+}
+
+func (self *fakeProvider) Id() cachemgr.RanobeProvider {
+	return "fake"
+}
+func (self *fakeProvider) Match(url string) bool {
+	return false
+}
+func (self *fakeProvider) UniqueName(url string) (string, error) {
+	return url, nil
+}
+func (self *fakeProvider) FetchInfo(uniqueName string) (cachemgr.RanobeInfo, error) {
+	return cachemgr.RanobeInfo{Name: uniqueName, CoverUrl: self.coverUrl}, nil
+}
+func (self *fakeProvider) ListChapters(uniqueName string) ([]provider.Chapter, error) {
+	return slices.Clone(self.chapters), nil
+}
+func (self *fakeProvider) FetchChapter(uniqueName string, chapter provider.Chapter) (schema.Node, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	key := chapter.Volume + ":" + chapter.Number
+	if key == self.failOn {
+		if self.onFail != nil {
+			self.onFail()
+		}
+		return schema.Node{}, errors.New("connection reset")
+	}
+	self.fetched = append(self.fetched, key)
+	self.branches = append(self.branches, chapter.Branch)
+
+	return schema.Node{
+		Type: schema.NodeTypeDoc,
+		Content: []schema.Node{{
+			Type:    schema.NodeTypeParagraph,
+			Content: []schema.Node{{Type: schema.NodeTypeText, Text: key}},
+		}},
+	}, nil
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	return &fakeProvider{
+		chapters: []provider.Chapter{
+			{Volume: "1", Number: "1", Revision: "1"},
+			{Volume: "1", Number: "2", Revision: "1"},
+			{Volume: "1", Number: "3", Revision: "1"},
+			{Volume: "2", Number: "1", Revision: "1"},
+		},
+	}
+}
+func TestDownloadResume(t *testing.T) {
+	fake := newFakeProvider(t)
+	fake.failOn = "1:3"
+
+	if err := Download(fake, "novel", Options{Jobs: 1}, func(int, int) {}); err == nil {
+		t.Fatal("Download() expected error")
+	}
+	if inCache, _ := cachemgr.InCache(fake.Id(), "novel"); inCache {
+		t.Fatal("InCache() = true after interrupted download")
+	}
+	pathInfo, err := cachemgr.LoadPathInfo(fake.Id(), "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pathInfo.Complete || len(pathInfo.Data) < 2 || len(pathInfo.Data) > 3 {
+		t.Fatalf("LoadPathInfo() after failure = %+v; want incomplete progress", pathInfo)
+	}
+	for _, chapter := range pathInfo.Data {
+		if chapter.Volume == "1" && chapter.Number == "3" {
+			t.Fatalf("LoadPathInfo() contains the failed chapter")
+		}
+	}
+	missing := len(fake.chapters) - len(pathInfo.Data)
+	fake.failOn = ""
+	fake.fetched = nil
+
+	var lastCurrent, lastTotal int
+	if err := Download(fake, "novel", Options{Jobs: 2}, func(current, total int) {
+		lastCurrent, lastTotal = current, total
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.fetched) != missing {
+		t.Errorf("Download() refetched %v; want only the %d missing chapters", fake.fetched, missing)
+	}
+	if lastCurrent != 4 || lastTotal != 4 {
+		t.Errorf("Download() final progress = %d/%d; want 4/4", lastCurrent, lastTotal)
+	}
+	pathInfo, err = cachemgr.LoadPathInfo(fake.Id(), "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pathInfo.Complete || len(pathInfo.Data) != 4 {
+		t.Fatalf("LoadPathInfo() = %+v; want 4 complete entries", pathInfo)
+	}
+	for index, chapter := range fake.chapters {
+		if pathInfo.Data[index].Volume != chapter.Volume || pathInfo.Data[index].Number != chapter.Number {
+			t.Errorf("PathInfo.Data[%d] = %+v; want %+v", index, pathInfo.Data[index], chapter)
+		}
+	}
+}
+func TestDownloadProgressSaveError(t *testing.T) {
+	fake := newFakeProvider(t)
+	fake.failOn = "1:3"
+	fake.onFail = func() {
+		ranobeDir, err := cachemgr.ConstructPath(fake.Id(), "novel")
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocker := filepath.Join(ranobeDir, "PathInfo.json")
+
+		if err := os.Remove(blocker); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(blocker, "blocker"), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := Download(fake, "novel", Options{Jobs: 1}, func(int, int) {})
+
+	var pathErr *os.LinkError
+	if err == nil || !strings.Contains(err.Error(), "connection reset") || !errors.As(err, &pathErr) {
+		t.Errorf("Download() = %v; want the fetch error joined with the progress save error", err)
+	}
+}
+func TestUpdate(t *testing.T) {
+	fake := newFakeProvider(t)
+
+	if err := Download(fake, "novel", Options{Jobs: 2}, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	fake.fetched = nil
+	fake.chapters[1].Revision = "edited"
+	fake.chapters = append(fake.chapters, provider.Chapter{Volume: "2", Number: "2", Revision: "1"})
+
+	if err := Download(fake, "novel", Options{Jobs: 2}, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.fetched) != 0 {
+		t.Fatalf("Download() of cached ranobe fetched %v", fake.fetched)
+	}
+	report, err := Update(fake, "novel", Options{Jobs: 2}, func(int, int) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Added) != 1 || report.Added[0].Number != "2" || report.Added[0].Volume != "2" {
+		t.Errorf("Update().Added = %+v; want volume 2 chapter 2", report.Added)
+	}
+	if len(report.Changed) != 1 || report.Changed[0].Revision != "edited" {
+		t.Errorf("Update().Changed = %+v; want the edited chapter", report.Changed)
+	}
+	if len(fake.fetched) != 2 {
+		t.Errorf("Update() fetched %v; want 2 chapters", fake.fetched)
+	}
+	pathInfo, err := cachemgr.LoadPathInfo(fake.Id(), "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pathInfo.Data) != 5 || pathInfo.Data[1].Revision != "edited" {
+		t.Errorf("LoadPathInfo() after update = %+v", pathInfo)
+	}
+}
+func TestUpdateBranch(t *testing.T) {
+	fake := newFakeProvider(t)
+	branches := []provider.Branch{
+		{Id: "10", Teams: []string{"First"}, CreatedAt: "2020", Revision: "10@2020"},
+		{Id: "20", Teams: []string{"Second"}, CreatedAt: "2021", Revision: "20@2021"},
+	}
+	for index := range fake.chapters {
+		fake.chapters[index].Branches = branches
+	}
+	first := Options{Jobs: 2, Branch: provider.BranchPolicy{Team: "first"}}
+
+	if err := Download(fake, "novel", first, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	for _, branch := range fake.branches {
+		if branch != "10" {
+			t.Fatalf("Download() fetched branches %v; want only 10", fake.branches)
+		}
+	}
+	tests := []struct {
+		name    string
+		options Options
+		fetched int
+		branch  string
+	}{
+		{"same branch is cached", first, 0, "10"},
+		{"no policy keeps cached branch", Options{Jobs: 2}, 0, "10"},
+		{"other branch is fetched", Options{Jobs: 2, Branch: provider.BranchPolicy{BranchId: "20"}}, 4, "20"},
+		{"fallback to newest", Options{Jobs: 2, Branch: provider.BranchPolicy{Team: "Third", Fallback: provider.FallbackNewest}}, 0, "20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.fetched, fake.branches = nil, nil
+
+			if _, err := Update(fake, "novel", tt.options, func(int, int) {}); err != nil {
+				t.Fatal(err)
+			}
+			if len(fake.fetched) != tt.fetched {
+				t.Errorf("Update() fetched %v; want %d chapters", fake.fetched, tt.fetched)
+			}
+			pathInfo, err := cachemgr.LoadPathInfo(fake.Id(), "novel")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, chapter := range pathInfo.Data {
+				if chapter.Branch != tt.branch {
+					t.Errorf("chapter %s/%s branch = %q; want %q", chapter.Volume, chapter.Number, chapter.Branch, tt.branch)
+				}
+			}
+		})
+	}
+	if _, err := Update(fake, "novel", Options{Jobs: 2, Branch: provider.BranchPolicy{Team: "Third", Fallback: provider.FallbackFail}}, func(int, int) {}); err == nil {
+		t.Errorf("Update() with a failing branch policy expected error")
+	}
+	second := Options{Jobs: 2, Branch: provider.BranchPolicy{BranchId: "20"}}
+
+	for index := range fake.chapters {
+		fake.chapters[index].Branches = append(slices.Clone(branches), provider.Branch{Id: "30", Revision: "30@2022"})
+	}
+	fake.chapters[0].Branches[1].Revision = "20@2023"
+	fake.fetched = nil
+
+	if _, err := Update(fake, "novel", second, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.fetched) != 1 || fake.fetched[0] != "1:1" {
+		t.Errorf("Update() fetched %v; want only the chapter edited in branch 20", fake.fetched)
+	}
+}
+func TestUpdateBranchSelection(t *testing.T) {
+	fake := newFakeProvider(t)
+
+	for index := range fake.chapters {
+		fake.chapters[index].Branches = []provider.Branch{
+			{Id: "10", Teams: []string{"First"}, Revision: "10@2020"},
+			{Id: "20", Teams: []string{"Second"}, Revision: "20@2021"},
+		}
+	}
+	policy := provider.BranchPolicy{Team: "second"}
+
+	if err := Download(fake, "novel", Options{Jobs: 2, Branch: policy}, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	volumes, err := selector.ParseRange("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.fetched = nil
+
+	if _, err := Update(fake, "novel", Options{Jobs: 2, Branch: policy, Selector: selector.Selector{Volumes: volumes}}, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.fetched) != 0 {
+		t.Errorf("Update() fetched %v; want nothing", fake.fetched)
+	}
+	pathInfo, err := cachemgr.LoadPathInfo(fake.Id(), "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pathInfo.Complete || len(pathInfo.Data) != len(fake.chapters) {
+		t.Fatalf("LoadPathInfo() after selective update = %+v; want %d complete entries", pathInfo, len(fake.chapters))
+	}
+	for _, chapter := range pathInfo.Data {
+		if chapter.Branch != "20" {
+			t.Errorf("chapter %s/%s branch = %q; want 20", chapter.Volume, chapter.Number, chapter.Branch)
+		}
+	}
+}
+func TestDownloadFlatCache(t *testing.T) {
+	fake := newFakeProvider(t)
+	fake.chapters = []provider.Chapter{
+		{Volume: "1", Number: "1", Revision: "1"},
+		{Volume: "1", Number: "12", Revision: "1"},
+		{Volume: "11", Number: "2", Revision: "1"},
+	}
+	if err := cachemgr.CreateRanobeDir(fake.Id(), "novel"); err != nil {
+		t.Fatal(err)
+	}
+	ranobeDir, err := cachemgr.ConstructPath(fake.Id(), "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(ranobeDir, "Version.json"))
+
+	pathInfo := cachemgr.PathInfo{Complete: true}
+
+	for _, chapter := range fake.chapters {
+		node, _ := fake.FetchChapter("novel", chapter)
+		path := filepath.Join(ranobeDir, chapter.Volume+chapter.Number+".json")
+
+		if err := node.ToFile(path); err != nil {
+			t.Fatal(err)
+		}
+		pathInfo.Data = append(pathInfo.Data, cachemgr.Chapter{
+			Path:     path,
+			Volume:   chapter.Volume,
+			Number:   chapter.Number,
+			Revision: chapter.Revision,
+		})
+	}
+	if err := pathInfo.Save(fake.Id(), "novel"); err != nil {
+		t.Fatal(err)
+	}
+	fake.fetched = nil
+
+	if err := Download(fake, "novel", Options{Jobs: 2}, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.fetched) != 2 {
+		t.Errorf("Download() fetched %v; want the 2 colliding chapters", fake.fetched)
+	}
+	if pathInfo, err = cachemgr.LoadPathInfo(fake.Id(), "novel"); err != nil {
+		t.Fatal(err)
+	}
+	for _, chapter := range pathInfo.Data {
+		node, err := schema.FromFile(chapter.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := node.Content[0].Content[0].Text; text != chapter.Volume+":"+chapter.Number {
+			t.Errorf("chapter %s:%s contains %q", chapter.Volume, chapter.Number, text)
+		}
+	}
+}
+func TestDownloadSelection(t *testing.T) {
+	fake := newFakeProvider(t)
+
+	volumes, err := selector.ParseRange("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := Options{Jobs: 2, Selector: selector.Selector{Volumes: volumes}}
+
+	if err := Download(fake, "novel", options, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.fetched) != 1 || fake.fetched[0] != "2:1" {
+		t.Errorf("Download() fetched %v; want only volume 2", fake.fetched)
+	}
+	if inCache, _ := cachemgr.InCache(fake.Id(), "novel"); inCache {
+		t.Errorf("InCache() = true after partial selection")
+	}
+	fake.fetched = nil
+
+	if err := Download(fake, "novel", Options{Jobs: 2}, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.fetched) != 3 {
+		t.Errorf("Download() fetched %v; want the 3 remaining chapters", fake.fetched)
+	}
+}
+func TestDownloadCover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("cover"))
+	}))
+	defer server.Close()
+
+	fake := newFakeProvider(t)
+	fake.coverUrl = server.URL + "/poster.webp?size=big"
+
+	if err := Download(fake, "novel", Options{Jobs: 2}, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	ranobeInfo, err := cachemgr.LoadRanobeInfo(fake.Id(), "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(ranobeInfo.CoverPath) != "cover.webp" {
+		t.Fatalf("CoverPath = %q; want cover.webp", ranobeInfo.CoverPath)
+	}
+	if data, err := os.ReadFile(ranobeInfo.CoverPath); err != nil || string(data) != "cover" {
+		t.Errorf("cover content = %q, %v", data, err)
+	}
+}
+func TestDownloadMissingCover(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	fake := newFakeProvider(t)
+	fake.coverUrl = server.URL + "/poster.jpg"
+
+	if err := Download(fake, "novel", Options{Jobs: 2}, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if ranobeInfo, err := cachemgr.LoadRanobeInfo(fake.Id(), "novel"); err != nil {
+		t.Fatal(err)
+	} else if ranobeInfo.CoverPath != "" || ranobeInfo.CoverChecksum != "" {
+		t.Errorf("RanobeInfo cover = %q, %q; want empty", ranobeInfo.CoverPath, ranobeInfo.CoverChecksum)
+	}
+	if len(fake.fetched) != len(fake.chapters) {
+		t.Errorf("fetched %d chapters; want %d", len(fake.fetched), len(fake.chapters))
+	}
+}
+func TestVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("cover"))
+	}))
+	defer server.Close()
+
+	fake := newFakeProvider(t)
+	fake.coverUrl = server.URL + "/cover.jpg"
+
+	if err := Download(fake, "novel", Options{Jobs: 2}, func(int, int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if report, err := Verify(fake, "novel", Options{Jobs: 2}, func(int, int) {}); err != nil || !report.Empty() {
+		t.Fatalf("Verify() of intact cache = %+v, %v", report, err)
+	}
+	pathInfo, err := cachemgr.LoadPathInfo(fake.Id(), "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranobeInfo, err := cachemgr.LoadRanobeInfo(fake.Id(), "novel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pathInfo.Data[0].Path, []byte(`{"type": "do`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(pathInfo.Data[2].Path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ranobeInfo.CoverPath, []byte("cov"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake.fetched = nil
+
+	report, err := Verify(fake, "novel", Options{Jobs: 2}, func(int, int) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) != 3 || len(report.Damaged) != 2 {
+		t.Errorf("Verify() = %+v; want 3 problems and 2 damaged chapters", report)
+	}
+	if len(fake.fetched) != 2 || fake.fetched[0] == fake.fetched[1] {
+		t.Errorf("Verify() fetched %v; want the 2 damaged chapters", fake.fetched)
+	}
+	if data, err := os.ReadFile(ranobeInfo.CoverPath); err != nil || string(data) != "cover" {
+		t.Errorf("cover after repair = %q, %v", data, err)
+	}
+	if inCache, _ := cachemgr.InCache(fake.Id(), "novel"); !inCache {
+		t.Errorf("InCache() = false after repair")
+	}
+	if report, err := Verify(fake, "novel", Options{Jobs: 2}, func(int, int) {}); err != nil || !report.Empty() {
+		t.Errorf("Verify() after repair = %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,12 @@
+package ranobe
+
+import (
+	"ranobedl/provider"
+	"ranobedl/selector"
+)
+
+type Options struct {
+	Jobs     int
+	Selector selector.Selector
+	Branch   provider.BranchPolicy
+}
@@ -0,0 +1,14 @@
+package ranobe
+
+import (
+	"ranobedl/provider"
+)
+
+type Report struct {
+	Added   []provider.Chapter
+	Changed []provider.Chapter
+}
+
+func (self *Report) Empty() bool {
+	return len(self.Added) == 0 && len(self.Changed) == 0
+}
@@ -68,16 +68,16 @@ func (mt MarkType) MarshalJSON() ([]byte, error) {
 	return json.Marshal(mt.String())
 }
 func isBold(html string) bool {
-	return html == "b"
+	return html == "b" || html == "strong"
 }
 func isItalic(html string) bool {
-	return html == "i"
+	return html == "i" || html == "em"
 }
 func isUnderline(html string) bool {
 	return html == "u"
 }
 func isStrike(html string) bool {
-	return html == "s"
+	return html == "s" || html == "del" || html == "strike"
 }
 func isCode(html string) bool {
 	return html == "code"
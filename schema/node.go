@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"ranobedl/util"
 )
 
 type Mark struct {
@@ -32,6 +33,36 @@ func (n *Node) ImageSrc() (string, error) {
 		return "", fmt.Errorf("Node has no src attribute")
 	}
 }
+func (n *Node) HeadingLevel() (int, error) {
+	if n.Type != NodeTypeHeading {
+		return 0, fmt.Errorf("Node is not heading")
+	}
+	if n.Attrs == nil {
+		return 0, fmt.Errorf("Node has no attributes")
+	}
+	switch level := n.Attrs["level"].(type) {
+	case int:
+		return level, nil
+	case float64:
+		return int(level), nil
+	default:
+		return 0, fmt.Errorf("Node has no level attribute")
+	}
+}
+func (n *Node) PlainText() string {
+	switch n.Type {
+	case NodeTypeText:
+		return n.Text
+	case NodeTypeHardBreak:
+		return "\n"
+	}
+	output := ""
+
+	for _, child := range n.Content {
+		output += child.PlainText()
+	}
+	return output
+}
 func (m *Mark) LinkHref() (string, error) {
 	if m.Type != MarkTypeLink {
 		return "", fmt.Errorf("Mark is not link")
@@ -80,10 +111,5 @@ func (node *Node) ToStream(stream io.Writer) error {
 	return encoder.Encode(node)
 }
 func (node *Node) ToFile(filename string) error {
-	if file, err := os.Create(filename); err != nil {
-		return err
-	} else {
-		defer file.Close()
-		return node.ToStream(file)
-	}
+	return util.WriteFileAtomic(filename, node.ToStream)
 }
@@ -17,6 +17,12 @@ const (
 	NodeTypeBlockquote
 	NodeTypeCodeBlock
 	NodeTypeHorizontalRule
+	NodeTypeTable
+	NodeTypeTableRow
+	NodeTypeTableHeader
+	NodeTypeTableCell
+	NodeTypePoem
+	NodeTypeStanza
 
 	// inline
 
@@ -47,7 +53,13 @@ func (nt NodeType) Group() NodeGroup {
 		nt == NodeTypeListItem ||
 		nt == NodeTypeBlockquote ||
 		nt == NodeTypeCodeBlock ||
-		nt == NodeTypeHorizontalRule {
+		nt == NodeTypeHorizontalRule ||
+		nt == NodeTypeTable ||
+		nt == NodeTypeTableRow ||
+		nt == NodeTypeTableHeader ||
+		nt == NodeTypeTableCell ||
+		nt == NodeTypePoem ||
+		nt == NodeTypeStanza {
 		return NodeGroupBlock
 	}
 	if nt == NodeTypeText ||
@@ -77,6 +89,18 @@ func NodeTypeFromString(str string) (NodeType, error) {
 		return NodeTypeCodeBlock, nil
 	case "horizontalRule":
 		return NodeTypeHorizontalRule, nil
+	case "table":
+		return NodeTypeTable, nil
+	case "tableRow":
+		return NodeTypeTableRow, nil
+	case "tableHeader":
+		return NodeTypeTableHeader, nil
+	case "tableCell":
+		return NodeTypeTableCell, nil
+	case "poem":
+		return NodeTypePoem, nil
+	case "stanza":
+		return NodeTypeStanza, nil
 	case "text":
 		return NodeTypeText, nil
 	case "hardBreak":
@@ -119,6 +143,18 @@ func (self NodeType) String() string {
 		return "codeBlock"
 	case NodeTypeHorizontalRule:
 		return "horizontalRule"
+	case NodeTypeTable:
+		return "table"
+	case NodeTypeTableRow:
+		return "tableRow"
+	case NodeTypeTableHeader:
+		return "tableHeader"
+	case NodeTypeTableCell:
+		return "tableCell"
+	case NodeTypePoem:
+		return "poem"
+	case NodeTypeStanza:
+		return "stanza"
 	case NodeTypeText:
 		return "text"
 	case NodeTypeHardBreak:
@@ -162,6 +198,23 @@ func isCodeBlock(html string) bool {
 func isHorizontalRule(html string) bool {
 	return html == "hr"
 }
+func isTable(html string) bool {
+	return html == "table"
+}
+func isTableSection(html string) bool {
+	return html == "thead" ||
+		html == "tbody" ||
+		html == "tfoot"
+}
+func isTableRow(html string) bool {
+	return html == "tr"
+}
+func isTableHeader(html string) bool {
+	return html == "th"
+}
+func isTableCell(html string) bool {
+	return html == "td"
+}
 func isHardBreak(html string) bool {
 	return html == "br"
 }
@@ -193,6 +246,18 @@ func NodeTypeFromHTML(html string) (NodeType, error) {
 	if isHorizontalRule(html) {
 		return NodeTypeHorizontalRule, nil
 	}
+	if isTable(html) {
+		return NodeTypeTable, nil
+	}
+	if isTableRow(html) {
+		return NodeTypeTableRow, nil
+	}
+	if isTableHeader(html) {
+		return NodeTypeTableHeader, nil
+	}
+	if isTableCell(html) {
+		return NodeTypeTableCell, nil
+	}
 	if isHardBreak(html) {
 		return NodeTypeHardBreak, nil
 	}
@@ -21,6 +21,10 @@ func TestNodeTypeFromString(t *testing.T) {
 		{"blockquote", NodeTypeBlockquote, nil},
 		{"codeBlock", NodeTypeCodeBlock, nil},
 		{"horizontalRule", NodeTypeHorizontalRule, nil},
+		{"table", NodeTypeTable, nil},
+		{"tableHeader", NodeTypeTableHeader, nil},
+		{"poem", NodeTypePoem, nil},
+		{"stanza", NodeTypeStanza, nil},
 		{"text", NodeTypeText, nil},
 		{"hardBreak", NodeTypeHardBreak, nil},
 		{"image", NodeTypeImage, nil},
@@ -56,6 +60,9 @@ func TestNodeTypeString(t *testing.T) {
 		{NodeTypeBlockquote, "blockquote"},
 		{NodeTypeCodeBlock, "codeBlock"},
 		{NodeTypeHorizontalRule, "horizontalRule"},
+		{NodeTypeTableRow, "tableRow"},
+		{NodeTypeTableCell, "tableCell"},
+		{NodeTypePoem, "poem"},
 		{NodeTypeText, "text"},
 		{NodeTypeHardBreak, "hardBreak"},
 		{NodeTypeImage, "image"},
@@ -154,7 +154,28 @@ func (hp *htmlParser) handleListItem(node *html.Node) (Node, error) {
 		}, nil
 	}
 }
+func (hp *htmlParser) hasBlockChildren(node *html.Node) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if nodeType, err := NodeTypeFromHTML(child.Data); err == nil && nodeType.IsBlock() {
+			return true
+		}
+	}
+	return false
+}
 func (hp *htmlParser) handleBlockquote(node *html.Node) (Node, error) {
+	if hp.hasBlockChildren(node) {
+		if content, err := hp.parseBlockChildren(node); err != nil {
+			return Node{}, err
+		} else {
+			return Node{
+				Type:    NodeTypeBlockquote,
+				Content: content,
+			}, nil
+		}
+	}
 	if content, err := hp.parseInlineChildren(node, []Mark{}); err != nil {
 		return Node{}, err
 	} else {
@@ -174,6 +195,71 @@ func (hp *htmlParser) handleCodeBlock(node *html.Node) (Node, error) {
 		}, nil
 	}
 }
+func (hp *htmlParser) parseTableRows(node *html.Node) ([]Node, error) {
+	var output []Node
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if isTableSection(child.Data) {
+			if rows, err := hp.parseTableRows(child); err != nil {
+				return []Node{}, err
+			} else {
+				output = append(output, rows...)
+			}
+		} else if isTableRow(child.Data) {
+			if row, err := hp.handleTableRow(child); err != nil {
+				return []Node{}, err
+			} else {
+				output = append(output, row)
+			}
+		}
+	}
+	return output, nil
+}
+func (hp *htmlParser) handleTable(node *html.Node) (Node, error) {
+	if content, err := hp.parseTableRows(node); err != nil {
+		return Node{}, err
+	} else {
+		return Node{
+			Type:    NodeTypeTable,
+			Content: content,
+		}, nil
+	}
+}
+func (hp *htmlParser) handleTableRow(node *html.Node) (Node, error) {
+	var content []Node
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || !(isTableHeader(child.Data) || isTableCell(child.Data)) {
+			continue
+		}
+		if cell, err := hp.handleTableCell(child); err != nil {
+			return Node{}, err
+		} else {
+			content = append(content, cell)
+		}
+	}
+	return Node{
+		Type:    NodeTypeTableRow,
+		Content: content,
+	}, nil
+}
+func (hp *htmlParser) handleTableCell(node *html.Node) (Node, error) {
+	nodeType := NodeTypeTableCell
+	if isTableHeader(node.Data) {
+		nodeType = NodeTypeTableHeader
+	}
+	if content, err := hp.parseBlockChildren(node); err != nil {
+		return Node{}, err
+	} else {
+		return Node{
+			Type:    nodeType,
+			Content: content,
+		}, nil
+	}
+}
 func (hp *htmlParser) handleHorizontalRule(_ *html.Node) (Node, error) {
 	return Node{
 		Type: NodeTypeHorizontalRule,
@@ -211,6 +297,15 @@ func (hp *htmlParser) parseBlock(node *html.Node) (Node, error) {
 	if isHorizontalRule(tag) {
 		return hp.handleHorizontalRule(node)
 	}
+	if isTable(tag) {
+		return hp.handleTable(node)
+	}
+	if isTableRow(tag) {
+		return hp.handleTableRow(node)
+	}
+	if isTableHeader(tag) || isTableCell(tag) {
+		return hp.handleTableCell(node)
+	}
 	if isHardBreak(tag) {
 		return hp.handleHardBreak(node)
 	}
@@ -247,7 +342,16 @@ func (hp *htmlParser) parseInline(node *html.Node, marks []Mark) ([]Node, error)
 			var tag string = node.Data
 			var mark Mark
 
-			if isBold(tag) {
+			if isHardBreak(tag) {
+				return []Node{{Type: NodeTypeHardBreak}}, nil
+
+			} else if isImage(tag) {
+				return []Node{{
+					Type:  NodeTypeImage,
+					Attrs: map[string]any{"src": hp.findAttr(node, "src")},
+				}}, nil
+
+			} else if isBold(tag) {
 				mark.Type = MarkTypeBold
 
 			} else if isItalic(tag) {
@@ -393,6 +393,43 @@ func TestParseInline(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:  "Strong and em aliases",
+			input: `<strong>Bold <em>italic</em></strong>`,
+			expected: []Node{
+				{
+					Type:  NodeTypeText,
+					Text:  "Bold ",
+					Marks: []Mark{{Type: MarkTypeBold}},
+				},
+				{
+					Type:  NodeTypeText,
+					Text:  "italic",
+					Marks: []Mark{{Type: MarkTypeBold}, {Type: MarkTypeItalic}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Hard break inside formatting",
+			input: `<i>one<br>two</i>`,
+			expected: []Node{
+				{
+					Type:  NodeTypeText,
+					Text:  "one",
+					Marks: []Mark{{Type: MarkTypeItalic}},
+				},
+				{
+					Type: NodeTypeHardBreak,
+				},
+				{
+					Type:  NodeTypeText,
+					Text:  "two",
+					Marks: []Mark{{Type: MarkTypeItalic}},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name:    "Invalid inline tag",
 			input:   `<invalid>Text</invalid>`,
@@ -496,6 +533,68 @@ func TestFromHtmlString(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:  "Blockquote with paragraphs",
+			input: `<blockquote><p>One</p><p>Two</p></blockquote>`,
+			expected: Node{
+				Type: NodeTypeDoc,
+				Content: []Node{
+					{
+						Type: NodeTypeBlockquote,
+						Content: []Node{
+							{
+								Type:    NodeTypeParagraph,
+								Content: []Node{{Type: NodeTypeText, Text: "One", Marks: []Mark{}}},
+							},
+							{
+								Type:    NodeTypeParagraph,
+								Content: []Node{{Type: NodeTypeText, Text: "Two", Marks: []Mark{}}},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Table with header row",
+			input: `<table><thead><tr><th>Name</th></tr></thead><tbody><tr><td>Value</td></tr></tbody></table>`,
+			expected: Node{
+				Type: NodeTypeDoc,
+				Content: []Node{
+					{
+						Type: NodeTypeTable,
+						Content: []Node{
+							{
+								Type: NodeTypeTableRow,
+								Content: []Node{
+									{
+										Type: NodeTypeTableHeader,
+										Content: []Node{{
+											Type:    NodeTypeParagraph,
+											Content: []Node{{Type: NodeTypeText, Text: "Name"}},
+										}},
+									},
+								},
+							},
+							{
+								Type: NodeTypeTableRow,
+								Content: []Node{
+									{
+										Type: NodeTypeTableCell,
+										Content: []Node{{
+											Type:    NodeTypeParagraph,
+											Content: []Node{{Type: NodeTypeText, Text: "Value"}},
+										}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name:  "Complex document",
 			input: `<html><body><h1>Title</h1><p>Text <b>bold</b></p><ul><li>Item</li></ul></body></html>`,
@@ -0,0 +1,48 @@
+package selector
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Position struct {
+	Volume     float64
+	Chapter    float64
+	HasChapter bool
+}
+
+func ParsePosition(str string) (Position, error) {
+	volume, chapter, hasChapter := strings.Cut(str, ":")
+
+	output := Position{HasChapter: hasChapter}
+
+	if number, err := parseNumber(volume); err != nil {
+		return Position{}, fmt.Errorf("Invalid position %q, expected <volume> or <volume>:<chapter>", str)
+	} else {
+		output.Volume = number
+	}
+	if hasChapter {
+		if number, err := parseNumber(chapter); err != nil {
+			return Position{}, fmt.Errorf("Invalid position %q, expected <volume> or <volume>:<chapter>", str)
+		} else {
+			output.Chapter = number
+		}
+	}
+	return output, nil
+}
+func (self *Position) compare(volume float64, chapter float64) int {
+	switch {
+	case volume < self.Volume:
+		return -1
+	case volume > self.Volume:
+		return 1
+	case !self.HasChapter:
+		return 0
+	case chapter < self.Chapter:
+		return -1
+	case chapter > self.Chapter:
+		return 1
+	default:
+		return 0
+	}
+}
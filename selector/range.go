@@ -0,0 +1,90 @@
+package selector
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type interval struct {
+	Min float64
+	Max float64
+}
+
+type Range struct {
+	intervals []interval
+}
+
+func parseNumber(str string) (float64, error) {
+	if number, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err != nil {
+		return 0, err
+	} else if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("Invalid number: %s", str)
+	} else {
+		return number, nil
+	}
+}
+func parseInterval(str string) (interval, error) {
+	from, to, isRange := strings.Cut(str, "-")
+
+	if !isRange {
+		if number, err := parseNumber(str); err != nil {
+			return interval{}, err
+		} else {
+			return interval{Min: number, Max: number}, nil
+		}
+	}
+	output := interval{Min: math.Inf(-1), Max: math.Inf(1)}
+
+	if strings.TrimSpace(from) == "" && strings.TrimSpace(to) == "" {
+		return interval{}, fmt.Errorf("Empty interval")
+	}
+	if strings.TrimSpace(from) != "" {
+		if number, err := parseNumber(from); err != nil {
+			return interval{}, err
+		} else {
+			output.Min = number
+		}
+	}
+	if strings.TrimSpace(to) != "" {
+		if number, err := parseNumber(to); err != nil {
+			return interval{}, err
+		} else {
+			output.Max = number
+		}
+	}
+	if output.Min > output.Max {
+		return interval{}, fmt.Errorf("Interval start is greater than end")
+	}
+	return output, nil
+}
+func ParseRange(str string) (Range, error) {
+	var output Range
+
+	if strings.TrimSpace(str) == "" {
+		return output, nil
+	}
+	for _, part := range strings.Split(str, ",") {
+		if interval, err := parseInterval(part); err != nil {
+			return Range{}, fmt.Errorf("Invalid range %q: %v", str, err)
+		} else {
+			output.intervals = append(output.intervals, interval)
+		}
+	}
+	return output, nil
+}
+func (self *Range) Empty() bool {
+	return len(self.intervals) == 0
+}
+func (self *Range) Contains(number float64) bool {
+	if self.Empty() {
+		return true
+	}
+	for _, interval := range self.intervals {
+		if number >= interval.Min && number <= interval.Max {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package selector
+
+type Selector struct {
+	Volumes  Range
+	Chapters Range
+	From     *Position
+	To       *Position
+}
+
+func (self *Selector) Empty() bool {
+	return self.Volumes.Empty() &&
+		self.Chapters.Empty() &&
+		self.From == nil &&
+		self.To == nil
+}
+func (self *Selector) Match(volume string, number string) bool {
+	if self.Empty() {
+		return true
+	}
+	volumeNumber, volumeErr := parseNumber(volume)
+	chapterNumber, chapterErr := parseNumber(number)
+
+	if volumeErr != nil && (!self.Volumes.Empty() || self.From != nil || self.To != nil) {
+		return false
+	}
+	if chapterErr != nil && (!self.Chapters.Empty() || self.From != nil || self.To != nil) {
+		return false
+	}
+	if !self.Volumes.Contains(volumeNumber) {
+		return false
+	}
+	if !self.Chapters.Contains(chapterNumber) {
+		return false
+	}
+	if self.From != nil && self.From.compare(volumeNumber, chapterNumber) < 0 {
+		return false
+	}
+	if self.To != nil && self.To.compare(volumeNumber, chapterNumber) > 0 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package selector
+
+import (
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		contains []float64
+		excludes []float64
+		wantErr  bool
+	}{
+		{"", []float64{0, 1, 100}, nil, false},
+		{"1-3", []float64{1, 2, 2.5, 3}, []float64{0, 3.5, 4}, false},
+		{"12.5", []float64{12.5}, []float64{12, 13}, false},
+		{"1,4-5,10-", []float64{1, 4, 5, 10, 999}, []float64{2, 6, 9.9}, false},
+		{"-3", []float64{0, 3}, []float64{3.1}, false},
+		{" 2 - 4 ", []float64{2, 4}, []float64{5}, false},
+		{"3-1", nil, nil, true},
+		{"a-b", nil, nil, true},
+		{"-", nil, nil, true},
+		{"1,,2", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseRange(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRange(%q) error = %v; wantErr %v", tt.input, err, tt.wantErr)
+			}
+			for _, number := range tt.contains {
+				if !result.Contains(number) {
+					t.Errorf("ParseRange(%q).Contains(%v) = false", tt.input, number)
+				}
+			}
+			for _, number := range tt.excludes {
+				if result.Contains(number) {
+					t.Errorf("ParseRange(%q).Contains(%v) = true", tt.input, number)
+				}
+			}
+		})
+	}
+}
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Position
+		wantErr  bool
+	}{
+		{"2", Position{Volume: 2}, false},
+		{"2:10", Position{Volume: 2, Chapter: 10, HasChapter: true}, false},
+		{"1:12.5", Position{Volume: 1, Chapter: 12.5, HasChapter: true}, false},
+		{":10", Position{}, true},
+		{"2:", Position{}, true},
+		{"x", Position{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParsePosition(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePosition(%q) error = %v; wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("ParsePosition(%q) = %+v; want %+v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+func TestSelectorMatch(t *testing.T) {
+	mustRange := func(str string) Range {
+		result, err := ParseRange(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	mustPosition := func(str string) *Position {
+		result, err := ParsePosition(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &result
+	}
+	tests := []struct {
+		name     string
+		selector Selector
+		volume   string
+		number   string
+		expected bool
+	}{
+		{"empty", Selector{}, "", "extra", true},
+		{"volume inside", Selector{Volumes: mustRange("1-3")}, "2", "15", true},
+		{"volume outside", Selector{Volumes: mustRange("1-3")}, "4", "1", false},
+		{"volume unparsable", Selector{Volumes: mustRange("1-3")}, "", "1", false},
+		{"decimal chapter", Selector{Chapters: mustRange("12-13")}, "1", "12.5", true},
+		{"chapter outside", Selector{Chapters: mustRange("10-50")}, "1", "51", false},
+		{"from before", Selector{From: mustPosition("2:10")}, "2", "9", false},
+		{"from equal", Selector{From: mustPosition("2:10")}, "2", "10", true},
+		{"from next volume", Selector{From: mustPosition("2:10")}, "3", "1", true},
+		{"from volume start", Selector{From: mustPosition("2")}, "2", "0.5", true},
+		{"to volume end", Selector{To: mustPosition("3")}, "3", "999", true},
+		{"to after", Selector{To: mustPosition("3:5")}, "3", "5.5", false},
+		{"to previous volume", Selector{To: mustPosition("3:5")}, "2", "100", true},
+		{
+			"combined",
+			Selector{Volumes: mustRange("1-2"), From: mustPosition("1:5")},
+			"1", "4", false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.selector.Match(tt.volume, tt.number); result != tt.expected {
+				t.Errorf("Match(%q, %q) = %v; want %v", tt.volume, tt.number, result, tt.expected)
+			}
+		})
+	}
+}
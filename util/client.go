@@ -0,0 +1,117 @@
+package util
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Client struct {
+	HttpClient  *http.Client
+	RateLimiter *RateLimiter
+
+	MaxRetries    int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	MaxRetryAfter time.Duration
+}
+
+var DefaultClient = &Client{
+	HttpClient:    &http.Client{Timeout: time.Minute},
+	RateLimiter:   NewRateLimiter(5, 5),
+	MaxRetries:    6,
+	BaseDelay:     500 * time.Millisecond,
+	MaxDelay:      30 * time.Second,
+	MaxRetryAfter: 2 * time.Minute,
+}
+
+func (self *Client) isRetryable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+func (self *Client) backoff(attempt int) time.Duration {
+	delay := self.BaseDelay << min(attempt, 30)
+
+	if delay <= 0 || delay > self.MaxDelay {
+		delay = self.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+func (self *Client) retryAfter(response *http.Response) (time.Duration, bool) {
+	header := response.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	var delay time.Duration
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		delay = time.Until(date)
+	} else {
+		return 0, false
+	}
+	return min(max(delay, 0), self.MaxRetryAfter), true
+}
+func (self *Client) do(urlStr string) (*http.Response, error) {
+	if parsed, err := url.Parse(urlStr); err != nil {
+		return nil, err
+	} else {
+		self.RateLimiter.Wait(parsed.Host)
+	}
+	return self.HttpClient.Get(urlStr)
+}
+func (self *Client) Get(urlStr string) (*http.Response, error) {
+	var lastErr error
+
+	for attempt := 0; ; attempt++ {
+		response, err := self.do(urlStr)
+		delay := self.backoff(attempt)
+
+		if err != nil {
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) && urlErr.Op == "parse" {
+				return nil, err
+			}
+			lastErr = err
+		} else if response.StatusCode == http.StatusOK {
+			return response, nil
+		} else {
+			response.Body.Close()
+
+			lastErr = &StatusError{
+				Url:        urlStr,
+				StatusCode: response.StatusCode,
+				Status:     response.Status,
+			}
+			if !self.isRetryable(response.StatusCode) {
+				return nil, lastErr
+			}
+			if retryAfter, found := self.retryAfter(response); found {
+				delay = retryAfter
+			}
+		}
+		if attempt >= self.MaxRetries {
+			return nil, &RetryLimitError{
+				Url:      urlStr,
+				Attempts: attempt + 1,
+				Err:      lastErr,
+			}
+		}
+		time.Sleep(delay)
+	}
+}
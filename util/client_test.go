@@ -0,0 +1,106 @@
+package util
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient() *Client {
+	return &Client{
+		HttpClient:    &http.Client{Timeout: time.Second},
+		MaxRetries:    3,
+		BaseDelay:     time.Millisecond,
+		MaxDelay:      5 * time.Millisecond,
+		MaxRetryAfter: time.Second,
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	response, err := newTestClient().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if calls.Load() != 3 {
+		t.Errorf("Get() made %d requests; want 3", calls.Load())
+	}
+}
+func TestClientRetryLimit(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient().Get(server.URL)
+
+	var retryErr *RetryLimitError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Get() error = %v; want RetryLimitError", err)
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Get() error does not wrap StatusError 503: %v", err)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("Get() made %d requests; want 4", calls.Load())
+	}
+}
+func TestClientNotRetryable(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient().Get(server.URL)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Get() error = %v; want StatusError 404", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Get() made %d requests; want 1", calls.Load())
+	}
+}
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(100, 2)
+	start := time.Now()
+
+	for range 4 {
+		limiter.Wait("example.com")
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("RateLimiter allowed 4 requests in %v; want at least 20ms", elapsed)
+	}
+	start = time.Now()
+	limiter.Wait("example.org")
+
+	if elapsed := time.Since(start); elapsed > 5*time.Millisecond {
+		t.Errorf("RateLimiter delayed a different host by %v", elapsed)
+	}
+}
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"fmt"
+)
+
+type StatusError struct {
+	Url        string
+	StatusCode int
+	Status     string
+}
+
+func (self *StatusError) Error() string {
+	return fmt.Sprintf("Status code not 200, %s (%s)", self.Status, self.Url)
+}
+
+type RetryLimitError struct {
+	Url      string
+	Attempts int
+	Err      error
+}
+
+func (self *RetryLimitError) Error() string {
+	return fmt.Sprintf("Request failed after %d attempts: %v", self.Attempts, self.Err)
+}
+func (self *RetryLimitError) Unwrap() error {
+	return self.Err
+}
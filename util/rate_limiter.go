@@ -0,0 +1,54 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+type tokenBucket struct {
+	Tokens     float64
+	LastRefill time.Time
+}
+
+type RateLimiter struct {
+	mutex   sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    requestsPerSecond,
+		burst:   float64(max(burst, 1)),
+		buckets: map[string]*tokenBucket{},
+	}
+}
+func (self *RateLimiter) reserve(host string) time.Duration {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	now := time.Now()
+	bucket, found := self.buckets[host]
+
+	if !found {
+		bucket = &tokenBucket{Tokens: self.burst, LastRefill: now}
+		self.buckets[host] = bucket
+	}
+	bucket.Tokens = min(self.burst, bucket.Tokens+now.Sub(bucket.LastRefill).Seconds()*self.rate)
+	bucket.LastRefill = now
+	bucket.Tokens--
+
+	if bucket.Tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.Tokens / self.rate * float64(time.Second))
+}
+func (self *RateLimiter) Wait(host string) {
+	if self == nil || self.rate <= 0 {
+		return
+	}
+	if delay := self.reserve(host); delay > 0 {
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"sync"
+)
+
+func RunParallel(count int, jobs int, fn func(index int) error) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+	var (
+		waitGroup sync.WaitGroup
+		errOnce   sync.Once
+		firstErr  error
+		done      = make(chan struct{})
+		indices   = make(chan int)
+	)
+	for range min(jobs, count) {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			for index := range indices {
+				if err := fn(index); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						close(done)
+					})
+				}
+			}
+		}()
+	}
+feed:
+	for index := range count {
+		select {
+		case indices <- index:
+		case <-done:
+			break feed
+		}
+	}
+	close(indices)
+	waitGroup.Wait()
+
+	return firstErr
+}
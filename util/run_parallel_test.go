@@ -0,0 +1,60 @@
+package util
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunParallel(t *testing.T) {
+	var running, peak atomic.Int32
+	results := make([]int, 100)
+
+	err := RunParallel(len(results), 4, func(index int) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			previous := peak.Load()
+			if current <= previous || peak.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		results[index] = index * 2
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() > 4 {
+		t.Errorf("RunParallel() ran %d jobs at once; want at most 4", peak.Load())
+	}
+	for index, result := range results {
+		if result != index*2 {
+			t.Fatalf("RunParallel() results[%d] = %d; want %d", index, result, index*2)
+		}
+	}
+}
+func TestRunParallelError(t *testing.T) {
+	expected := errors.New("failed")
+	var calls atomic.Int32
+
+	err := RunParallel(1000, 2, func(index int) error {
+		calls.Add(1)
+		if index == 3 {
+			return expected
+		}
+		return nil
+	})
+	if err != expected {
+		t.Errorf("RunParallel() error = %v; want %v", err, expected)
+	}
+	if calls.Load() == 1000 {
+		t.Errorf("RunParallel() did not stop scheduling after error")
+	}
+}
+func TestRunParallelEmpty(t *testing.T) {
+	if err := RunParallel(0, 4, func(int) error { return errors.New("called") }); err != nil {
+		t.Errorf("RunParallel(0) error = %v", err)
+	}
+}
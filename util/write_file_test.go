@@ -0,0 +1,43 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "PathInfo.json")
+
+	write := func(content string, failure error) error {
+		return WriteFileAtomic(path, func(writer io.Writer) error {
+			if _, err := io.WriteString(writer, content); err != nil {
+				return err
+			}
+			return failure
+		})
+	}
+	if err := write("first", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := write("trunc", errors.New("interrupted")); err == nil {
+		t.Errorf("WriteFileAtomic() expected error")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "first" {
+		t.Errorf("file after failed write = %q, %v; want \"first\"", data, err)
+	}
+	if err := write("second", nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "second" {
+		t.Errorf("file after write = %q, %v; want \"second\"", data, err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 {
+		t.Errorf("directory has %d entries; want only the written file", len(entries))
+	}
+}